@@ -0,0 +1,102 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/skycoin/exchange-api/exchange"
+	"github.com/skycoin/exchange-api/exchange/c2cx"
+	"github.com/skycoin/exchange-api/exchange/cryptopia"
+	"github.com/skycoin/exchange-api/server"
+)
+
+const (
+	readTimeout  = 30 * time.Second
+	writeTimeout = 150 * time.Second
+	idleTimeout  = 120 * time.Second
+)
+
+// credentials returns the key and secret of an exchange from the environment variables
+// <NAME>_API_KEY and <NAME>_API_SECRET, falling back to <name>.key and <name>.secret in the config file
+func credentials(name, envPrefix string) (string, string) {
+	key, secret := os.Getenv(envPrefix+"_API_KEY"), os.Getenv(envPrefix+"_API_SECRET")
+	if key != "" && secret != "" {
+		return key, secret
+	}
+	return viper.GetString(name + ".key"), viper.GetString(name + ".secret")
+}
+
+// defaultConfigPath returns ~/.exchangectl/config.toml, using $HOME if the current user can't be looked up
+func defaultConfigPath() (string, error) {
+	home := os.Getenv("HOME")
+	if usr, err := user.Current(); err == nil {
+		home = usr.HomeDir
+	} else if home == "" {
+		return "", fmt.Errorf("failed to get the current user and $HOME is not set. err: %v", err)
+	}
+	return filepath.Join(home, ".exchangectl/config.toml"), nil
+}
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:6061", "address to listen on")
+	config := flag.String("config", "", "config file with the exchange credentials and the server token (default ~/.exchangectl/config.toml)")
+	flag.Parse()
+
+	if *config == "" {
+		path, err := defaultConfigPath()
+		if err != nil {
+			log.Printf("%v, using environment variables only", err)
+		}
+		*config = path
+	}
+
+	if *config != "" {
+		viper.SetConfigFile(*config)
+		if err := viper.ReadInConfig(); err != nil {
+			log.Printf("failed to read the config file %s, using environment variables only. err: %v", *config, err)
+		}
+	}
+
+	var exchanges []exchange.Exchange
+
+	key, secret := credentials("c2cx", "C2CX")
+	if key == "" || secret == "" {
+		log.Println("c2cx credentials not found, private endpoints will fail")
+	}
+	exchanges = append(exchanges, c2cx.NewAdapter(c2cx.NewAPIClient(key, secret)))
+
+	key, secret = credentials("cryptopia", "CRYPTOPIA")
+	if key == "" || secret == "" {
+		log.Println("cryptopia credentials not found, private endpoints will fail")
+	}
+	exchanges = append(exchanges, cryptopia.NewAdapter(cryptopia.NewAPIClient(key, secret)))
+
+	handler := server.New(exchanges...)
+	handler.Logger = log.New(os.Stdout, "[exchange-api-server] ", log.LstdFlags|log.LUTC)
+	handler.Token = os.Getenv("EXCHANGE_API_TOKEN")
+	if handler.Token == "" {
+		handler.Token = viper.GetString("server.token")
+	}
+	if handler.Token == "" {
+		log.Println("server token not found in EXCHANGE_API_TOKEN or server.token, orders can't be placed or cancelled")
+	}
+
+	srv := &http.Server{
+		Addr:         *addr,
+		Handler:      handler,
+		ReadTimeout:  readTimeout,
+		WriteTimeout: writeTimeout,
+		IdleTimeout:  idleTimeout,
+	}
+
+	log.Printf("listening on %s", *addr)
+	log.Fatal(srv.ListenAndServe())
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/skycoin/exchange-api/exchange"
+	"github.com/skycoin/exchange-api/exchange/c2cx"
+	"github.com/skycoin/exchange-api/exchange/cryptopia"
+	"github.com/skycoin/exchange-api/exchange/recorder"
+)
+
+// pairs decodes a comma separated list of symbols with an exchange's codec
+func pairs(codec exchange.PairCodec, list string) []exchange.Pair {
+	var result []exchange.Pair
+	for _, s := range strings.Split(list, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		pair, err := codec.DecodePair(s)
+		if err != nil {
+			log.Fatalf("invalid symbol %q. err: %v", s, err)
+		}
+		result = append(result, pair)
+	}
+	return result
+}
+
+func main() {
+	dir := flag.String("dir", "data", "directory to write the recordings to")
+	prefix := flag.String("prefix", "market", "prefix of the recording file names")
+	interval := flag.Duration("interval", recorder.DefaultPollInterval, "interval between two polls")
+	c2cxSymbols := flag.String("c2cx", "BTC_SKY", "comma separated c2cx trade pairs to record the orderbook and ticker of")
+	cryptopiaSymbols := flag.String("cryptopia", "SKY/BTC", "comma separated cryptopia markets to record the orderbook and trades of")
+	hours := flag.Int("history-hours", 1, "hours of cryptopia market history fetched by each poll, must exceed the interval")
+	maxSize := flag.Int64("max-size", recorder.DefaultMaxSize, "uncompressed size in bytes after which a new file is started")
+	maxAge := flag.Duration("max-age", recorder.DefaultMaxAge, "age after which a new file is started")
+	flag.Parse()
+
+	var sources []recorder.Source
+
+	c2cxAdapter := c2cx.NewAdapter(c2cx.NewAPIClient("", ""))
+	for _, pair := range pairs(c2cxAdapter.Codec(), *c2cxSymbols) {
+		sources = append(sources,
+			recorder.OrderbookSource(c2cxAdapter, pair),
+			recorder.TickerSource(c2cxAdapter, pair),
+		)
+	}
+
+	cryptopiaClient := cryptopia.NewAPIClient("", "")
+	cryptopiaAdapter := cryptopia.NewAdapter(cryptopiaClient)
+	for _, pair := range pairs(cryptopiaAdapter.Codec(), *cryptopiaSymbols) {
+		sources = append(sources,
+			recorder.OrderbookSource(cryptopiaAdapter, pair),
+			recorder.NewHistorySource(cryptopiaClient, cryptopia.EncodePair(pair), *hours),
+		)
+	}
+
+	if len(sources) == 0 {
+		log.Fatal("no markets to record")
+	}
+
+	w := recorder.NewWriter(*dir, *prefix)
+	w.MaxSize = *maxSize
+	w.MaxAge = *maxAge
+
+	r := recorder.NewRecorder(w, *interval, sources...)
+	r.OnError = func(err error) {
+		log.Printf("poll failed. err: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		r.Run()
+		close(done)
+	}()
+
+	log.Printf("recording %d sources to %s every %s", len(sources), *dir, *interval)
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	<-sig
+
+	close(r.Stop)
+
+	select {
+	case <-done:
+	case <-time.After(time.Minute):
+		log.Fatal("timed out waiting for the last poll")
+	}
+
+	log.Printf("recorded %d records", r.Records())
+}
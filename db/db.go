@@ -0,0 +1,295 @@
+// Package db provides an embedded, file-backed store for orders, fills, balances and orderbook snapshots.
+// Each kind of record is appended as a JSON line to its own file in the store's directory.
+// Orders, fills and balances are indexed in memory when the store is opened,
+// orderbook snapshots are indexed by file offset and read back from disk on demand.
+package db
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/skycoin/exchange-api/exchange"
+)
+
+const (
+	ordersFile     = "orders.jsonl"
+	fillsFile      = "fills.jsonl"
+	balancesFile   = "balances.jsonl"
+	orderbooksFile = "orderbooks.jsonl"
+)
+
+var (
+	// ErrNotFound is returned when a record does not exist
+	ErrNotFound = errors.New("record not found")
+	// ErrClosed is returned when the Store was closed
+	ErrClosed = errors.New("store is closed")
+)
+
+// Query selects records by exchange, symbol and time range.
+// Empty fields match everything, From is inclusive and To is exclusive.
+type Query struct {
+	Exchange string
+	Symbol   string
+	From     time.Time
+	To       time.Time
+}
+
+func (q Query) matchSeries(exchangeName, symbol string) bool {
+	return (q.Exchange == "" || q.Exchange == exchangeName) && (q.Symbol == "" || q.Symbol == symbol)
+}
+
+func (q Query) matchTime(t time.Time) bool {
+	return (q.From.IsZero() || !t.Before(q.From)) && (q.To.IsZero() || t.Before(q.To))
+}
+
+type seriesKey struct {
+	exchange string
+	symbol   string
+}
+
+type orderKey struct {
+	exchange string
+	orderID  exchange.OrderID
+}
+
+type snapshotIndex struct {
+	timestamp time.Time
+	offset    int64
+	length    int64
+}
+
+// Store is an embedded, file-backed store. It is safe for concurrent use.
+type Store struct {
+	dir string
+
+	mu         sync.RWMutex
+	closed     bool
+	orders     map[orderKey]OrderRecord
+	fills      map[seriesKey][]Fill
+	balances   map[string][]BalanceSnapshot
+	orderbooks map[seriesKey][]snapshotIndex
+
+	ordersFile     *os.File
+	fillsFile      *os.File
+	balancesFile   *os.File
+	orderbooksFile *os.File
+	orderbooksSize int64
+
+	now func() time.Time
+}
+
+// Open opens the Store in dir, creating it if it doesn't exist, and loads its indexes
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, err
+	}
+
+	s := &Store{
+		dir:        dir,
+		orders:     make(map[orderKey]OrderRecord),
+		fills:      make(map[seriesKey][]Fill),
+		balances:   make(map[string][]BalanceSnapshot),
+		orderbooks: make(map[seriesKey][]snapshotIndex),
+		now:        time.Now,
+	}
+
+	var err error
+	if s.ordersFile, err = s.load(ordersFile, s.loadOrder); err != nil {
+		s.Close() // nolint: errcheck
+		return nil, err
+	}
+	if s.fillsFile, err = s.load(fillsFile, s.loadFill); err != nil {
+		s.Close() // nolint: errcheck
+		return nil, err
+	}
+	if s.balancesFile, err = s.load(balancesFile, s.loadBalances); err != nil {
+		s.Close() // nolint: errcheck
+		return nil, err
+	}
+	if s.orderbooksFile, err = s.load(orderbooksFile, s.loadOrderbook); err != nil {
+		s.Close() // nolint: errcheck
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Dir returns the Store's directory
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Close syncs and closes the Store's files
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	var firstErr error
+	for _, f := range []*os.File{s.ordersFile, s.fillsFile, s.balancesFile, s.orderbooksFile} {
+		if f == nil {
+			continue
+		}
+		if err := f.Sync(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if err := f.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// load opens a file for appending after passing each of its lines to f.
+// A truncated last line, left by a crash during a write, is discarded.
+func (s *Store) load(name string, f func(line []byte, offset int64) error) (*os.File, error) {
+	file, err := os.OpenFile(filepath.Join(s.dir, name), os.O_RDWR|os.O_CREATE, 0640)
+	if err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(file)
+	var offset int64
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			file.Close() // nolint: errcheck
+			return nil, err
+		}
+
+		if err := f(line[:len(line)-1], offset); err != nil {
+			file.Close() // nolint: errcheck
+			return nil, err
+		}
+		offset += int64(len(line))
+	}
+
+	if err := file.Truncate(offset); err != nil {
+		file.Close() // nolint: errcheck
+		return nil, err
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close() // nolint: errcheck
+		return nil, err
+	}
+
+	if name == orderbooksFile {
+		s.orderbooksSize = offset
+	}
+
+	return file, nil
+}
+
+// appendLine writes v as a JSON line to f, returning the line's length. A partial write, e.g. when the disk
+// is full, is truncated so that the next line isn't appended to it. The caller must hold the write lock.
+func (s *Store) appendLine(f *os.File, v interface{}) (int64, error) {
+	if s.closed {
+		return 0, ErrClosed
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return 0, err
+	}
+	b = append(b, '\n')
+
+	offset, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+
+	if n, err := f.Write(b); err != nil {
+		if n > 0 {
+			truncate(f, offset) // nolint: errcheck
+		}
+		return 0, err
+	}
+
+	return int64(len(b)), nil
+}
+
+// truncate discards the end of f from offset and moves the write position back to it
+func truncate(f *os.File, offset int64) error {
+	if err := f.Truncate(offset); err != nil {
+		return err
+	}
+	_, err := f.Seek(offset, io.SeekStart)
+	return err
+}
+
+// rewrite atomically replaces the file name, currently opened as old, with the given records
+// and returns the new file opened for appending. The caller must hold the write lock.
+func (s *Store) rewrite(name string, old *os.File, records []interface{}) (*os.File, error) {
+	path := filepath.Join(s.dir, name)
+	tmpPath := path + ".tmp"
+
+	tmp, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0640)
+	if err != nil {
+		return nil, err
+	}
+
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			tmp.Close() // nolint: errcheck
+			return nil, err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		tmp.Close() // nolint: errcheck
+		return nil, err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close() // nolint: errcheck
+		return nil, err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		tmp.Close() // nolint: errcheck
+		return nil, err
+	}
+
+	old.Close() // nolint: errcheck
+	return tmp, nil
+}
+
+// Sync flushes the Store's files to disk
+func (s *Store) Sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+
+	for _, f := range []*os.File{s.ordersFile, s.fillsFile, s.balancesFile, s.orderbooksFile} {
+		if err := f.Sync(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// searchTime returns the index of the first of n time ordered elements which is not before t
+func searchTime(n int, at func(int) time.Time, t time.Time) int {
+	return sort.Search(n, func(i int) bool {
+		return !at(i).Before(t)
+	})
+}
@@ -0,0 +1,153 @@
+package db
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/exchange-api/exchange"
+)
+
+func newTestStore(t *testing.T) (*Store, func()) {
+	dir, err := ioutil.TempDir("", "exchange-api-db")
+	require.NoError(t, err)
+
+	s, err := Open(dir)
+	require.NoError(t, err)
+
+	return s, func() {
+		s.Close()         // nolint: errcheck
+		os.RemoveAll(dir) // nolint: errcheck
+	}
+}
+
+func newTestRecord(symbol string, ts time.Time, bid int64) exchange.MarketRecord {
+	return exchange.MarketRecord{
+		Timestamp: ts,
+		Symbol:    symbol,
+		Bids: []exchange.MarketOrder{
+			{Price: decimal.New(bid, -5), Volume: decimal.New(10, 0)},
+		},
+		Asks: []exchange.MarketOrder{
+			{Price: decimal.New(bid+1, -5), Volume: decimal.New(5, 0)},
+		},
+	}
+}
+
+func TestOrderbooks(t *testing.T) {
+	s, cleanup := newTestStore(t)
+	defer cleanup()
+
+	start := time.Unix(1521563904, 0)
+	for i := 0; i < 5; i++ {
+		ts := start.Add(time.Duration(i) * time.Minute)
+		require.NoError(t, s.AddOrderbook("c2cx", newTestRecord("BTC_SKY", ts, 100+int64(i))))
+		require.NoError(t, s.AddOrderbook("cryptopia", newTestRecord("SKY/BTC", ts, 200+int64(i))))
+	}
+
+	all, err := s.Orderbooks(Query{})
+	require.NoError(t, err)
+	require.Len(t, all, 10)
+
+	books, err := s.Orderbooks(Query{
+		Exchange: "c2cx",
+		Symbol:   "BTC_SKY",
+		From:     start.Add(time.Minute),
+		To:       start.Add(time.Minute * 3),
+	})
+	require.NoError(t, err)
+	require.Len(t, books, 2)
+	require.Equal(t, start.Add(time.Minute).Unix(), books[0].Record.Timestamp.Unix())
+	require.True(t, books[0].Record.Bids[0].Price.Equal(decimal.New(101, -5)))
+	require.True(t, books[1].Record.Bids[0].Price.Equal(decimal.New(102, -5)))
+
+	latest, err := s.LatestOrderbook("cryptopia", "SKY/BTC")
+	require.NoError(t, err)
+	require.True(t, latest.Record.Bids[0].Price.Equal(decimal.New(204, -5)))
+
+	_, err = s.LatestOrderbook("cryptopia", "LTC/BTC")
+	require.Equal(t, ErrNotFound, err)
+}
+
+func TestBalances(t *testing.T) {
+	s, cleanup := newTestStore(t)
+	defer cleanup()
+
+	start := time.Unix(1521563904, 0).UTC()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, s.AddBalances(BalanceSnapshot{
+			Exchange:  "c2cx",
+			Timestamp: start.Add(time.Duration(i) * time.Hour),
+			Balances: exchange.Balances{
+				"SKY": {Currency: "SKY", Total: decimal.New(int64(i), 0)},
+			},
+		}))
+	}
+
+	snapshots := s.Balances(Query{Exchange: "c2cx", From: start.Add(time.Hour)})
+	require.Len(t, snapshots, 2)
+	require.True(t, snapshots[0].Balances["SKY"].Total.Equal(decimal.New(1, 0)))
+
+	latest, err := s.LatestBalances("c2cx")
+	require.NoError(t, err)
+	require.True(t, latest.Balances["SKY"].Total.Equal(decimal.New(2, 0)))
+
+	_, err = s.LatestBalances("cryptopia")
+	require.Equal(t, ErrNotFound, err)
+}
+
+func TestReopen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exchange-api-db")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	s, err := Open(dir)
+	require.NoError(t, err)
+
+	ts := time.Unix(1521563904, 0).UTC()
+	order := exchange.Order{
+		OrderID: 1,
+		Symbol:  "BTC_SKY",
+		Action:  exchange.ActionBuy,
+		Status:  exchange.StatusOpened,
+		Price:   decimal.New(102, -5),
+		Volume:  decimal.New(2, 0),
+		Created: ts,
+	}
+	require.NoError(t, s.SaveOrder("c2cx", order))
+	require.NoError(t, s.SetOrderOwner("c2cx", 1, "bot"))
+	require.NoError(t, s.AddOrderbook("c2cx", newTestRecord("BTC_SKY", ts, 100)))
+	require.NoError(t, s.AddBalances(BalanceSnapshot{Exchange: "c2cx", Timestamp: ts}))
+	require.NoError(t, s.Close())
+	require.Equal(t, ErrClosed, s.AddBalances(BalanceSnapshot{Exchange: "c2cx"}))
+
+	// Simulate a crash in the middle of a write
+	f, err := os.OpenFile(filepath.Join(dir, orderbooksFile), os.O_APPEND|os.O_WRONLY, 0640)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"exchange":"c2cx","rec`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	s, err = Open(dir)
+	require.NoError(t, err)
+	defer s.Close() // nolint: errcheck
+
+	owned := s.OwnedOrders("bot")
+	require.Len(t, owned, 1)
+	require.Equal(t, exchange.OrderID(1), owned[0].OrderID)
+	require.True(t, owned[0].Price.Equal(order.Price))
+
+	require.Len(t, s.Balances(Query{}), 1)
+
+	// The truncated line was discarded, new snapshots are appended after the last complete one
+	require.NoError(t, s.AddOrderbook("c2cx", newTestRecord("BTC_SKY", ts.Add(time.Second), 101)))
+	books, err := s.Orderbooks(Query{Exchange: "c2cx"})
+	require.NoError(t, err)
+	require.Len(t, books, 2)
+	require.True(t, books[1].Record.Bids[0].Price.Equal(decimal.New(101, -5)))
+}
@@ -0,0 +1,276 @@
+package db
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/skycoin/exchange-api/exchange"
+)
+
+// OrderRecord is the latest known state of an order placed on an exchange
+type OrderRecord struct {
+	Exchange string    `json:"exchange"`
+	Owner    string    `json:"owner,omitempty"`
+	Updated  time.Time `json:"updated"`
+	exchange.Order
+}
+
+// Fill is an execution of part of an order
+type Fill struct {
+	Exchange  string           `json:"exchange"`
+	Symbol    string           `json:"symbol"`
+	OrderID   exchange.OrderID `json:"order_id"`
+	Action    exchange.Action  `json:"action"`
+	Price     decimal.Decimal  `json:"price"`
+	Volume    decimal.Decimal  `json:"volume"`
+	Fee       decimal.Decimal  `json:"fee"`
+	Timestamp time.Time        `json:"timestamp"`
+}
+
+func (s *Store) loadOrder(line []byte, _ int64) error {
+	var r OrderRecord
+	if err := json.Unmarshal(line, &r); err != nil {
+		return err
+	}
+	s.orders[orderKey{r.Exchange, r.OrderID}] = r
+	return nil
+}
+
+func (s *Store) loadFill(line []byte, _ int64) error {
+	var f Fill
+	if err := json.Unmarshal(line, &f); err != nil {
+		return err
+	}
+	s.insertFill(f)
+	return nil
+}
+
+// SaveOrder records the latest state of an order, keeping its owner.
+// If the filled volume grew since the order was last saved, the difference is recorded as a Fill,
+// priced from the order's average price. If the Fill can't be recorded, the order is not updated either.
+func (s *Store) SaveOrder(exchangeName string, o exchange.Order) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := orderKey{exchangeName, o.OrderID}
+	prev, exists := s.orders[key]
+
+	if s.closed {
+		return ErrClosed
+	}
+	offset, err := s.ordersFile.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	now := s.now().UTC()
+	r := OrderRecord{
+		Exchange: exchangeName,
+		Owner:    prev.Owner,
+		Updated:  now,
+		Order:    o,
+	}
+
+	if err := s.putOrder(r); err != nil {
+		return err
+	}
+
+	var prevFilled, prevCost, prevFee decimal.Decimal
+	if exists {
+		prevFilled = prev.Filled
+		prevCost = averagePrice(prev.Order).Mul(prev.Filled)
+		prevFee = prev.Fee
+	}
+
+	volume := o.Filled.Sub(prevFilled)
+	if !volume.GreaterThan(decimal.Zero) {
+		return nil
+	}
+
+	cost := averagePrice(o).Mul(o.Filled).Sub(prevCost)
+	err = s.addFill(Fill{
+		Exchange:  exchangeName,
+		Symbol:    o.Symbol,
+		OrderID:   o.OrderID,
+		Action:    o.Action,
+		Price:     cost.Div(volume),
+		Volume:    volume,
+		Fee:       o.Fee.Sub(prevFee),
+		Timestamp: now,
+	})
+	if err != nil {
+		// Roll the order back, so that the next SaveOrder records the fill
+		if exists {
+			s.orders[key] = prev
+		} else {
+			delete(s.orders, key)
+		}
+		truncate(s.ordersFile, offset) // nolint: errcheck
+		return err
+	}
+
+	return nil
+}
+
+// SetOrderOwner assigns an order to an owner, e.g. the name of the bot which placed it
+func (s *Store) SetOrderOwner(exchangeName string, orderID exchange.OrderID, owner string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.orders[orderKey{exchangeName, orderID}]
+	if !ok {
+		return ErrNotFound
+	}
+
+	r.Owner = owner
+	r.Updated = s.now().UTC()
+	return s.putOrder(r)
+}
+
+func (s *Store) putOrder(r OrderRecord) error {
+	if _, err := s.appendLine(s.ordersFile, r); err != nil {
+		return err
+	}
+	s.orders[orderKey{r.Exchange, r.OrderID}] = r
+	return nil
+}
+
+// Order returns the latest state of an order
+func (s *Store) Order(exchangeName string, orderID exchange.OrderID) (*OrderRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	r, ok := s.orders[orderKey{exchangeName, orderID}]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &r, nil
+}
+
+// Orders returns the orders matching q, selected and ordered by creation time
+func (s *Store) Orders(q Query) []OrderRecord {
+	return s.filterOrders(func(r OrderRecord) bool {
+		return q.matchSeries(r.Exchange, r.Symbol) && q.matchTime(r.Created)
+	})
+}
+
+// OpenOrders returns the orders of an exchange which were open when last saved.
+// An empty exchangeName matches all exchanges.
+func (s *Store) OpenOrders(exchangeName string) []OrderRecord {
+	return s.filterOrders(func(r OrderRecord) bool {
+		return (exchangeName == "" || r.Exchange == exchangeName) && r.Status.Open()
+	})
+}
+
+// OwnedOrders returns the orders assigned to owner
+func (s *Store) OwnedOrders(owner string) []OrderRecord {
+	return s.filterOrders(func(r OrderRecord) bool {
+		return r.Owner == owner
+	})
+}
+
+func (s *Store) filterOrders(f func(OrderRecord) bool) []OrderRecord {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []OrderRecord
+	for _, r := range s.orders {
+		if f(r) {
+			result = append(result, r)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Created.Equal(result[j].Created) {
+			return result[i].OrderID < result[j].OrderID
+		}
+		return result[i].Created.Before(result[j].Created)
+	})
+
+	return result
+}
+
+// CompactOrders rewrites the orders file keeping only the latest state of each order
+func (s *Store) CompactOrders() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+
+	records := make([]interface{}, 0, len(s.orders))
+	for _, r := range s.orders {
+		records = append(records, r)
+	}
+
+	f, err := s.rewrite(ordersFile, s.ordersFile, records)
+	if err != nil {
+		return err
+	}
+	s.ordersFile = f
+	return nil
+}
+
+// AddFill records a fill which was not derived from SaveOrder
+func (s *Store) AddFill(f Fill) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addFill(f)
+}
+
+func (s *Store) addFill(f Fill) error {
+	if _, err := s.appendLine(s.fillsFile, f); err != nil {
+		return err
+	}
+	s.insertFill(f)
+	return nil
+}
+
+func (s *Store) insertFill(f Fill) {
+	key := seriesKey{f.Exchange, f.Symbol}
+	fills := s.fills[key]
+	i := searchTime(len(fills), func(i int) time.Time {
+		return fills[i].Timestamp
+	}, f.Timestamp.Add(1))
+
+	fills = append(fills, Fill{})
+	copy(fills[i+1:], fills[i:])
+	fills[i] = f
+	s.fills[key] = fills
+}
+
+// Fills returns the fills matching q, ordered by time
+func (s *Store) Fills(q Query) []Fill {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []Fill
+	for key, fills := range s.fills {
+		if !q.matchSeries(key.exchange, key.symbol) {
+			continue
+		}
+		for _, f := range fills {
+			if q.matchTime(f.Timestamp) {
+				result = append(result, f)
+			}
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Timestamp.Before(result[j].Timestamp)
+	})
+
+	return result
+}
+
+func averagePrice(o exchange.Order) decimal.Decimal {
+	if o.AvgPrice.GreaterThan(decimal.Zero) {
+		return o.AvgPrice
+	}
+	return o.Price
+}
@@ -0,0 +1,154 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/exchange-api/exchange"
+)
+
+func TestSaveOrder(t *testing.T) {
+	s, cleanup := newTestStore(t)
+	defer cleanup()
+
+	now := time.Unix(1521563904, 0).UTC()
+	s.now = func() time.Time { return now }
+
+	order := exchange.Order{
+		OrderID: 42,
+		Symbol:  "SKY/BTC",
+		Action:  exchange.ActionSell,
+		Status:  exchange.StatusOpened,
+		Price:   decimal.New(2, -3),
+		Volume:  decimal.New(10, 0),
+		Created: now,
+	}
+	require.NoError(t, s.SaveOrder("cryptopia", order))
+	require.Empty(t, s.Fills(Query{}))
+	require.Len(t, s.OpenOrders("cryptopia"), 1)
+
+	// First fill of 4 at 0.002
+	now = now.Add(time.Minute)
+	order.Status = exchange.StatusPartial
+	order.Filled = decimal.New(4, 0)
+	order.AvgPrice = decimal.New(2, -3)
+	order.Fee = decimal.New(1, -5)
+	require.NoError(t, s.SaveOrder("cryptopia", order))
+
+	// Second fill of 6 at 0.003, average price is now 0.0026
+	now = now.Add(time.Minute)
+	order.Status = exchange.StatusCompleted
+	order.Filled = decimal.New(10, 0)
+	order.AvgPrice = decimal.New(26, -4)
+	order.Fee = decimal.New(3, -5)
+	require.NoError(t, s.SaveOrder("cryptopia", order))
+
+	fills := s.Fills(Query{Exchange: "cryptopia", Symbol: "SKY/BTC"})
+	require.Len(t, fills, 2)
+	require.True(t, fills[0].Volume.Equal(decimal.New(4, 0)))
+	require.True(t, fills[0].Price.Equal(decimal.New(2, -3)))
+	require.True(t, fills[1].Volume.Equal(decimal.New(6, 0)))
+	require.True(t, fills[1].Price.Equal(decimal.New(3, -3)))
+	require.True(t, fills[1].Fee.Equal(decimal.New(2, -5)))
+	require.Equal(t, exchange.ActionSell, fills[1].Action)
+
+	require.Len(t, s.Fills(Query{From: now}), 1)
+	require.Empty(t, s.OpenOrders("cryptopia"))
+
+	r, err := s.Order("cryptopia", 42)
+	require.NoError(t, err)
+	require.Equal(t, exchange.StatusCompleted, r.Status)
+	require.Equal(t, now, r.Updated)
+
+	_, err = s.Order("c2cx", 42)
+	require.Equal(t, ErrNotFound, err)
+	require.Equal(t, ErrNotFound, s.SetOrderOwner("c2cx", 42, "bot"))
+}
+
+func TestSaveOrder_FillFails(t *testing.T) {
+	s, cleanup := newTestStore(t)
+	defer cleanup()
+
+	order := exchange.Order{
+		OrderID: 42,
+		Symbol:  "SKY/BTC",
+		Action:  exchange.ActionBuy,
+		Status:  exchange.StatusOpened,
+		Price:   decimal.New(2, -3),
+		Volume:  decimal.New(10, 0),
+	}
+	require.NoError(t, s.SaveOrder("cryptopia", order))
+
+	// fills can't be written to a read only file
+	writable := s.fillsFile
+	readOnly, err := os.Open(filepath.Join(s.Dir(), fillsFile))
+	require.NoError(t, err)
+	defer readOnly.Close() // nolint: errcheck
+	s.fillsFile = readOnly
+
+	order.Status = exchange.StatusPartial
+	order.Filled = decimal.New(4, 0)
+	require.Error(t, s.SaveOrder("cryptopia", order))
+	r, err := s.Order("cryptopia", 42)
+	require.NoError(t, err)
+	require.Equal(t, exchange.StatusOpened, r.Status)
+
+	// the fill is recorded once it can be written
+	s.fillsFile = writable
+	require.NoError(t, s.SaveOrder("cryptopia", order))
+	require.Len(t, s.Fills(Query{}), 1)
+
+	dir := s.Dir()
+	require.NoError(t, s.Close())
+	s, err = Open(dir)
+	require.NoError(t, err)
+	r, err = s.Order("cryptopia", 42)
+	require.NoError(t, err)
+	require.Equal(t, exchange.StatusPartial, r.Status)
+	require.Len(t, s.Fills(Query{}), 1)
+	require.NoError(t, s.Close())
+}
+
+func TestOrdersQuery(t *testing.T) {
+	s, cleanup := newTestStore(t)
+	defer cleanup()
+
+	start := time.Unix(1521563904, 0).UTC()
+	for i := 0; i < 4; i++ {
+		require.NoError(t, s.SaveOrder("c2cx", exchange.Order{
+			OrderID: exchange.OrderID(10 - i),
+			Symbol:  "BTC_SKY",
+			Status:  exchange.StatusOpened,
+			Created: start.Add(time.Duration(i) * time.Hour),
+		}))
+	}
+	require.NoError(t, s.SaveOrder("c2cx", exchange.Order{OrderID: 1, Symbol: "BTC_ETH", Created: start}))
+
+	orders := s.Orders(Query{Exchange: "c2cx", Symbol: "BTC_SKY", From: start.Add(time.Hour)})
+	require.Len(t, orders, 3)
+	require.Equal(t, exchange.OrderID(9), orders[0].OrderID)
+	require.Equal(t, exchange.OrderID(7), orders[2].OrderID)
+
+	require.Len(t, s.Orders(Query{}), 5)
+
+	require.NoError(t, s.SetOrderOwner("c2cx", 8, "bot"))
+	require.NoError(t, s.CompactOrders())
+	require.Len(t, s.Orders(Query{}), 5)
+	require.Len(t, s.OwnedOrders("bot"), 1)
+
+	// The compacted file remains appendable and readable
+	require.NoError(t, s.SaveOrder("c2cx", exchange.Order{OrderID: 2, Symbol: "BTC_ETH", Created: start}))
+	dir := s.Dir()
+	require.NoError(t, s.Close())
+
+	s, err := Open(dir)
+	require.NoError(t, err)
+	defer s.Close() // nolint: errcheck
+	require.Len(t, s.Orders(Query{}), 6)
+	require.Len(t, s.OwnedOrders("bot"), 1)
+}
@@ -0,0 +1,220 @@
+package db
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/skycoin/exchange-api/exchange"
+)
+
+// BalanceSnapshot is the account's balances on an exchange at a point in time
+type BalanceSnapshot struct {
+	Exchange  string            `json:"exchange"`
+	Timestamp time.Time         `json:"timestamp"`
+	Balances  exchange.Balances `json:"balances"`
+}
+
+// OrderbookSnapshot is an exchange's orderbook at a point in time.
+// Record.Timestamp has a resolution of one second when read back, as defined by MarketRecord's JSON encoding.
+type OrderbookSnapshot struct {
+	Exchange string                `json:"exchange"`
+	Record   exchange.MarketRecord `json:"record"`
+}
+
+func (s *Store) loadBalances(line []byte, _ int64) error {
+	var b BalanceSnapshot
+	if err := json.Unmarshal(line, &b); err != nil {
+		return err
+	}
+	s.insertBalances(b)
+	return nil
+}
+
+func (s *Store) loadOrderbook(line []byte, offset int64) error {
+	var o OrderbookSnapshot
+	if err := json.Unmarshal(line, &o); err != nil {
+		return err
+	}
+	s.insertOrderbook(o, offset, int64(len(line)))
+	return nil
+}
+
+// AddBalances records a snapshot of the balances of an exchange. A zero Timestamp is set to the current time.
+func (s *Store) AddBalances(b BalanceSnapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if b.Timestamp.IsZero() {
+		b.Timestamp = s.now().UTC()
+	}
+
+	if _, err := s.appendLine(s.balancesFile, b); err != nil {
+		return err
+	}
+	s.insertBalances(b)
+	return nil
+}
+
+func (s *Store) insertBalances(b BalanceSnapshot) {
+	snapshots := s.balances[b.Exchange]
+	i := searchTime(len(snapshots), func(i int) time.Time {
+		return snapshots[i].Timestamp
+	}, b.Timestamp.Add(1))
+
+	snapshots = append(snapshots, BalanceSnapshot{})
+	copy(snapshots[i+1:], snapshots[i:])
+	snapshots[i] = b
+	s.balances[b.Exchange] = snapshots
+}
+
+// Balances returns the balance snapshots matching q, ordered by time. q.Symbol is ignored.
+func (s *Store) Balances(q Query) []BalanceSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []BalanceSnapshot
+	for exchangeName, snapshots := range s.balances {
+		if q.Exchange != "" && q.Exchange != exchangeName {
+			continue
+		}
+		for _, b := range snapshots {
+			if q.matchTime(b.Timestamp) {
+				result = append(result, b)
+			}
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Timestamp.Before(result[j].Timestamp)
+	})
+
+	return result
+}
+
+// LatestBalances returns the most recent balance snapshot of an exchange
+func (s *Store) LatestBalances(exchangeName string) (*BalanceSnapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snapshots := s.balances[exchangeName]
+	if len(snapshots) == 0 {
+		return nil, ErrNotFound
+	}
+
+	b := snapshots[len(snapshots)-1]
+	return &b, nil
+}
+
+// AddOrderbook records a snapshot of an exchange's orderbook, keyed by the record's Symbol and Timestamp
+func (s *Store) AddOrderbook(exchangeName string, record exchange.MarketRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o := OrderbookSnapshot{
+		Exchange: exchangeName,
+		Record:   record,
+	}
+
+	n, err := s.appendLine(s.orderbooksFile, o)
+	if err != nil {
+		return err
+	}
+
+	s.insertOrderbook(o, s.orderbooksSize, n-1)
+	s.orderbooksSize += n
+	return nil
+}
+
+func (s *Store) insertOrderbook(o OrderbookSnapshot, offset, length int64) {
+	key := seriesKey{o.Exchange, o.Record.Symbol}
+	// Index by the resolution which is persisted, so that queries match before and after a restart
+	ts := time.Unix(o.Record.Timestamp.Unix(), 0)
+
+	index := s.orderbooks[key]
+	i := searchTime(len(index), func(i int) time.Time {
+		return index[i].timestamp
+	}, ts.Add(1))
+
+	index = append(index, snapshotIndex{})
+	copy(index[i+1:], index[i:])
+	index[i] = snapshotIndex{
+		timestamp: ts,
+		offset:    offset,
+		length:    length,
+	}
+	s.orderbooks[key] = index
+}
+
+// Orderbooks returns the orderbook snapshots matching q, ordered by time
+func (s *Store) Orderbooks(q Query) ([]OrderbookSnapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return nil, ErrClosed
+	}
+
+	var result []OrderbookSnapshot
+	for key, index := range s.orderbooks {
+		if !q.matchSeries(key.exchange, key.symbol) {
+			continue
+		}
+
+		start := 0
+		if !q.From.IsZero() {
+			start = searchTime(len(index), func(i int) time.Time {
+				return index[i].timestamp
+			}, q.From)
+		}
+
+		for _, idx := range index[start:] {
+			if !q.matchTime(idx.timestamp) {
+				break
+			}
+
+			o, err := s.readOrderbook(idx)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, *o)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Record.Timestamp.Before(result[j].Record.Timestamp)
+	})
+
+	return result, nil
+}
+
+// LatestOrderbook returns the most recent orderbook snapshot of a symbol on an exchange
+func (s *Store) LatestOrderbook(exchangeName, symbol string) (*OrderbookSnapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return nil, ErrClosed
+	}
+
+	index := s.orderbooks[seriesKey{exchangeName, symbol}]
+	if len(index) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return s.readOrderbook(index[len(index)-1])
+}
+
+func (s *Store) readOrderbook(idx snapshotIndex) (*OrderbookSnapshot, error) {
+	b := make([]byte, idx.length)
+	if _, err := s.orderbooksFile.ReadAt(b, idx.offset); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	var o OrderbookSnapshot
+	if err := json.Unmarshal(b, &o); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
@@ -0,0 +1,195 @@
+package exchange
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// ErrCrossedBook is returned when the highest bid is not below the lowest ask
+	ErrCrossedBook = errors.New("highest bid is not below the lowest ask")
+	// ErrUnsortedLevels is returned when bids are not sorted from the highest price or asks from the lowest price
+	ErrUnsortedLevels = errors.New("levels are not sorted from the best price")
+	// ErrInvalidLevel is returned when a level's price or volume is zero or negative
+	ErrInvalidLevel = errors.New("level price and volume must be positive")
+	// ErrNegativePercent is returned when a distance from the mid price is negative
+	ErrNegativePercent = errors.New("percent must not be negative")
+
+	hundred = decimal.New(100, 0)
+)
+
+// ValidationError describes a problem found by MarketRecord.Validate
+type ValidationError struct {
+	// Side is ActionBuy for bids and ActionSell for asks, empty if the problem involves both sides
+	Side Action
+	// Index is the position of the offending level in Bids or Asks, -1 if the problem involves both sides
+	Index int
+	// Err is ErrCrossedBook, ErrUnsortedLevels or ErrInvalidLevel
+	Err error
+}
+
+func (e ValidationError) Error() string {
+	switch e.Side {
+	case ActionBuy:
+		return fmt.Sprintf("bid %d: %v", e.Index, e.Err)
+	case ActionSell:
+		return fmt.Sprintf("ask %d: %v", e.Index, e.Err)
+	default:
+		return e.Err.Error()
+	}
+}
+
+// Validate checks that the levels are positive, bids are sorted from the highest price,
+// asks from the lowest price and the book is not crossed. It returns a ValidationError for the first problem found.
+func (r *MarketRecord) Validate() error {
+	if err := validateLevels(ActionBuy, r.Bids, func(a, b decimal.Decimal) bool { return a.LessThan(b) }); err != nil {
+		return err
+	}
+
+	if err := validateLevels(ActionSell, r.Asks, func(a, b decimal.Decimal) bool { return a.GreaterThan(b) }); err != nil {
+		return err
+	}
+
+	if len(r.Bids) > 0 && len(r.Asks) > 0 && !r.Bids[0].Price.LessThan(r.Asks[0].Price) {
+		return ValidationError{Index: -1, Err: ErrCrossedBook}
+	}
+
+	return nil
+}
+
+func validateLevels(side Action, levels []MarketOrder, worse func(a, b decimal.Decimal) bool) error {
+	for i, o := range levels {
+		if !o.Price.GreaterThan(decimal.Zero) || !o.Volume.GreaterThan(decimal.Zero) {
+			return ValidationError{Side: side, Index: i, Err: ErrInvalidLevel}
+		}
+		if i > 0 && worse(levels[i-1].Price, o.Price) {
+			return ValidationError{Side: side, Index: i, Err: ErrUnsortedLevels}
+		}
+	}
+	return nil
+}
+
+func (r *MarketRecord) quotes() (bid, ask *MarketOrder, err error) {
+	bid = r.HighestBid()
+	ask = r.CheapestAsk()
+	if bid == nil || ask == nil {
+		return nil, nil, ErrEmptyOrderbook
+	}
+	return bid, ask, nil
+}
+
+// Spread returns the difference between the lowest ask and the highest bid
+func (r *MarketRecord) Spread() (decimal.Decimal, error) {
+	bid, ask, err := r.quotes()
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return ask.Price.Sub(bid.Price), nil
+}
+
+// SpreadBps returns the spread in basis points of the mid price
+func (r *MarketRecord) SpreadBps() (decimal.Decimal, error) {
+	bid, ask, err := r.quotes()
+	if err != nil {
+		return decimal.Zero, err
+	}
+	mid := bid.Price.Add(ask.Price).Div(two)
+	return ask.Price.Sub(bid.Price).Div(mid).Mul(bpsFactor), nil
+}
+
+// Mid returns the average of the highest bid and the lowest ask
+func (r *MarketRecord) Mid() (decimal.Decimal, error) {
+	bid, ask, err := r.quotes()
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return bid.Price.Add(ask.Price).Div(two), nil
+}
+
+// Microprice returns the mid price weighted by the volume at the best quotes.
+// It leans towards the ask when the bid is larger, as the next trade is more likely to move the price up.
+func (r *MarketRecord) Microprice() (decimal.Decimal, error) {
+	bid, ask, err := r.quotes()
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	volume := bid.Volume.Add(ask.Volume)
+	if volume.Equal(decimal.Zero) {
+		return bid.Price.Add(ask.Price).Div(two), nil
+	}
+
+	return bid.Price.Mul(ask.Volume).Add(ask.Price.Mul(bid.Volume)).Div(volume), nil
+}
+
+// Depth is the liquidity of an orderbook within a distance of the mid price
+type Depth struct {
+	// Percent is the distance from Mid, e.g. 2 for 2%
+	Percent decimal.Decimal `json:"percent"`
+	Mid     decimal.Decimal `json:"mid"`
+	// BidVolume and AskVolume are the coins offered within the distance
+	BidVolume decimal.Decimal `json:"bid_volume"`
+	AskVolume decimal.Decimal `json:"ask_volume"`
+	// BidCost and AskCost are the value of those coins in the quote currency
+	BidCost decimal.Decimal `json:"bid_cost"`
+	AskCost decimal.Decimal `json:"ask_cost"`
+}
+
+// Imbalance returns (BidVolume - AskVolume) / (BidVolume + AskVolume), ranging from -1 when only
+// asks are present to 1 when only bids are present. It is zero if there is no volume.
+func (d Depth) Imbalance() decimal.Decimal {
+	total := d.BidVolume.Add(d.AskVolume)
+	if total.Equal(decimal.Zero) {
+		return decimal.Zero
+	}
+	return d.BidVolume.Sub(d.AskVolume).Div(total)
+}
+
+// Depth returns the cumulative volume of bids priced at most percent below the mid price
+// and of asks priced at most percent above it. The levels do not need to be sorted.
+func (r *MarketRecord) Depth(percent decimal.Decimal) (*Depth, error) {
+	if percent.LessThan(decimal.Zero) {
+		return nil, ErrNegativePercent
+	}
+
+	mid, err := r.Mid()
+	if err != nil {
+		return nil, err
+	}
+
+	offset := mid.Mul(percent).Div(hundred)
+	minBid := mid.Sub(offset)
+	maxAsk := mid.Add(offset)
+
+	d := &Depth{
+		Percent: percent,
+		Mid:     mid,
+	}
+
+	for _, o := range r.Bids {
+		if !o.Price.LessThan(minBid) {
+			d.BidVolume = d.BidVolume.Add(o.Volume)
+			d.BidCost = d.BidCost.Add(o.TotalCost())
+		}
+	}
+
+	for _, o := range r.Asks {
+		if !o.Price.GreaterThan(maxAsk) {
+			d.AskVolume = d.AskVolume.Add(o.Volume)
+			d.AskCost = d.AskCost.Add(o.TotalCost())
+		}
+	}
+
+	return d, nil
+}
+
+// Imbalance returns the bid/ask volume imbalance within percent of the mid price, see Depth.Imbalance
+func (r *MarketRecord) Imbalance(percent decimal.Decimal) (decimal.Decimal, error) {
+	d, err := r.Depth(percent)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return d.Imbalance(), nil
+}
@@ -0,0 +1,176 @@
+package exchange
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func newSortedMarketRecord() MarketRecord {
+	return MarketRecord{
+		Symbol: "SKY/BTC",
+		Bids: []MarketOrder{
+			{Price: decimal.NewFromFloat(3.0), Volume: decimal.NewFromFloat(7.0)},
+			{Price: decimal.NewFromFloat(2.0), Volume: decimal.NewFromFloat(6.0)},
+		},
+		Asks: []MarketOrder{
+			{Price: decimal.NewFromFloat(4.0), Volume: decimal.NewFromFloat(3.0)},
+			{Price: decimal.NewFromFloat(5.0), Volume: decimal.NewFromFloat(9.0)},
+		},
+	}
+}
+
+func TestQuotes(t *testing.T) {
+	marketRecord := newFakeMarketRecord()
+
+	require.True(t, marketRecord.HighestBid().Price.Equal(decimal.NewFromFloat(3.0)))
+	require.True(t, marketRecord.CheapestAsk().Price.Equal(decimal.NewFromFloat(4.0)))
+
+	spread, err := marketRecord.Spread()
+	require.NoError(t, err)
+	require.True(t, spread.Equal(decimal.NewFromFloat(1.0)))
+
+	mid, err := marketRecord.Mid()
+	require.NoError(t, err)
+	require.True(t, mid.Equal(decimal.NewFromFloat(3.5)))
+
+	bps, err := marketRecord.SpreadBps()
+	require.NoError(t, err)
+	require.Equal(t, "2857.14", bps.StringFixed(2))
+
+	// best bid 3x7, best ask 4x5: (3*5 + 4*7) / 12
+	micro, err := marketRecord.Microprice()
+	require.NoError(t, err)
+	require.Equal(t, "3.5833", micro.StringFixed(4))
+
+	empty := MarketRecord{Bids: marketRecord.Bids}
+	require.Nil(t, empty.CheapestAsk())
+	_, err = empty.Spread()
+	require.Equal(t, ErrEmptyOrderbook, err)
+	_, err = empty.SpreadBps()
+	require.Equal(t, ErrEmptyOrderbook, err)
+	_, err = empty.Mid()
+	require.Equal(t, ErrEmptyOrderbook, err)
+	_, err = empty.Microprice()
+	require.Equal(t, ErrEmptyOrderbook, err)
+}
+
+func TestDepth(t *testing.T) {
+	marketRecord := newFakeMarketRecord()
+
+	tests := []struct {
+		name      string
+		percent   float64
+		bidVolume float64
+		bidCost   float64
+		askVolume float64
+		askCost   float64
+		imbalance string
+	}{
+		{
+			name:      "at mid",
+			percent:   0,
+			imbalance: "0.0000",
+		},
+		{
+			name:      "best quotes",
+			percent:   15,
+			bidVolume: 7,
+			bidCost:   21,
+			askVolume: 8,
+			askCost:   32,
+			imbalance: "-0.0667",
+		},
+		{
+			name:      "whole book",
+			percent:   50,
+			bidVolume: 13,
+			bidCost:   33,
+			askVolume: 17,
+			askCost:   77,
+			imbalance: "-0.1333",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := marketRecord.Depth(decimal.NewFromFloat(tc.percent))
+			require.NoError(t, err)
+			require.True(t, d.Mid.Equal(decimal.NewFromFloat(3.5)))
+			require.True(t, d.BidVolume.Equal(decimal.NewFromFloat(tc.bidVolume)))
+			require.True(t, d.BidCost.Equal(decimal.NewFromFloat(tc.bidCost)))
+			require.True(t, d.AskVolume.Equal(decimal.NewFromFloat(tc.askVolume)))
+			require.True(t, d.AskCost.Equal(decimal.NewFromFloat(tc.askCost)))
+			require.Equal(t, tc.imbalance, d.Imbalance().StringFixed(4))
+
+			imbalance, err := marketRecord.Imbalance(decimal.NewFromFloat(tc.percent))
+			require.NoError(t, err)
+			require.True(t, imbalance.Equal(d.Imbalance()))
+		})
+	}
+
+	_, err := marketRecord.Depth(decimal.NewFromFloat(-1.0))
+	require.Equal(t, ErrNegativePercent, err)
+
+	_, err = (&MarketRecord{}).Depth(decimal.NewFromFloat(1.0))
+	require.Equal(t, ErrEmptyOrderbook, err)
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(r *MarketRecord)
+		err    error
+	}{
+		{
+			name:   "valid",
+			modify: func(r *MarketRecord) {},
+		},
+		{
+			name:   "empty",
+			modify: func(r *MarketRecord) { *r = MarketRecord{} },
+		},
+		{
+			name:   "unsorted bids",
+			modify: func(r *MarketRecord) { r.Bids[0], r.Bids[1] = r.Bids[1], r.Bids[0] },
+			err:    ValidationError{Side: ActionBuy, Index: 1, Err: ErrUnsortedLevels},
+		},
+		{
+			name:   "unsorted asks",
+			modify: func(r *MarketRecord) { r.Asks[0], r.Asks[1] = r.Asks[1], r.Asks[0] },
+			err:    ValidationError{Side: ActionSell, Index: 1, Err: ErrUnsortedLevels},
+		},
+		{
+			name:   "zero volume",
+			modify: func(r *MarketRecord) { r.Asks[1].Volume = decimal.Zero },
+			err:    ValidationError{Side: ActionSell, Index: 1, Err: ErrInvalidLevel},
+		},
+		{
+			name:   "negative price",
+			modify: func(r *MarketRecord) { r.Bids[1].Price = decimal.NewFromFloat(-2.0) },
+			err:    ValidationError{Side: ActionBuy, Index: 1, Err: ErrInvalidLevel},
+		},
+		{
+			name:   "crossed",
+			modify: func(r *MarketRecord) { r.Bids[0].Price = decimal.NewFromFloat(4.0) },
+			err:    ValidationError{Index: -1, Err: ErrCrossedBook},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newSortedMarketRecord()
+			tc.modify(&r)
+			err := r.Validate()
+			if tc.err == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.Equal(t, tc.err, err)
+		})
+	}
+
+	require.Equal(t, "ask 1: levels are not sorted from the best price", ValidationError{Side: ActionSell, Index: 1, Err: ErrUnsortedLevels}.Error())
+	require.Equal(t, ErrCrossedBook.Error(), ValidationError{Index: -1, Err: ErrCrossedBook}.Error())
+}
@@ -0,0 +1,308 @@
+package exchange
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// DefaultScanInterval is the Scanner's polling interval if none is specified
+const DefaultScanInterval = 10 * time.Second
+
+// ArbBook is a venue's orderbook with the costs of trading on the venue and moving funds off it
+type ArbBook struct {
+	Venue  string
+	Record *MarketRecord
+	// Fee is the venue's taker fee rate, e.g. 0.002 for 0.2%
+	Fee decimal.Decimal
+	// BaseWithdrawFee and QuoteWithdrawFee are the flat fees charged to withdraw each currency of the market
+	BaseWithdrawFee  decimal.Decimal
+	QuoteWithdrawFee decimal.Decimal
+	// Codec decodes the record's symbol, CanonicalCodec if nil
+	Codec PairCodec
+}
+
+// ArbStep is the profit of an arbitrage after filling a cumulative volume
+type ArbStep struct {
+	Volume decimal.Decimal `json:"volume"`
+	Profit decimal.Decimal `json:"profit"`
+}
+
+// Opportunity is an executable arbitrage: buying on one venue and selling on another.
+// All amounts are in the quote currency.
+type Opportunity struct {
+	Timestamp time.Time `json:"timestamp"`
+	Pair      Pair      `json:"pair"`
+	BuyVenue  string    `json:"buy_venue"`
+	SellVenue string    `json:"sell_venue"`
+	// Volume is the most profitable quantity of coins to trade
+	Volume decimal.Decimal `json:"volume"`
+	// BuyPrice and SellPrice are the worst prices reached, usable as limit prices
+	BuyPrice     decimal.Decimal `json:"buy_price"`
+	SellPrice    decimal.Decimal `json:"sell_price"`
+	BuyCost      decimal.Decimal `json:"buy_cost"`
+	SellProceeds decimal.Decimal `json:"sell_proceeds"`
+	TradingFees  decimal.Decimal `json:"trading_fees"`
+	WithdrawFees decimal.Decimal `json:"withdraw_fees"`
+	Profit       decimal.Decimal `json:"profit"`
+	// ProfitBps is Profit in basis points of the buy cost including fees
+	ProfitBps decimal.Decimal `json:"profit_bps"`
+	// Steps is the profit after filling each level pair, showing how profit changes with size
+	Steps []ArbStep `json:"steps"`
+}
+
+// FindArbitrage returns the most profitable arbitrage buying from buy's asks and selling to sell's bids,
+// nil if there is none. Trading fees are charged on both venues. Moving the coins bought costs the buy venue's
+// base withdraw fee, valued at the sell price, and moving the proceeds back costs the sell venue's quote withdraw fee.
+func FindArbitrage(buy, sell ArbBook) (*Opportunity, error) {
+	pair, err := arbPair(buy)
+	if err != nil {
+		return nil, err
+	}
+	sellPair, err := arbPair(sell)
+	if err != nil {
+		return nil, err
+	}
+	if pair != sellPair {
+		return nil, ErrSymbolMismatch
+	}
+
+	asks := buy.Record.Copy().Asks
+	sort.Slice(asks, func(i, j int) bool { return asks[i].Price.LessThan(asks[j].Price) })
+	bids := sell.Record.Copy().Bids
+	sort.Slice(bids, func(i, j int) bool { return bids[i].Price.GreaterThan(bids[j].Price) })
+
+	timestamp := buy.Record.Timestamp
+	if sell.Record.Timestamp.Before(timestamp) {
+		timestamp = sell.Record.Timestamp
+	}
+
+	var (
+		best    *Opportunity
+		current = Opportunity{
+			Timestamp: timestamp,
+			Pair:      pair,
+			BuyVenue:  buy.Venue,
+			SellVenue: sell.Venue,
+		}
+		steps []ArbStep
+	)
+
+	for i, j := 0, 0; i < len(asks) && j < len(bids); {
+		ask, bid := &asks[i], &bids[j]
+		if !ask.Price.Mul(one.Add(buy.Fee)).LessThan(bid.Price.Mul(one.Sub(sell.Fee))) {
+			break
+		}
+
+		take := decimal.Min(ask.Volume, bid.Volume)
+		cost := take.Mul(ask.Price)
+		proceeds := take.Mul(bid.Price)
+
+		current.Volume = current.Volume.Add(take)
+		current.BuyPrice = ask.Price
+		current.SellPrice = bid.Price
+		current.BuyCost = current.BuyCost.Add(cost)
+		current.SellProceeds = current.SellProceeds.Add(proceeds)
+		current.TradingFees = current.TradingFees.Add(cost.Mul(buy.Fee)).Add(proceeds.Mul(sell.Fee))
+		current.WithdrawFees = buy.BaseWithdrawFee.Mul(bid.Price).Add(sell.QuoteWithdrawFee)
+		current.Profit = current.SellProceeds.Sub(current.BuyCost).Sub(current.TradingFees).Sub(current.WithdrawFees)
+
+		steps = append(steps, ArbStep{Volume: current.Volume, Profit: current.Profit})
+		if best == nil || current.Profit.GreaterThan(best.Profit) {
+			o := current
+			best = &o
+		}
+
+		ask.Volume = ask.Volume.Sub(take)
+		bid.Volume = bid.Volume.Sub(take)
+		if ask.Volume.Equal(decimal.Zero) {
+			i++
+		}
+		if bid.Volume.Equal(decimal.Zero) {
+			j++
+		}
+	}
+
+	if best == nil || !best.Profit.GreaterThan(decimal.Zero) {
+		return nil, nil
+	}
+
+	best.ProfitBps = best.Profit.Div(best.BuyCost.Add(best.BuyCost.Mul(buy.Fee))).Mul(bpsFactor)
+	best.Steps = steps
+
+	return best, nil
+}
+
+func arbPair(b ArbBook) (Pair, error) {
+	pair, err := b.Record.Pair(b.Codec)
+	if err != nil {
+		return Pair{}, fmt.Errorf("%s: %v", b.Venue, err)
+	}
+	return pair, nil
+}
+
+// ScanRecords looks for arbitrage between every pair of the orderbooks, which must be of the same market.
+// Opportunities are returned from the most profitable.
+func ScanRecords(books ...ArbBook) ([]Opportunity, error) {
+	var result []Opportunity
+	for i := range books {
+		for j := range books {
+			if i == j {
+				continue
+			}
+
+			o, err := FindArbitrage(books[i], books[j])
+			if err != nil {
+				return nil, err
+			}
+			if o != nil {
+				result = append(result, *o)
+			}
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Profit.GreaterThan(result[j].Profit)
+	})
+
+	return result, nil
+}
+
+// ArbVenue is a Venue with the fees charged to withdraw each currency of its market
+type ArbVenue struct {
+	Venue
+	BaseWithdrawFee  decimal.Decimal
+	QuoteWithdrawFee decimal.Decimal
+}
+
+// Scanner polls the orderbooks of a market on several venues and looks for arbitrage between them.
+// It only detects opportunities, no orders are placed.
+type Scanner struct {
+	Venues       []ArbVenue
+	ScanInterval time.Duration
+	// MinProfit is the minimal profit, in the quote currency, of a reported opportunity
+	MinProfit decimal.Decimal
+	// Stop stops Run when closed
+	Stop chan struct{}
+	// OnOpportunity, if set, is called with every opportunity found, e.g. to emit a signal
+	OnOpportunity func(Opportunity)
+
+	mu            sync.RWMutex
+	opportunities []Opportunity
+	updated       time.Time
+	err           error
+}
+
+// NewScanner creates a Scanner for the given venues
+func NewScanner(interval time.Duration, venues ...ArbVenue) *Scanner {
+	if interval <= 0 {
+		interval = DefaultScanInterval
+	}
+
+	return &Scanner{
+		Venues:       venues,
+		ScanInterval: interval,
+		Stop:         make(chan struct{}),
+	}
+}
+
+// Run scans every ScanInterval until Stop is closed.
+// Errors do not stop the Scanner, the latest one is available from Err.
+func (s *Scanner) Run() {
+	ticker := time.NewTicker(s.ScanInterval)
+	defer ticker.Stop()
+
+	for {
+		s.Scan() // nolint: errcheck
+
+		select {
+		case <-s.Stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Scan fetches the venues' orderbooks once and returns the opportunities with at least MinProfit
+func (s *Scanner) Scan() ([]Opportunity, error) {
+	opportunities, err := s.scan()
+
+	s.mu.Lock()
+	s.err = err
+	if err == nil {
+		s.opportunities = opportunities
+		s.updated = time.Now().UTC()
+	}
+	s.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if s.OnOpportunity != nil {
+		for _, o := range opportunities {
+			s.OnOpportunity(o)
+		}
+	}
+
+	return opportunities, nil
+}
+
+func (s *Scanner) scan() ([]Opportunity, error) {
+	books := make([]ArbBook, len(s.Venues))
+	for i, v := range s.Venues {
+		name := v.Exchange.Name()
+
+		record, err := v.Exchange.GetOrderbook(v.Pair)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+
+		books[i] = ArbBook{
+			Venue:            name,
+			Record:           record,
+			Fee:              v.Fee,
+			BaseWithdrawFee:  v.BaseWithdrawFee,
+			QuoteWithdrawFee: v.QuoteWithdrawFee,
+			Codec:            v.Exchange.Codec(),
+		}
+	}
+
+	all, err := ScanRecords(books...)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Opportunity
+	for _, o := range all {
+		if o.Profit.GreaterThanOrEqual(s.MinProfit) {
+			result = append(result, o)
+		}
+	}
+
+	return result, nil
+}
+
+// Opportunities returns the opportunities found by the last successful scan
+func (s *Scanner) Opportunities() []Opportunity {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.opportunities
+}
+
+// LastUpdate returns the time of the last successful scan
+func (s *Scanner) LastUpdate() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.updated
+}
+
+// Err returns the error of the last scan, if any
+func (s *Scanner) Err() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.err
+}
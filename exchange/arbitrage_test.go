@@ -0,0 +1,192 @@
+package exchange
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func readRecord(t *testing.T, name string) *MarketRecord {
+	b, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+
+	var r MarketRecord
+	require.NoError(t, json.Unmarshal(b, &r))
+	return &r
+}
+
+func newArbBooks(t *testing.T) (ArbBook, ArbBook) {
+	c2cx := ArbBook{
+		Venue:           "c2cx",
+		Record:          readRecord(t, "arbitrage_c2cx.json"),
+		BaseWithdrawFee: decimal.NewFromFloat(1.0),
+		Codec:           quoteFirstCodec,
+	}
+	cryptopia := ArbBook{
+		Venue:            "cryptopia",
+		Record:           readRecord(t, "arbitrage_cryptopia.json"),
+		Fee:              decimal.NewFromFloat(0.002),
+		QuoteWithdrawFee: decimal.NewFromFloat(0.0005),
+	}
+	return c2cx, cryptopia
+}
+
+func TestFindArbitrage(t *testing.T) {
+	c2cx, cryptopia := newArbBooks(t)
+
+	o, err := FindArbitrage(c2cx, cryptopia)
+	require.NoError(t, err)
+	require.NotNil(t, o)
+
+	require.True(t, o.Timestamp.Equal(time.Unix(1500000000, 0)))
+	require.Equal(t, NewPair(SKY, BTC), o.Pair)
+	require.Equal(t, "c2cx", o.BuyVenue)
+	require.Equal(t, "cryptopia", o.SellVenue)
+	require.Equal(t, "150", o.Volume.String())
+	require.Equal(t, "0.00062", o.BuyPrice.String())
+	require.Equal(t, "0.00064", o.SellPrice.String())
+	require.Equal(t, "0.091", o.BuyCost.String())
+	require.Equal(t, "0.099", o.SellProceeds.String())
+	require.Equal(t, "0.000198", o.TradingFees.String())
+	require.Equal(t, "0.00114", o.WithdrawFees.String())
+	require.Equal(t, "0.006662", o.Profit.String())
+	require.Equal(t, "732.09", o.ProfitBps.StringFixed(2))
+
+	var steps []string
+	for _, s := range o.Steps {
+		steps = append(steps, s.Volume.String()+":"+s.Profit.String())
+	}
+	require.Equal(t, []string{"50:0.00373", "100:0.005726", "150:0.006662"}, steps)
+
+	// the books are not modified
+	require.Equal(t, readRecord(t, "arbitrage_c2cx.json"), c2cx.Record)
+
+	o, err = FindArbitrage(cryptopia, c2cx)
+	require.NoError(t, err)
+	require.Nil(t, o)
+}
+
+func TestFindArbitrageWithdrawFees(t *testing.T) {
+	c2cx, cryptopia := newArbBooks(t)
+
+	// the spread doesn't cover moving the coins
+	c2cx.BaseWithdrawFee = decimal.NewFromFloat(20.0)
+	o, err := FindArbitrage(c2cx, cryptopia)
+	require.NoError(t, err)
+	require.Nil(t, o)
+}
+
+func TestFindArbitrageErrors(t *testing.T) {
+	c2cx, cryptopia := newArbBooks(t)
+
+	c2cx.Codec = nil
+	_, err := FindArbitrage(c2cx, cryptopia)
+	require.EqualError(t, err, "c2cx: invalid symbol")
+
+	c2cx, cryptopia = newArbBooks(t)
+	cryptopia.Record.Symbol = "SKY/ETH"
+	_, err = FindArbitrage(c2cx, cryptopia)
+	require.Equal(t, ErrSymbolMismatch, err)
+}
+
+func TestScanRecords(t *testing.T) {
+	c2cx, cryptopia := newArbBooks(t)
+
+	opportunities, err := ScanRecords(c2cx, cryptopia)
+	require.NoError(t, err)
+	require.Len(t, opportunities, 1)
+	require.Equal(t, "c2cx", opportunities[0].BuyVenue)
+
+	opportunities, err = ScanRecords(c2cx)
+	require.NoError(t, err)
+	require.Empty(t, opportunities)
+}
+
+func newFakeScanner(t *testing.T) (*Scanner, *fakeExchange) {
+	c2cxBook, cryptopiaBook := newArbBooks(t)
+
+	c2cx := newFakeExchange()
+	c2cx.name = "c2cx"
+	c2cx.codec = quoteFirstCodec
+	c2cx.orderbooks["BTC_SKY"] = *c2cxBook.Record
+
+	cryptopia := newFakeExchange()
+	cryptopia.name = "cryptopia"
+	cryptopia.orderbooks["SKY/BTC"] = *cryptopiaBook.Record
+
+	scanner := NewScanner(0,
+		ArbVenue{
+			Venue:           Venue{Exchange: c2cx, Pair: skyBtc},
+			BaseWithdrawFee: c2cxBook.BaseWithdrawFee,
+		},
+		ArbVenue{
+			Venue:            Venue{Exchange: cryptopia, Pair: skyBtc, Fee: cryptopiaBook.Fee},
+			QuoteWithdrawFee: cryptopiaBook.QuoteWithdrawFee,
+		},
+	)
+
+	return scanner, cryptopia
+}
+
+func TestScanner(t *testing.T) {
+	scanner, cryptopia := newFakeScanner(t)
+	require.Equal(t, DefaultScanInterval, scanner.ScanInterval)
+
+	var signals []Opportunity
+	scanner.OnOpportunity = func(o Opportunity) {
+		signals = append(signals, o)
+	}
+
+	opportunities, err := scanner.Scan()
+	require.NoError(t, err)
+	require.Len(t, opportunities, 1)
+	require.Equal(t, opportunities, signals)
+	require.Equal(t, opportunities, scanner.Opportunities())
+	require.False(t, scanner.LastUpdate().IsZero())
+
+	scanner.MinProfit = decimal.NewFromFloat(0.01)
+	opportunities, err = scanner.Scan()
+	require.NoError(t, err)
+	require.Empty(t, opportunities)
+	require.Len(t, signals, 1)
+
+	cryptopia.err = errors.New("exchange unavailable")
+	_, err = scanner.Scan()
+	require.EqualError(t, err, "cryptopia: exchange unavailable")
+	require.Equal(t, err, scanner.Err())
+}
+
+func TestScannerRun(t *testing.T) {
+	scanner, _ := newFakeScanner(t)
+	scanner.ScanInterval = time.Millisecond
+
+	found := make(chan Opportunity, 1)
+	scanner.OnOpportunity = func(o Opportunity) {
+		select {
+		case found <- o:
+		default:
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		scanner.Run()
+		close(done)
+	}()
+
+	select {
+	case o := <-found:
+		require.Equal(t, "cryptopia", o.SellVenue)
+	case <-time.After(time.Second):
+		t.Fatal("no opportunity found")
+	}
+
+	close(scanner.Stop)
+	<-done
+}
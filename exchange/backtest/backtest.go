@@ -0,0 +1,315 @@
+// Package backtest replays recorded orderbooks and trades of a market to a strategy
+// and simulates its orders on a paper exchange
+package backtest
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/skycoin/exchange-api/exchange"
+	"github.com/skycoin/exchange-api/exchange/paper"
+)
+
+var (
+	// ErrNoEvents is returned when running a backtest without orderbooks or trades
+	ErrNoEvents = errors.New("no events to replay")
+
+	two = decimal.New(2, 0)
+)
+
+// Event is an orderbook snapshot or a trade, exactly one of them is set
+type Event struct {
+	Time      time.Time
+	Orderbook *exchange.MarketRecord
+	Trade     *exchange.Trade
+}
+
+// Strategy is called with every replayed event, after the event was applied to the simulated exchange
+type Strategy func(ctx *Context, ev Event)
+
+// Config configures a Backtester
+type Config struct {
+	// Pair is the market, the symbols of the replayed orderbooks are ignored
+	Pair exchange.Pair
+	// Fee is the fee rate charged on every fill, e.g. 0.002 for 0.2%
+	Fee decimal.Decimal
+	// Latency delays the strategy's orders and cancels, they reach the exchange Latency after the event which triggered them
+	Latency time.Duration
+	// Balances are deposited before the replay starts
+	Balances map[exchange.Currency]decimal.Decimal
+}
+
+// Backtester replays orderbooks and trades of one market in time order.
+// Orderbook snapshots replace the external orders of the simulated book, resting strategy orders crossed by a
+// snapshot are filled at their own price. Trades fill resting strategy orders priced at or better than the trade.
+type Backtester struct {
+	Config Config
+	events []Event
+}
+
+// New creates a Backtester
+func New(cfg Config) *Backtester {
+	return &Backtester{
+		Config: cfg,
+	}
+}
+
+// AddOrderbooks adds orderbook snapshots to replay
+func (b *Backtester) AddOrderbooks(records ...exchange.MarketRecord) {
+	for i := range records {
+		r := records[i].Copy()
+		b.events = append(b.events, Event{Time: r.Timestamp, Orderbook: &r})
+	}
+}
+
+// AddTrades adds historical trades to replay
+func (b *Backtester) AddTrades(trades ...exchange.Trade) {
+	for i := range trades {
+		t := trades[i]
+		b.events = append(b.events, Event{Time: t.Timestamp, Trade: &t})
+	}
+}
+
+// EquityPoint is the value of the account, in the quote currency, after an event
+type EquityPoint struct {
+	Time   time.Time       `json:"time"`
+	Equity decimal.Decimal `json:"equity"`
+}
+
+// Fill is a fill of one of the strategy's orders
+type Fill struct {
+	Time    time.Time        `json:"time"`
+	OrderID exchange.OrderID `json:"order_id"`
+	Action  exchange.Action  `json:"action"`
+	Price   decimal.Decimal  `json:"price"`
+	Volume  decimal.Decimal  `json:"volume"`
+	Fee     decimal.Decimal  `json:"fee"`
+}
+
+// Result summarizes a backtest. The account is valued at the mid price of the latest orderbook,
+// or the latest trade price if there is no orderbook with both sides.
+type Result struct {
+	Equity      []EquityPoint    `json:"equity"`
+	Fills       []Fill           `json:"fills"`
+	Orders      []exchange.Order `json:"orders"`
+	StartEquity decimal.Decimal  `json:"start_equity"`
+	EndEquity   decimal.Decimal  `json:"end_equity"`
+	// Return is the relative change from StartEquity to EndEquity
+	Return decimal.Decimal `json:"return"`
+	// MaxDrawdown is the largest relative fall of the equity from a previous peak
+	MaxDrawdown decimal.Decimal `json:"max_drawdown"`
+	// Sharpe is the mean of the returns between equity points divided by their standard deviation,
+	// scaled by the square root of their count. It is not annualized and assumes a zero risk-free rate.
+	Sharpe float64 `json:"sharpe"`
+}
+
+// Run replays the events in time order to the strategy and returns the result.
+// Orderbooks are applied before trades with the same timestamp.
+func (b *Backtester) Run(strategy Strategy) (*Result, error) {
+	if len(b.events) == 0 {
+		return nil, ErrNoEvents
+	}
+
+	events := append([]Event(nil), b.events...)
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].Time.Equal(events[j].Time) {
+			return events[i].Time.Before(events[j].Time)
+		}
+		return events[i].Orderbook != nil && events[j].Orderbook == nil
+	})
+
+	r := &run{
+		cfg:   b.Config,
+		now:   events[0].Time,
+		owned: make(map[exchange.OrderID]struct{}),
+	}
+	r.exchange = paper.New("backtest", exchange.CanonicalCodec, b.Config.Fee)
+	r.exchange.Now = func() time.Time { return r.now }
+
+	for currency, amount := range b.Config.Balances {
+		if err := r.exchange.Deposit(currency, amount); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx := &Context{run: r}
+	result := &Result{}
+
+	for _, ev := range events {
+		r.execute(ev.Time)
+		r.now = ev.Time
+
+		if err := r.apply(ev); err != nil {
+			return nil, err
+		}
+
+		result.Equity = append(result.Equity, EquityPoint{Time: ev.Time, Equity: r.equity()})
+
+		if strategy != nil {
+			strategy(ctx, ev)
+		}
+	}
+
+	// requests made after the last event still reach the exchange
+	r.execute(time.Time{})
+
+	return r.result(result), nil
+}
+
+// run is the state of one replay
+type run struct {
+	cfg      Config
+	exchange *paper.Exchange
+	now      time.Time
+	pending  []*Request
+	owned    map[exchange.OrderID]struct{}
+	mark     decimal.Decimal
+}
+
+// apply applies an event to the exchange and updates the mark price
+func (r *run) apply(ev Event) error {
+	switch {
+	case ev.Orderbook != nil:
+		book := ev.Orderbook.Copy()
+		book.Symbol = r.cfg.Pair.String()
+		if err := r.exchange.SetOrderbook(book); err != nil {
+			return err
+		}
+		bid, ask := book.HighestBid(), book.CheapestAsk()
+		if bid != nil && ask != nil {
+			r.mark = bid.Price.Add(ask.Price).Div(two)
+		}
+	case ev.Trade != nil:
+		if err := r.exchange.Cross(r.cfg.Pair, ev.Trade.Action, ev.Trade.Price, ev.Trade.Volume); err != nil {
+			return err
+		}
+		r.mark = ev.Trade.Price
+	}
+	return nil
+}
+
+// execute sends the pending requests due by until to the exchange, all of them if until is zero
+func (r *run) execute(until time.Time) {
+	for len(r.pending) > 0 {
+		req := r.pending[0]
+		if !until.IsZero() && req.Due.After(until) {
+			return
+		}
+		r.pending = r.pending[1:]
+
+		r.now = req.Due
+		req.send(r)
+	}
+}
+
+func (r *run) equity() decimal.Decimal {
+	balances, _ := r.exchange.GetBalances() // nolint: errcheck
+	base := balances[string(r.cfg.Pair.Base)].Total
+	quote := balances[string(r.cfg.Pair.Quote)].Total
+	return quote.Add(base.Mul(r.mark))
+}
+
+func (r *run) result(result *Result) *Result {
+	for _, t := range r.exchange.Trades(r.cfg.Pair) {
+		for _, id := range []exchange.OrderID{t.TakerOrderID, t.MakerOrderID} {
+			if _, ok := r.owned[id]; !ok {
+				continue
+			}
+
+			action := t.Action
+			if id == t.MakerOrderID {
+				action = opposite(action)
+			}
+
+			result.Fills = append(result.Fills, Fill{
+				Time:    t.Timestamp,
+				OrderID: id,
+				Action:  action,
+				Price:   t.Price,
+				Volume:  t.Volume,
+				Fee:     t.Price.Mul(t.Volume).Mul(r.cfg.Fee),
+			})
+		}
+	}
+
+	ids := make([]exchange.OrderID, 0, len(r.owned))
+	for id := range r.owned {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		o, err := r.exchange.GetOrderStatus(r.cfg.Pair, id)
+		if err == nil {
+			result.Orders = append(result.Orders, *o)
+		}
+	}
+
+	result.StartEquity = result.Equity[0].Equity
+	result.EndEquity = result.Equity[len(result.Equity)-1].Equity
+	if !result.StartEquity.Equal(decimal.Zero) {
+		result.Return = result.EndEquity.Sub(result.StartEquity).Div(result.StartEquity)
+	}
+	result.MaxDrawdown = maxDrawdown(result.Equity)
+	result.Sharpe = sharpe(result.Equity)
+
+	return result
+}
+
+func opposite(a exchange.Action) exchange.Action {
+	if a == exchange.ActionBuy {
+		return exchange.ActionSell
+	}
+	return exchange.ActionBuy
+}
+
+func maxDrawdown(equity []EquityPoint) decimal.Decimal {
+	result := decimal.Zero
+	peak := decimal.Zero
+	for _, p := range equity {
+		if p.Equity.GreaterThan(peak) {
+			peak = p.Equity
+		}
+		if peak.GreaterThan(decimal.Zero) {
+			if dd := peak.Sub(p.Equity).Div(peak); dd.GreaterThan(result) {
+				result = dd
+			}
+		}
+	}
+	return result
+}
+
+func sharpe(equity []EquityPoint) float64 {
+	var returns []float64
+	for i := 1; i < len(equity); i++ {
+		prev, _ := equity[i-1].Equity.Float64()
+		cur, _ := equity[i].Equity.Float64()
+		if prev != 0 {
+			returns = append(returns, cur/prev-1)
+		}
+	}
+
+	if len(returns) < 2 {
+		return 0
+	}
+
+	var mean float64
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	var variance float64
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	std := math.Sqrt(variance / float64(len(returns)-1))
+	if std == 0 {
+		return 0
+	}
+
+	return mean / std * math.Sqrt(float64(len(returns)))
+}
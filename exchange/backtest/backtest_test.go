@@ -0,0 +1,186 @@
+package backtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/exchange-api/exchange"
+)
+
+var start = time.Date(2018, 3, 20, 10, 0, 0, 0, time.UTC)
+
+func d(f float64) decimal.Decimal {
+	return decimal.NewFromFloat(f)
+}
+
+func book(offset time.Duration, bid, ask float64) exchange.MarketRecord {
+	return exchange.MarketRecord{
+		Timestamp: start.Add(offset),
+		Symbol:    "SKY/BTC",
+		Bids:      []exchange.MarketOrder{{Price: d(bid), Volume: d(7)}},
+		Asks:      []exchange.MarketOrder{{Price: d(ask), Volume: d(5)}},
+	}
+}
+
+func newBacktester(latency time.Duration) *Backtester {
+	return New(Config{
+		Pair:    exchange.NewPair(exchange.SKY, exchange.BTC),
+		Latency: latency,
+		Balances: map[exchange.Currency]decimal.Decimal{
+			exchange.BTC: d(100),
+		},
+	})
+}
+
+func TestRun(t *testing.T) {
+	b := newBacktester(0)
+	b.AddOrderbooks(
+		book(0, 3, 4),
+		book(2*time.Minute, 5, 6),
+		book(3*time.Minute, 4, 5),
+	)
+	b.AddTrades(exchange.Trade{
+		Timestamp: start.Add(time.Minute),
+		Price:     d(3.5),
+		Volume:    d(5),
+		Action:    exchange.ActionSell,
+	})
+
+	var (
+		events []Event
+		req    *Request
+	)
+	result, err := b.Run(func(ctx *Context, ev Event) {
+		events = append(events, ev)
+		if req == nil {
+			req = ctx.LimitBuy(d(3.5), d(2))
+			require.True(t, req.Done)
+			require.NoError(t, req.Err)
+			require.Len(t, ctx.OpenOrders(), 1)
+		}
+	})
+	require.NoError(t, err)
+
+	require.Len(t, events, 4)
+	require.NotNil(t, events[0].Orderbook)
+	require.NotNil(t, events[1].Trade)
+
+	require.Len(t, result.Fills, 1)
+	fill := result.Fills[0]
+	require.Equal(t, req.OrderID, fill.OrderID)
+	require.Equal(t, exchange.ActionBuy, fill.Action)
+	require.True(t, fill.Price.Equal(d(3.5)))
+	require.True(t, fill.Volume.Equal(d(2)))
+	require.Equal(t, start.Add(time.Minute), fill.Time)
+
+	require.Len(t, result.Orders, 1)
+	require.Equal(t, exchange.StatusCompleted, result.Orders[0].Status)
+
+	// 93 BTC and 2 SKY valued at the mid price, or the last trade
+	var equity []string
+	for _, p := range result.Equity {
+		equity = append(equity, p.Equity.String())
+	}
+	require.Equal(t, []string{"100", "100", "104", "102"}, equity)
+
+	require.True(t, result.StartEquity.Equal(d(100)))
+	require.True(t, result.EndEquity.Equal(d(102)))
+	require.True(t, result.Return.Equal(d(0.02)))
+	require.Equal(t, "0.0192", result.MaxDrawdown.StringFixed(4))
+	require.True(t, result.Sharpe > 0)
+}
+
+func TestRun_Latency(t *testing.T) {
+	b := newBacktester(10 * time.Second)
+	b.AddOrderbooks(
+		book(0, 3, 4),
+		book(5*time.Second, 3, 4.5),
+		book(20*time.Second, 3, 5),
+	)
+
+	var req *Request
+	result, err := b.Run(func(ctx *Context, ev Event) {
+		if req == nil {
+			req = ctx.MarketBuy(d(9))
+			require.False(t, req.Done)
+			require.Equal(t, start.Add(10*time.Second), req.Due)
+		}
+	})
+	require.NoError(t, err)
+	require.True(t, req.Done)
+	require.NoError(t, req.Err)
+
+	// the order reaches the exchange after the second snapshot
+	require.Len(t, result.Fills, 1)
+	require.True(t, result.Fills[0].Price.Equal(d(4.5)))
+	require.True(t, result.Fills[0].Volume.Equal(d(2)))
+	require.Equal(t, start.Add(10*time.Second), result.Fills[0].Time)
+}
+
+func TestRun_Fee(t *testing.T) {
+	b := newBacktester(0)
+	b.Config.Fee = d(0.01)
+	b.AddOrderbooks(book(0, 3, 4))
+
+	result, err := b.Run(func(ctx *Context, ev Event) {
+		req := ctx.LimitBuy(d(4), d(1))
+		require.NoError(t, req.Err)
+
+		o, err := ctx.Order(req.OrderID)
+		require.NoError(t, err)
+		require.Equal(t, exchange.StatusCompleted, o.Status)
+
+		balances := ctx.Balances()
+		require.True(t, balances["BTC"].Total.Equal(d(95.96)))
+	})
+	require.NoError(t, err)
+
+	require.Len(t, result.Fills, 1)
+	require.True(t, result.Fills[0].Fee.Equal(d(0.04)))
+}
+
+func TestRun_NoEvents(t *testing.T) {
+	_, err := newBacktester(0).Run(nil)
+	require.Equal(t, ErrNoEvents, err)
+}
+
+func TestMaxDrawdown(t *testing.T) {
+	cases := []struct {
+		name   string
+		equity []float64
+		expect string
+	}{
+		{"empty", nil, "0"},
+		{"rising", []float64{1, 2, 3}, "0"},
+		{"falling", []float64{10, 8, 9, 5, 12}, "0.5"},
+		{"new peak", []float64{10, 9, 20, 15}, "0.25"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var points []EquityPoint
+			for _, e := range tc.equity {
+				points = append(points, EquityPoint{Equity: d(e)})
+			}
+			require.Equal(t, tc.expect, maxDrawdown(points).String())
+		})
+	}
+}
+
+func TestSharpe(t *testing.T) {
+	points := func(equity ...float64) []EquityPoint {
+		var result []EquityPoint
+		for _, e := range equity {
+			result = append(result, EquityPoint{Equity: d(e)})
+		}
+		return result
+	}
+
+	require.Equal(t, 0.0, sharpe(points(1, 2)))
+	require.Equal(t, 0.0, sharpe(points(1, 2, 4)))
+	require.True(t, sharpe(points(100, 101, 103, 102)) > 0)
+	require.True(t, sharpe(points(100, 99, 97, 98)) < 0)
+}
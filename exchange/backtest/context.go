@@ -0,0 +1,119 @@
+package backtest
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/skycoin/exchange-api/exchange"
+)
+
+// Request is an order or cancel sent by a strategy. It reaches the exchange after the configured latency,
+// until then Done is false.
+type Request struct {
+	// Due is when the request reaches the exchange
+	Due time.Time
+	// OrderID is the placed or cancelled order
+	OrderID exchange.OrderID
+	Err     error
+	Done    bool
+
+	send func(*run)
+}
+
+// Context gives a strategy access to the simulated exchange
+type Context struct {
+	run *run
+}
+
+// Time returns the time of the current event
+func (c *Context) Time() time.Time {
+	return c.run.now
+}
+
+// Orderbook returns the simulated orderbook, including the strategy's resting orders
+func (c *Context) Orderbook() *exchange.MarketRecord {
+	r, _ := c.run.exchange.GetOrderbook(c.run.cfg.Pair) // nolint: errcheck
+	return r
+}
+
+// Balances returns the account's balances
+func (c *Context) Balances() exchange.Balances {
+	b, _ := c.run.exchange.GetBalances() // nolint: errcheck
+	return b
+}
+
+// OpenOrders returns the strategy's resting orders
+func (c *Context) OpenOrders() []exchange.Order {
+	o, _ := c.run.exchange.GetOpenOrders(c.run.cfg.Pair) // nolint: errcheck
+	return o
+}
+
+// Order returns the latest state of one of the strategy's orders
+func (c *Context) Order(orderID exchange.OrderID) (*exchange.Order, error) {
+	return c.run.exchange.GetOrderStatus(c.run.cfg.Pair, orderID)
+}
+
+// LimitBuy sends a limit buy order
+func (c *Context) LimitBuy(price, volume decimal.Decimal) *Request {
+	return c.place(func(r *run) (exchange.OrderID, error) {
+		return r.exchange.LimitBuy(r.cfg.Pair, price, volume)
+	})
+}
+
+// LimitSell sends a limit sell order
+func (c *Context) LimitSell(price, volume decimal.Decimal) *Request {
+	return c.place(func(r *run) (exchange.OrderID, error) {
+		return r.exchange.LimitSell(r.cfg.Pair, price, volume)
+	})
+}
+
+// MarketBuy sends a market buy order spending amount of the quote currency, fees included
+func (c *Context) MarketBuy(amount decimal.Decimal) *Request {
+	return c.place(func(r *run) (exchange.OrderID, error) {
+		return r.exchange.MarketBuy(r.cfg.Pair, amount)
+	})
+}
+
+// MarketSell sends a market sell order
+func (c *Context) MarketSell(volume decimal.Decimal) *Request {
+	return c.place(func(r *run) (exchange.OrderID, error) {
+		return r.exchange.MarketSell(r.cfg.Pair, volume)
+	})
+}
+
+// Cancel sends a cancel of an order
+func (c *Context) Cancel(orderID exchange.OrderID) *Request {
+	req := &Request{OrderID: orderID}
+	req.send = func(r *run) {
+		req.Err = r.exchange.CancelOrder(r.cfg.Pair, orderID)
+		req.Done = true
+	}
+	return c.submit(req)
+}
+
+func (c *Context) place(f func(*run) (exchange.OrderID, error)) *Request {
+	req := &Request{}
+	req.send = func(r *run) {
+		req.OrderID, req.Err = f(r)
+		if req.Err == nil {
+			r.owned[req.OrderID] = struct{}{}
+		}
+		req.Done = true
+	}
+	return c.submit(req)
+}
+
+// submit queues a request, or sends it right away without latency
+func (c *Context) submit(req *Request) *Request {
+	r := c.run
+	req.Due = r.now.Add(r.cfg.Latency)
+
+	if r.cfg.Latency <= 0 {
+		req.send(r)
+		return req
+	}
+
+	r.pending = append(r.pending, req)
+	return req
+}
@@ -0,0 +1,468 @@
+// Package bookcodec implements a compact binary encoding of exchange.MarketRecord streams.
+//
+// A stream is a sequence of frames, each prefixed by its length as a uvarint. A frame is
+//
+//	byte    frame type, full or diff
+//	varint  timestamp, unix seconds
+//	uvarint timestamp, nanoseconds
+//	uvarint symbol length, followed by the symbol, full frames only
+//	varint  price exponent
+//	varint  volume exponent
+//	side    bids
+//	side    asks
+//
+// and a side is a uvarint level count followed by, for every level, the varint difference between its price
+// and the previous level's price (zero for the first level) and its varint volume. Prices and volumes are
+// stored as integers scaled by the frame's exponents, the smallest exponents of the frame's values, so that
+// decoding yields exactly the encoded values.
+//
+// A full frame holds the whole orderbook. A diff frame holds the levels which changed since the previous frame,
+// removed levels having a zero volume. Diffs are only used for sorted orderbooks with unique, positive levels.
+package bookcodec
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/skycoin/exchange-api/exchange"
+)
+
+const (
+	frameFull byte = 1
+	frameDiff byte = 2
+
+	// maxFrameSize limits the memory allocated for a frame read from a corrupt stream
+	maxFrameSize = 64 << 20
+)
+
+var (
+	// ErrOverflow is returned when a price or volume scaled by its frame's exponent doesn't fit in an int64
+	ErrOverflow = errors.New("scaled value overflows int64")
+	// ErrMissingKeyframe is returned when decoding a diff frame without the frame it is based on
+	ErrMissingKeyframe = errors.New("diff frame without a previous frame")
+	// ErrInvalidFrame is returned when decoding a corrupt frame
+	ErrInvalidFrame = errors.New("invalid frame")
+)
+
+// Encoder writes MarketRecords to a stream
+type Encoder struct {
+	// KeyframeInterval is the number of frames between two full frames, frames in between are diffs
+	// against the previous record when possible. With 0 or 1 every frame is full.
+	KeyframeInterval int
+
+	w      io.Writer
+	buf    []byte
+	prev   *exchange.MarketRecord
+	frames int
+}
+
+// NewEncoder creates an Encoder writing full frames only
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{
+		w: w,
+	}
+}
+
+// Encode writes a record as one frame
+func (e *Encoder) Encode(r exchange.MarketRecord) error {
+	diff := e.KeyframeInterval > 1 && e.frames%e.KeyframeInterval != 0 &&
+		e.prev != nil && e.prev.Symbol == r.Symbol && diffable(e.prev) && diffable(&r)
+
+	var err error
+	if diff {
+		bids := diffSide(e.prev.Bids, r.Bids, true)
+		asks := diffSide(e.prev.Asks, r.Asks, false)
+		e.buf, err = appendFrame(e.buf[:0], frameDiff, r.Timestamp, r.Symbol, bids, asks)
+	} else {
+		e.buf, err = appendFrame(e.buf[:0], frameFull, r.Timestamp, r.Symbol, r.Bids, r.Asks)
+	}
+	if err != nil {
+		return err
+	}
+
+	var size [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(size[:], uint64(len(e.buf)))
+	if _, err := e.w.Write(size[:n]); err != nil {
+		return err
+	}
+	if _, err := e.w.Write(e.buf); err != nil {
+		return err
+	}
+
+	prev := r.Copy()
+	e.prev = &prev
+	e.frames++
+	return nil
+}
+
+// Marshal encodes a record as a single full frame, without the length prefix
+func Marshal(r exchange.MarketRecord) ([]byte, error) {
+	return appendFrame(nil, frameFull, r.Timestamp, r.Symbol, r.Bids, r.Asks)
+}
+
+// Unmarshal decodes a frame returned by Marshal
+func Unmarshal(b []byte) (*exchange.MarketRecord, error) {
+	return decodeFrame(b, nil)
+}
+
+// diffable returns true if the bids are strictly descending, the asks strictly ascending and all volumes positive,
+// so that a diff can be applied without changing the order of the levels
+func diffable(r *exchange.MarketRecord) bool {
+	return sortedLevels(r.Bids, true) && sortedLevels(r.Asks, false)
+}
+
+func sortedLevels(levels []exchange.MarketOrder, descending bool) bool {
+	for i, l := range levels {
+		if l.Volume.Sign() <= 0 {
+			return false
+		}
+		if i > 0 && !better(l.Price, levels[i-1].Price, descending) {
+			return false
+		}
+	}
+	return true
+}
+
+// better returns true if price a comes after price b on a side
+func better(a, b decimal.Decimal, descending bool) bool {
+	if descending {
+		return a.LessThan(b)
+	}
+	return a.GreaterThan(b)
+}
+
+// diffSide returns the changed levels from prev to cur, both sorted, with zero volumes for the removed levels
+func diffSide(prev, cur []exchange.MarketOrder, descending bool) []exchange.MarketOrder {
+	var changes []exchange.MarketOrder
+	i, j := 0, 0
+	for i < len(prev) || j < len(cur) {
+		switch {
+		case j == len(cur) || (i < len(prev) && better(cur[j].Price, prev[i].Price, descending)):
+			changes = append(changes, exchange.MarketOrder{Price: prev[i].Price, Volume: decimal.Zero})
+			i++
+		case i == len(prev) || better(prev[i].Price, cur[j].Price, descending):
+			changes = append(changes, cur[j])
+			j++
+		default:
+			if !prev[i].Volume.Equal(cur[j].Volume) {
+				changes = append(changes, cur[j])
+			}
+			i++
+			j++
+		}
+	}
+	return changes
+}
+
+// applyDiff applies changes made by diffSide to prev
+func applyDiff(prev, changes []exchange.MarketOrder, descending bool) ([]exchange.MarketOrder, error) {
+	if !sortedPrices(changes, descending) {
+		return nil, ErrInvalidFrame
+	}
+
+	result := make([]exchange.MarketOrder, 0, len(prev)+len(changes))
+	i, j := 0, 0
+	for i < len(prev) || j < len(changes) {
+		switch {
+		case j == len(changes) || (i < len(prev) && better(changes[j].Price, prev[i].Price, descending)):
+			result = append(result, prev[i])
+			i++
+		case i == len(prev) || better(prev[i].Price, changes[j].Price, descending):
+			if changes[j].Volume.Sign() <= 0 {
+				return nil, ErrInvalidFrame
+			}
+			result = append(result, changes[j])
+			j++
+		default:
+			if changes[j].Volume.Sign() > 0 {
+				result = append(result, changes[j])
+			}
+			i++
+			j++
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, nil
+	}
+	return result, nil
+}
+
+func sortedPrices(levels []exchange.MarketOrder, descending bool) bool {
+	for i := 1; i < len(levels); i++ {
+		if !better(levels[i].Price, levels[i-1].Price, descending) {
+			return false
+		}
+	}
+	return true
+}
+
+// minExponents returns the smallest price and volume exponents of the levels, 0 if there are none
+func minExponents(sides ...[]exchange.MarketOrder) (int32, int32) {
+	var priceExp, volumeExp int32
+	first := true
+	for _, side := range sides {
+		for _, l := range side {
+			if first || l.Price.Exponent() < priceExp {
+				priceExp = l.Price.Exponent()
+			}
+			if first || l.Volume.Exponent() < volumeExp {
+				volumeExp = l.Volume.Exponent()
+			}
+			first = false
+		}
+	}
+	return priceExp, volumeExp
+}
+
+// scaled returns d as an integer multiple of 10^exp, exp not greater than d's exponent
+func scaled(d decimal.Decimal, exp int32) (int64, error) {
+	v := d.Coefficient()
+	if shift := d.Exponent() - exp; shift > 0 {
+		v.Mul(v, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(shift)), nil))
+	}
+
+	if !v.IsInt64() {
+		return 0, ErrOverflow
+	}
+	return v.Int64(), nil
+}
+
+func appendFrame(b []byte, frame byte, ts time.Time, symbol string, bids, asks []exchange.MarketOrder) ([]byte, error) {
+	priceExp, volumeExp := minExponents(bids, asks)
+
+	b = append(b, frame)
+	b = appendVarint(b, ts.Unix())
+	b = appendUvarint(b, uint64(ts.Nanosecond()))
+	if frame == frameFull {
+		b = appendUvarint(b, uint64(len(symbol)))
+		b = append(b, symbol...)
+	}
+	b = appendVarint(b, int64(priceExp))
+	b = appendVarint(b, int64(volumeExp))
+
+	for _, side := range [][]exchange.MarketOrder{bids, asks} {
+		b = appendUvarint(b, uint64(len(side)))
+
+		var prev int64
+		for _, l := range side {
+			price, err := scaled(l.Price, priceExp)
+			if err != nil {
+				return nil, err
+			}
+			volume, err := scaled(l.Volume, volumeExp)
+			if err != nil {
+				return nil, err
+			}
+
+			delta := price - prev
+			if (prev < 0 && price >= 0 && delta < 0) || (prev > 0 && price < 0 && delta >= 0) {
+				return nil, ErrOverflow
+			}
+
+			b = appendVarint(b, delta)
+			b = appendVarint(b, volume)
+			prev = price
+		}
+	}
+
+	return b, nil
+}
+
+func appendVarint(b []byte, v int64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(tmp[:], v)
+	return append(b, tmp[:n]...)
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	return append(b, tmp[:n]...)
+}
+
+// Decoder reads MarketRecords from a stream written by an Encoder
+type Decoder struct {
+	r    *bufio.Reader
+	buf  []byte
+	prev *exchange.MarketRecord
+}
+
+// NewDecoder creates a Decoder reading from r
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{
+		r: bufio.NewReader(r),
+	}
+}
+
+// Decode reads the next record. It returns io.EOF at the end of the stream
+// and io.ErrUnexpectedEOF if the stream ends within a frame.
+func (d *Decoder) Decode() (*exchange.MarketRecord, error) {
+	size, err := binary.ReadUvarint(d.r)
+	switch {
+	case err == io.EOF:
+		return nil, io.EOF
+	case err != nil:
+		return nil, io.ErrUnexpectedEOF
+	case size > maxFrameSize:
+		return nil, ErrInvalidFrame
+	}
+
+	if uint64(cap(d.buf)) < size {
+		d.buf = make([]byte, size)
+	}
+	d.buf = d.buf[:size]
+	if _, err := io.ReadFull(d.r, d.buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	r, err := decodeFrame(d.buf, d.prev)
+	if err != nil {
+		return nil, err
+	}
+
+	prev := r.Copy()
+	d.prev = &prev
+	return r, nil
+}
+
+// frameReader reads the fields of a frame, recording the first error
+type frameReader struct {
+	b   []byte
+	err error
+}
+
+func (f *frameReader) varint() int64 {
+	if f.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(f.b)
+	if n <= 0 {
+		f.err = ErrInvalidFrame
+		return 0
+	}
+	f.b = f.b[n:]
+	return v
+}
+
+func (f *frameReader) uvarint() uint64 {
+	if f.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(f.b)
+	if n <= 0 {
+		f.err = ErrInvalidFrame
+		return 0
+	}
+	f.b = f.b[n:]
+	return v
+}
+
+func (f *frameReader) bytes(n uint64) []byte {
+	if f.err != nil {
+		return nil
+	}
+	if uint64(len(f.b)) < n {
+		f.err = ErrInvalidFrame
+		return nil
+	}
+	v := f.b[:n]
+	f.b = f.b[n:]
+	return v
+}
+
+func (f *frameReader) exponent() int32 {
+	v := f.varint()
+	if v < math.MinInt32 || v > math.MaxInt32 {
+		f.err = ErrInvalidFrame
+	}
+	return int32(v)
+}
+
+func (f *frameReader) side(priceExp, volumeExp int32) []exchange.MarketOrder {
+	count := f.uvarint()
+	// every level takes at least two bytes
+	if f.err != nil || count > uint64(len(f.b)/2) {
+		f.err = ErrInvalidFrame
+		return nil
+	}
+	if count == 0 {
+		return nil
+	}
+
+	levels := make([]exchange.MarketOrder, count)
+	var price int64
+	for i := range levels {
+		price += f.varint()
+		levels[i] = exchange.MarketOrder{
+			Price:  decimal.New(price, priceExp),
+			Volume: decimal.New(f.varint(), volumeExp),
+		}
+	}
+	return levels
+}
+
+func decodeFrame(b []byte, prev *exchange.MarketRecord) (*exchange.MarketRecord, error) {
+	if len(b) == 0 {
+		return nil, ErrInvalidFrame
+	}
+
+	frame := b[0]
+	f := &frameReader{b: b[1:]}
+
+	sec := f.varint()
+	nsec := f.uvarint()
+
+	r := &exchange.MarketRecord{
+		Timestamp: time.Unix(sec, int64(nsec)).UTC(),
+	}
+
+	switch frame {
+	case frameFull:
+		r.Symbol = string(f.bytes(f.uvarint()))
+	case frameDiff:
+		if prev == nil {
+			return nil, ErrMissingKeyframe
+		}
+		r.Symbol = prev.Symbol
+	default:
+		return nil, ErrInvalidFrame
+	}
+
+	priceExp := f.exponent()
+	volumeExp := f.exponent()
+	bids := f.side(priceExp, volumeExp)
+	asks := f.side(priceExp, volumeExp)
+
+	if f.err != nil {
+		return nil, f.err
+	}
+	if nsec >= uint64(time.Second) || len(f.b) != 0 {
+		return nil, ErrInvalidFrame
+	}
+
+	if frame == frameFull {
+		r.Bids = bids
+		r.Asks = asks
+		return r, nil
+	}
+
+	var err error
+	if r.Bids, err = applyDiff(prev.Bids, bids, true); err != nil {
+		return nil, err
+	}
+	if r.Asks, err = applyDiff(prev.Asks, asks, false); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
@@ -0,0 +1,298 @@
+package bookcodec
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/exchange-api/exchange"
+)
+
+var start = time.Date(2018, 3, 20, 10, 0, 0, 123456789, time.UTC)
+
+func level(price string, volume string) exchange.MarketOrder {
+	p, err := decimal.NewFromString(price)
+	if err != nil {
+		panic(err)
+	}
+	v, err := decimal.NewFromString(volume)
+	if err != nil {
+		panic(err)
+	}
+	return exchange.MarketOrder{Price: p, Volume: v}
+}
+
+// newBook returns a sorted orderbook with depth levels on each side, shifted by step ticks
+func newBook(rnd *rand.Rand, ts time.Time, depth, step int) exchange.MarketRecord {
+	r := exchange.MarketRecord{
+		Timestamp: ts,
+		Symbol:    "SKY/BTC",
+	}
+
+	tick := decimal.New(1, -8)
+	mid := decimal.New(int64(120000+step), -8)
+	for i := 1; i <= depth; i++ {
+		offset := tick.Mul(decimal.New(int64(i*3), 0))
+		r.Bids = append(r.Bids, exchange.MarketOrder{
+			Price:  mid.Sub(offset),
+			Volume: decimal.New(rnd.Int63n(1000000)+1, -4),
+		})
+		r.Asks = append(r.Asks, exchange.MarketOrder{
+			Price:  mid.Add(offset),
+			Volume: decimal.New(rnd.Int63n(1000000)+1, -4),
+		})
+	}
+	return r
+}
+
+// evolve returns a copy of r with a few levels changed, added and removed
+func evolve(rnd *rand.Rand, r exchange.MarketRecord) exchange.MarketRecord {
+	c := r.Copy()
+	c.Timestamp = c.Timestamp.Add(time.Second)
+	for i := 0; i < 3 && len(c.Bids) > 0; i++ {
+		j := rnd.Intn(len(c.Bids))
+		c.Bids[j].Volume = decimal.New(rnd.Int63n(1000000)+1, -4)
+	}
+	if len(c.Asks) > 1 {
+		c.Asks = c.Asks[1:]
+	}
+	if len(c.Bids) > 0 {
+		c.Bids = append([]exchange.MarketOrder{{
+			Price:  c.Bids[0].Price.Add(decimal.New(1, -8)),
+			Volume: decimal.New(25, -1),
+		}}, c.Bids...)
+	}
+	return c
+}
+
+func requireRecordEqual(t *testing.T, expected, actual exchange.MarketRecord) {
+	require.True(t, expected.Timestamp.Equal(actual.Timestamp), "%v != %v", expected.Timestamp, actual.Timestamp)
+	require.Equal(t, expected.Symbol, actual.Symbol)
+
+	for _, side := range []struct{ expected, actual []exchange.MarketOrder }{
+		{expected.Bids, actual.Bids},
+		{expected.Asks, actual.Asks},
+	} {
+		require.Len(t, side.actual, len(side.expected))
+		for i := range side.expected {
+			require.True(t, side.expected[i].Price.Equal(side.actual[i].Price), "%d: %s != %s", i, side.expected[i].Price, side.actual[i].Price)
+			require.True(t, side.expected[i].Volume.Equal(side.actual[i].Volume), "%d: %s != %s", i, side.expected[i].Volume, side.actual[i].Volume)
+		}
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	cases := []struct {
+		name   string
+		record exchange.MarketRecord
+	}{
+		{
+			name: "unsorted",
+			record: exchange.MarketRecord{
+				Timestamp: start,
+				Symbol:    "SKY/BTC",
+				Bids:      []exchange.MarketOrder{level("3", "7"), level("2.5", "0.00000001"), level("3", "6")},
+				Asks:      []exchange.MarketOrder{level("5", "9"), level("4", "5"), level("4.00000001", "1000000")},
+			},
+		},
+		{
+			name: "empty",
+			record: exchange.MarketRecord{
+				Symbol: "BTC_SKY",
+			},
+		},
+		{
+			name: "large exponent",
+			record: exchange.MarketRecord{
+				Timestamp: start,
+				Asks:      []exchange.MarketOrder{level("120000000000", "0"), level("-0.5", "-2")},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := Marshal(tc.record)
+			require.NoError(t, err)
+
+			r, err := Unmarshal(b)
+			require.NoError(t, err)
+			requireRecordEqual(t, tc.record, *r)
+		})
+	}
+}
+
+func TestMarshal_Overflow(t *testing.T) {
+	r := exchange.MarketRecord{
+		Bids: []exchange.MarketOrder{level("100000000000", "1"), level("0.000000001", "1")},
+	}
+	_, err := Marshal(r)
+	require.Equal(t, ErrOverflow, err)
+}
+
+func TestUnmarshal_Invalid(t *testing.T) {
+	b, err := Marshal(newBook(rand.New(rand.NewSource(1)), start, 5, 0))
+	require.NoError(t, err)
+
+	for _, corrupt := range [][]byte{
+		nil,
+		b[:len(b)-1],
+		append(append([]byte{}, b...), 0),
+		append([]byte{9}, b[1:]...),
+	} {
+		_, err := Unmarshal(corrupt)
+		require.Equal(t, ErrInvalidFrame, err)
+	}
+
+	b[0] = frameDiff
+	_, err = Unmarshal(b)
+	require.Equal(t, ErrMissingKeyframe, err)
+}
+
+func TestEncoderDecoder(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	records := []exchange.MarketRecord{newBook(rnd, start, 20, 0)}
+	for i := 1; i < 25; i++ {
+		records = append(records, evolve(rnd, records[i-1]))
+	}
+	// another symbol needs a full frame
+	records[12].Symbol = "SKY/ETH"
+	// so does an unsorted book
+	records[17].Bids[0], records[17].Bids[1] = records[17].Bids[1], records[17].Bids[0]
+	// an emptied side
+	records[20].Asks = nil
+
+	var full, diff bytes.Buffer
+	for _, r := range records {
+		require.NoError(t, NewEncoder(&full).Encode(r))
+	}
+
+	e := NewEncoder(&diff)
+	e.KeyframeInterval = 10
+	for _, r := range records {
+		require.NoError(t, e.Encode(r))
+	}
+	require.True(t, diff.Len() < full.Len()/2, "diff %d, full %d", diff.Len(), full.Len())
+
+	for _, stream := range []*bytes.Buffer{&full, &diff} {
+		d := NewDecoder(stream)
+		for _, expected := range records {
+			r, err := d.Decode()
+			require.NoError(t, err)
+			requireRecordEqual(t, expected, *r)
+		}
+
+		_, err := d.Decode()
+		require.Equal(t, io.EOF, err)
+	}
+}
+
+func TestDecoder_Truncated(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	e.KeyframeInterval = 10
+	r := newBook(rnd, start, 5, 0)
+	require.NoError(t, e.Encode(r))
+	first := buf.Len()
+	require.NoError(t, e.Encode(evolve(rnd, r)))
+
+	// a stream starting with a diff frame
+	_, err := NewDecoder(bytes.NewReader(buf.Bytes()[first:])).Decode()
+	require.Equal(t, ErrMissingKeyframe, err)
+
+	d := NewDecoder(bytes.NewReader(buf.Bytes()[:buf.Len()-1]))
+	_, err = d.Decode()
+	require.NoError(t, err)
+	_, err = d.Decode()
+	require.Equal(t, io.ErrUnexpectedEOF, err)
+}
+
+func TestSize(t *testing.T) {
+	r := newBook(rand.New(rand.NewSource(1)), start, 100, 0)
+
+	j, err := json.Marshal(r)
+	require.NoError(t, err)
+	b, err := Marshal(r)
+	require.NoError(t, err)
+
+	t.Logf("100 levels per side: json %d bytes, binary %d bytes", len(j), len(b))
+	require.True(t, len(b)*4 < len(j))
+}
+
+func benchmarkRecord() exchange.MarketRecord {
+	return newBook(rand.New(rand.NewSource(1)), start, 100, 0)
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	r := benchmarkRecord()
+	encoded, err := Marshal(r)
+	require.NoError(b, err)
+	b.SetBytes(int64(len(encoded)))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Marshal(r) // nolint: errcheck
+	}
+}
+
+func BenchmarkMarshalJSON(b *testing.B) {
+	r := benchmarkRecord()
+	encoded, err := json.Marshal(r)
+	require.NoError(b, err)
+	b.SetBytes(int64(len(encoded)))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		json.Marshal(r) // nolint: errcheck
+	}
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+	encoded, err := Marshal(benchmarkRecord())
+	require.NoError(b, err)
+	b.SetBytes(int64(len(encoded)))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Unmarshal(encoded) // nolint: errcheck
+	}
+}
+
+func BenchmarkUnmarshalJSON(b *testing.B) {
+	encoded, err := json.Marshal(benchmarkRecord())
+	require.NoError(b, err)
+	b.SetBytes(int64(len(encoded)))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var r exchange.MarketRecord
+		json.Unmarshal(encoded, &r) // nolint: errcheck
+	}
+}
+
+func BenchmarkEncodeDiff(b *testing.B) {
+	rnd := rand.New(rand.NewSource(1))
+	records := []exchange.MarketRecord{benchmarkRecord()}
+	for i := 1; i < 100; i++ {
+		records = append(records, evolve(rnd, records[i-1]))
+	}
+
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	e.KeyframeInterval = 60
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		e.Encode(records[i%len(records)]) // nolint: errcheck
+	}
+}
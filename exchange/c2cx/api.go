@@ -2,6 +2,7 @@ package c2cx
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -13,10 +14,13 @@ import (
 	// The following is nolinted because it's part of c2cx's authentication scheme
 	"crypto/md5" // nolint: gas
 
-	"net"
 	"time"
 
 	"github.com/shopspring/decimal"
+
+	"github.com/skycoin/exchange-api/exchange/internal/httpclient"
+	"github.com/skycoin/exchange-api/exchange/ratelimit"
+	"github.com/skycoin/exchange-api/exchange/retry"
 )
 
 const (
@@ -29,11 +33,11 @@ const (
 	getTickerEndpoint        = "ticker"
 )
 
-const (
-	dialTimeout         = 60 * time.Second
-	httpClientTimeout   = 120 * time.Second
-	tlsHandshakeTimeout = 60 * time.Second
-)
+// tooManyRequests is the message of the rate limit error, with code 400
+const tooManyRequests = "Too Many Requests"
+
+// EndpointRate is C2CX's documented rate limit of each endpoint
+var EndpointRate = ratelimit.Rate{Requests: 60, Per: time.Minute}
 
 var (
 	apiroot = url.URL{
@@ -89,12 +93,33 @@ func (e APIError) Error() string {
 	return fmt.Sprintf("C2CX request failed: endpoint=%s code=%d message=%s", e.Endpoint, e.Code, e.Message)
 }
 
-// Client implements a wrapper around the C2CX API interface
+// Client implements a wrapper around the C2CX API interface.
+// The methods ending with Context cancel their requests when the context is done, and then return ctx.Err().
 type Client struct {
-	Key        string
-	Secret     string
-	Debug      bool
+	Key    string
+	Secret string
+	Debug  bool
+	// HTTPClient sends the requests, http.DefaultClient if nil
 	HTTPClient *http.Client
+	// BaseURL is the root of the API endpoints, https://api.c2cx.com/v1/ if nil
+	BaseURL *url.URL
+	// UserAgent, if set, is sent in the User-Agent header
+	UserAgent string
+	// Limiter, if set, delays requests to stay within the rate limits. It can be shared by the clients of an API key.
+	Limiter *ratelimit.Limiter
+	// Retry, if set, retries failed requests. Order creation is retried after a timeout or a server error
+	// only if it has a customer ID, and no order with that ID exists.
+	Retry *retry.Policy
+}
+
+// NewLimiter creates a Limiter allowing EndpointRate requests to each endpoint and global requests in total.
+// Cancelling orders has priority over other requests, and polling market data has the lowest priority.
+func NewLimiter(global ratelimit.Rate) *ratelimit.Limiter {
+	l := ratelimit.New(global, EndpointRate)
+	l.Priorities[cancelOrderEndpoint] = ratelimit.PriorityHigh
+	l.Priorities[getOrderbookEndpoint] = ratelimit.PriorityLow
+	l.Priorities[getTickerEndpoint] = ratelimit.PriorityLow
+	return l
 }
 
 // CancelMultiError is returned when an error was encountered while cancelling multiple orders
@@ -124,33 +149,48 @@ type getOrderbookResponse struct {
 	Orderbook Orderbook `json:"data"`
 }
 
-// NewAPIClient creates new instance of Client struct and returns it
+// Options configures the HTTP requests of a Client created by NewAPIClientWithOptions. Zero fields use the defaults.
+type Options = httpclient.Options
+
+// NewAPIClient creates new instance of Client struct and returns it.
+// Its Limiter enforces EndpointRate without a global limit, and it retries with the default retry.Policy.
 func NewAPIClient(key, secret string) *Client {
-	var netTransport = &http.Transport{
-		Dial: (&net.Dialer{
-			Timeout: dialTimeout,
-		}).Dial,
-		TLSHandshakeTimeout: tlsHandshakeTimeout,
-	}
-	httpClient := &http.Client{
-		Timeout:   httpClientTimeout,
-		Transport: netTransport,
+	// the default options are valid
+	c, _ := NewAPIClientWithOptions(key, secret, Options{})
+	return c
+}
+
+// NewAPIClientWithOptions creates a Client like NewAPIClient, configured by opts.
+// An error is returned if opts.BaseURL is not an absolute URL.
+func NewAPIClientWithOptions(key, secret string, opts Options) (*Client, error) {
+	baseURL, err := opts.URL(apiroot)
+	if err != nil {
+		return nil, err
 	}
 
 	return &Client{
 		Key:        key,
 		Secret:     secret,
-		HTTPClient: httpClient,
-	}
+		HTTPClient: opts.Client(),
+		BaseURL:    baseURL,
+		UserAgent:  opts.UserAgent,
+		Limiter:    NewLimiter(ratelimit.Rate{}),
+		Retry:      retry.NewPolicy(),
+	}, nil
 }
 
 // GetOrderbook gets all open orders by given symbol
 // This method does not required API key and signing
 func (c *Client) GetOrderbook(symbol TradePair) (*Orderbook, error) {
+	return c.GetOrderbookContext(context.Background(), symbol)
+}
+
+// GetOrderbookContext is GetOrderbook with a context
+func (c *Client) GetOrderbookContext(ctx context.Context, symbol TradePair) (*Orderbook, error) {
 	params := url.Values{}
 	params.Set("symbol", string(symbol))
 
-	data, err := c.get(getOrderbookEndpoint, params)
+	data, err := c.get(ctx, getOrderbookEndpoint, params)
 	if err != nil {
 		return nil, err
 	}
@@ -173,7 +213,12 @@ type getBalanceResponse struct {
 
 // GetBalanceSummary returns user balance for all available currencies
 func (c *Client) GetBalanceSummary() (*BalanceSummary, error) {
-	data, err := c.post(getBalanceEndpoint, nil)
+	return c.GetBalanceSummaryContext(context.Background())
+}
+
+// GetBalanceSummaryContext is GetBalanceSummary with a context
+func (c *Client) GetBalanceSummaryContext(ctx context.Context) (*BalanceSummary, error) {
+	data, err := c.post(ctx, getBalanceEndpoint, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -200,6 +245,11 @@ type createOrderResponse struct {
 // advanced is a advanced options for order creation
 // if advanced is nil, isAdvancedOrder sets to zero, else advanced will be used as advanced options
 func (c *Client) CreateOrder(symbol TradePair, price, quantity decimal.Decimal, orderType OrderType, priceType PriceType, customerID *string, advanced *AdvancedOrderParams) (OrderID, error) {
+	return c.CreateOrderContext(context.Background(), symbol, price, quantity, orderType, priceType, customerID, advanced)
+}
+
+// CreateOrderContext is CreateOrder with a context
+func (c *Client) CreateOrderContext(ctx context.Context, symbol TradePair, price, quantity decimal.Decimal, orderType OrderType, priceType PriceType, customerID *string, advanced *AdvancedOrderParams) (OrderID, error) {
 	params := url.Values{}
 	params.Set("symbol", string(symbol))
 	params.Set("price", price.String())
@@ -233,7 +283,56 @@ func (c *Client) CreateOrder(symbol TradePair, price, quantity decimal.Decimal,
 		params.Set("isAdvancedOrder", "0")
 	}
 
-	data, err := c.post(createOrderEndpoint, params)
+	// The order may have been placed if the request timed out or got a server error. It is only retried
+	// if no order has its customer ID; a retry placing the order again is rejected, customer IDs can't be reused.
+	var (
+		orderID  OrderID
+		attempts int
+		unknown  bool
+	)
+	err := c.Retry.Do(ctx, customerID != nil, func(err error) retry.Class {
+		if unknown {
+			return retry.Permanent
+		}
+		return classify(err)
+	}, func() error {
+		var err error
+		orderID, err = c.createOrder(ctx, params)
+		attempts++
+		if err == nil || customerID == nil {
+			return err
+		}
+
+		switch classify(err) {
+		case retry.RateLimited:
+			// rejected before being placed
+			return err
+		case retry.Permanent:
+			// only a retry can be rejected because an earlier attempt placed the order
+			if attempts == 1 {
+				return err
+			}
+		}
+
+		o, lookupErr := c.findOrderByCustomerID(ctx, symbol, *customerID)
+		switch {
+		case lookupErr != nil:
+			unknown = true
+		case o != nil:
+			orderID = o.OrderID
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return orderID, nil
+}
+
+func (c *Client) createOrder(ctx context.Context, params url.Values) (OrderID, error) {
+	data, err := c.send(ctx, http.MethodPost, createOrderEndpoint, params)
 	if err != nil {
 		return 0, err
 	}
@@ -250,6 +349,21 @@ func (c *Client) CreateOrder(symbol TradePair, price, quantity decimal.Decimal,
 	return resp.Order.OrderID, nil
 }
 
+// findOrderByCustomerID returns the order with a customer ID, nil if there is none
+func (c *Client) findOrderByCustomerID(ctx context.Context, symbol TradePair, customerID string) (*Order, error) {
+	orders, err := c.GetOrderByStatusContext(ctx, symbol, StatusAll)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range orders {
+		if orders[i].CustomerID != nil && *orders[i].CustomerID == customerID {
+			return &orders[i], nil
+		}
+	}
+	return nil, nil
+}
+
 type getOrderInfoResponse struct {
 	status
 	Order Order `json:"data"`
@@ -257,11 +371,16 @@ type getOrderInfoResponse struct {
 
 // GetOrderInfo returns extended information about given order
 func (c *Client) GetOrderInfo(symbol TradePair, orderID OrderID) (*Order, error) {
+	return c.GetOrderInfoContext(context.Background(), symbol, orderID)
+}
+
+// GetOrderInfoContext is GetOrderInfo with a context
+func (c *Client) GetOrderInfoContext(ctx context.Context, symbol TradePair, orderID OrderID) (*Order, error) {
 	params := url.Values{}
 	params.Set("orderId", fmt.Sprint(orderID))
 	params.Set("symbol", string(symbol))
 
-	data, err := c.post(getOrderInfoEndpoint, params)
+	data, err := c.post(ctx, getOrderInfoEndpoint, params)
 	if err != nil {
 		return nil, err
 	}
@@ -286,22 +405,33 @@ type getOrderInfoAllResponse struct {
 // GetOrderInfoAll returns extended information about all orders
 // Returns a 400 if it decides there are no orders (there may be orders but it can disagree).
 func (c *Client) GetOrderInfoAll(symbol TradePair) ([]Order, error) {
+	return c.GetOrderInfoAllContext(context.Background(), symbol)
+}
+
+// GetOrderInfoAllContext is GetOrderInfoAll with a context
+func (c *Client) GetOrderInfoAllContext(ctx context.Context, symbol TradePair) ([]Order, error) {
 	params := url.Values{}
 	params.Set("orderId", allOrders)
 	params.Set("symbol", string(symbol))
 
-	data, err := c.post(getOrderInfoEndpoint, params)
+	data, err := c.post(ctx, getOrderInfoEndpoint, params)
 	if err != nil {
 		return nil, err
 	}
 
-	var resp getOrderInfoAllResponse
-	if err := json.Unmarshal(data, &resp); err != nil {
+	// the data of an error response is an object, check the status before decoding the orders
+	var st status
+	if err := json.Unmarshal(data, &st); err != nil {
 		return nil, NewOtherError(err)
 	}
 
-	if resp.status.Code != http.StatusOK {
-		return nil, NewAPIError(getOrderInfoEndpoint, resp.status.Code, resp.status.Message)
+	if st.Code != http.StatusOK {
+		return nil, NewAPIError(getOrderInfoEndpoint, st.Code, st.Message)
+	}
+
+	var resp getOrderInfoAllResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, NewOtherError(err)
 	}
 
 	return resp.Orders, nil
@@ -314,10 +444,15 @@ type cancelOrderResponse struct {
 
 // CancelOrder cancel order with given orderID
 func (c *Client) CancelOrder(orderID OrderID) error {
+	return c.CancelOrderContext(context.Background(), orderID)
+}
+
+// CancelOrderContext is CancelOrder with a context
+func (c *Client) CancelOrderContext(ctx context.Context, orderID OrderID) error {
 	params := url.Values{}
 	params.Set("orderId", fmt.Sprint(orderID))
 
-	data, err := c.post(cancelOrderEndpoint, params)
+	data, err := c.post(ctx, cancelOrderEndpoint, params)
 	if err != nil {
 		return err
 	}
@@ -344,15 +479,21 @@ type getOrderByStatusResponse struct {
 }
 
 // GetOrderByStatusPaged get all orders with given status for a given pagination page.
+// It returns the orders, the page index and the page count. Page 0 is the same as page 1.
 // NOTE: GetOrderByStatusPaged may returns orders with a different status than specified
 func (c *Client) GetOrderByStatusPaged(symbol TradePair, status OrderStatus, page int) ([]Order, int, int, error) {
+	return c.GetOrderByStatusPagedContext(context.Background(), symbol, status, page)
+}
+
+// GetOrderByStatusPagedContext is GetOrderByStatusPaged with a context
+func (c *Client) GetOrderByStatusPagedContext(ctx context.Context, symbol TradePair, status OrderStatus, page int) ([]Order, int, int, error) {
 	params := url.Values{}
 	params.Set("symbol", string(symbol))
 	params.Set("status", fmt.Sprint(status))
 	params.Set("pageindex", fmt.Sprint(page))
 	params.Set("pagesize", fmt.Sprint(maxPageSize))
 
-	data, err := c.post(getOrderByStatusEndpoint, params)
+	data, err := c.post(ctx, getOrderByStatusEndpoint, params)
 	if err != nil {
 		return nil, 0, 0, err
 	}
@@ -365,15 +506,29 @@ func (c *Client) GetOrderByStatusPaged(symbol TradePair, status OrderStatus, pag
 	if resp.status.Code != http.StatusOK {
 		return nil, 0, 0, NewAPIError(getOrderByStatusEndpoint, resp.status.Code, resp.status.Message)
 	}
-	return resp.Data.Rows, *resp.Data.PageIndex, resp.Data.pagination.PageCount, nil
+
+	// the API returns a null pageindex, see note in api_notes.go
+	index := page
+	if resp.Data.PageIndex != nil {
+		index = *resp.Data.PageIndex
+	} else if index == 0 {
+		index = 1
+	}
+
+	return resp.Data.Rows, index, resp.Data.pagination.PageCount, nil
 }
 
 // GetOrderByStatus get all orders with given status. Makes multiple calls in the event of pagination.
 // NOTE: GetOrderByStatus may returns orders with a different status than specified
 func (c *Client) GetOrderByStatus(symbol TradePair, status OrderStatus) ([]Order, error) {
+	return c.GetOrderByStatusContext(context.Background(), symbol, status)
+}
+
+// GetOrderByStatusContext is GetOrderByStatus with a context
+func (c *Client) GetOrderByStatusContext(ctx context.Context, symbol TradePair, status OrderStatus) ([]Order, error) {
 	page := 1
 
-	pageOrders, _, nPages, err := c.GetOrderByStatusPaged(symbol, status, page)
+	pageOrders, _, nPages, err := c.GetOrderByStatusPagedContext(ctx, symbol, status, page)
 	if err != nil {
 		return nil, err
 	}
@@ -382,7 +537,7 @@ func (c *Client) GetOrderByStatus(symbol TradePair, status OrderStatus) ([]Order
 	page++
 
 	for page <= nPages {
-		pageOrders, _, nPages, err = c.GetOrderByStatusPaged(symbol, status, page)
+		pageOrders, _, nPages, err = c.GetOrderByStatusPagedContext(ctx, symbol, status, page)
 		if err != nil {
 			return nil, err
 		}
@@ -397,7 +552,12 @@ func (c *Client) GetOrderByStatus(symbol TradePair, status OrderStatus) ([]Order
 // CancelAll cancels all executed orders for an orderbook.
 // If it encounters an error, it aborts and returns the order IDs that had been cancelled to that point.
 func (c *Client) CancelAll(symbol TradePair) ([]OrderID, error) {
-	orders, err := c.GetOrderInfoAll(symbol)
+	return c.CancelAllContext(context.Background(), symbol)
+}
+
+// CancelAllContext is CancelAll with a context
+func (c *Client) CancelAllContext(ctx context.Context, symbol TradePair) ([]OrderID, error) {
+	orders, err := c.GetOrderInfoAllContext(ctx, symbol)
 	if err != nil {
 		return nil, err
 	}
@@ -409,18 +569,28 @@ func (c *Client) CancelAll(symbol TradePair) ([]OrderID, error) {
 		}
 	}
 
-	return c.CancelMultiple(orderIDs)
+	return c.CancelMultipleContext(ctx, orderIDs)
 }
 
 // CancelMultiple cancels multiple orders.  It will try to cancel all of them, not
 // stopping for any individual error. If any orders failed to cancel, a CancelMultiError is returned
 // along with the array of order IDs which were successfully cancelled.
 func (c *Client) CancelMultiple(orderIDs []OrderID) ([]OrderID, error) {
+	return c.CancelMultipleContext(context.Background(), orderIDs)
+}
+
+// CancelMultipleContext is CancelMultiple with a context.
+// Once ctx is done, the remaining orders are not sent and fail with ctx.Err().
+func (c *Client) CancelMultipleContext(ctx context.Context, orderIDs []OrderID) ([]OrderID, error) {
 	var cancelledOrderIDs []OrderID
 	var cancelErr CancelMultiError
 
 	for _, v := range orderIDs {
-		if err := c.CancelOrder(v); err != nil {
+		err := ctx.Err()
+		if err == nil {
+			err = c.CancelOrderContext(ctx, v)
+		}
+		if err != nil {
 			cancelErr.OrderIDs = append(cancelErr.OrderIDs, v)
 			cancelErr.Errors = append(cancelErr.Errors, err)
 			continue
@@ -437,7 +607,12 @@ func (c *Client) CancelMultiple(orderIDs []OrderID) ([]OrderID, error) {
 
 // LimitBuy place limit buy order
 func (c *Client) LimitBuy(symbol TradePair, price, amount decimal.Decimal, customerID *string) (OrderID, error) {
-	orderID, err := c.CreateOrder(symbol, price, amount, OrderTypeBuy, PriceTypeLimit, customerID, nil)
+	return c.LimitBuyContext(context.Background(), symbol, price, amount, customerID)
+}
+
+// LimitBuyContext is LimitBuy with a context
+func (c *Client) LimitBuyContext(ctx context.Context, symbol TradePair, price, amount decimal.Decimal, customerID *string) (OrderID, error) {
+	orderID, err := c.CreateOrderContext(ctx, symbol, price, amount, OrderTypeBuy, PriceTypeLimit, customerID, nil)
 	if err != nil {
 		return 0, err
 	}
@@ -447,7 +622,12 @@ func (c *Client) LimitBuy(symbol TradePair, price, amount decimal.Decimal, custo
 
 // LimitSell place limit sell order
 func (c *Client) LimitSell(symbol TradePair, price, amount decimal.Decimal, customerID *string) (OrderID, error) {
-	orderID, err := c.CreateOrder(symbol, price, amount, OrderTypeSell, PriceTypeLimit, customerID, nil)
+	return c.LimitSellContext(context.Background(), symbol, price, amount, customerID)
+}
+
+// LimitSellContext is LimitSell with a context
+func (c *Client) LimitSellContext(ctx context.Context, symbol TradePair, price, amount decimal.Decimal, customerID *string) (OrderID, error) {
+	orderID, err := c.CreateOrderContext(ctx, symbol, price, amount, OrderTypeSell, PriceTypeLimit, customerID, nil)
 	if err != nil {
 		return 0, err
 	}
@@ -459,7 +639,12 @@ func (c *Client) LimitSell(symbol TradePair, price, amount decimal.Decimal, cust
 // the trade pair's first coin in exchange for the second coin.
 // e.g. for BTC_SKY, the amount is the amount of BTC you want to spend on SKY.
 func (c *Client) MarketBuy(symbol TradePair, amount decimal.Decimal, customerID *string) (OrderID, error) {
-	orderID, err := c.CreateOrder(symbol, amount, decimal.Zero, OrderTypeBuy, PriceTypeMarket, customerID, nil)
+	return c.MarketBuyContext(context.Background(), symbol, amount, customerID)
+}
+
+// MarketBuyContext is MarketBuy with a context
+func (c *Client) MarketBuyContext(ctx context.Context, symbol TradePair, amount decimal.Decimal, customerID *string) (OrderID, error) {
+	orderID, err := c.CreateOrderContext(ctx, symbol, amount, decimal.Zero, OrderTypeBuy, PriceTypeMarket, customerID, nil)
 	if err != nil {
 		return 0, err
 	}
@@ -471,7 +656,12 @@ func (c *Client) MarketBuy(symbol TradePair, amount decimal.Decimal, customerID
 // of the trade pair's second coin in exchange for the first coin.
 // e.g. for BTC_SKY, the amount is the amount of SKY you want to sell for BTC.
 func (c *Client) MarketSell(symbol TradePair, amount decimal.Decimal, customerID *string) (OrderID, error) {
-	orderID, err := c.CreateOrder(symbol, decimal.Zero, amount, OrderTypeSell, PriceTypeMarket, customerID, nil)
+	return c.MarketSellContext(context.Background(), symbol, amount, customerID)
+}
+
+// MarketSellContext is MarketSell with a context
+func (c *Client) MarketSellContext(ctx context.Context, symbol TradePair, amount decimal.Decimal, customerID *string) (OrderID, error) {
+	orderID, err := c.CreateOrderContext(ctx, symbol, decimal.Zero, amount, OrderTypeSell, PriceTypeMarket, customerID, nil)
 	if err != nil {
 		return 0, err
 	}
@@ -486,9 +676,14 @@ type getTickerResponse struct {
 }
 
 func (c *Client) GetTicker(symbol TradePair) (*TickerData, error) {
+	return c.GetTickerContext(context.Background(), symbol)
+}
+
+// GetTickerContext is GetTicker with a context
+func (c *Client) GetTickerContext(ctx context.Context, symbol TradePair) (*TickerData, error) {
 	params := url.Values{}
 	params.Add("symbol", string(symbol))
-	data, err := c.get(getTickerEndpoint, params)
+	data, err := c.get(ctx, getTickerEndpoint, params)
 	if err != nil {
 		return nil, err
 	}
@@ -502,65 +697,124 @@ func (c *Client) GetTicker(symbol TradePair) (*TickerData, error) {
 	return &resp.Data, err
 }
 
-func (c *Client) get(method string, params url.Values) ([]byte, error) { // nolint: unparam
-	reqURL := apiroot
-	reqURL.Path += method
-	reqURL.RawQuery = params.Encode()
-	resp, err := c.HTTPClient.Get(reqURL.String())
-	if err != nil {
-		return nil, NewOtherError(err)
+// wait waits for the Limiter to allow a request to an endpoint
+func (c *Client) wait(ctx context.Context, method string) error {
+	if c.Limiter == nil {
+		return nil
 	}
 
-	defer resp.Body.Close() // nolint: errcheck
+	return c.Limiter.Wait(ctx, method)
+}
 
-	b, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, NewOtherError(err)
+// contextError returns ctx.Err() if ctx is done, since it caused err, otherwise err as an OtherError
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
 	}
+	return NewOtherError(err)
+}
 
-	// NOTE:
-	// c2cx's API always returns 200 OK except for 500 errors
-	// Instead, it places the status code inside of a JSON object per request
-	// The caller must handle the status code, since the structure of the JSON
-	// is different across requests
-	if resp.StatusCode >= 500 && resp.StatusCode < 600 {
-		message := http.StatusText(resp.StatusCode)
-		if message == "" {
-			message = "Unexpected server error"
+// classify classifies the errors of requests for retries
+func classify(err error) retry.Class {
+	switch e := err.(type) {
+	case APIError:
+		switch {
+		case e.Code == http.StatusBadRequest && e.Message == tooManyRequests:
+			return retry.RateLimited
+		case e.Code >= 500 && e.Code < 600:
+			return retry.Transient
 		}
-		return nil, NewAPIError(method, resp.StatusCode, message)
+	case OtherError:
+		return retry.NetClass(e.error)
 	}
+	return retry.Permanent
+}
 
-	if c.Debug {
-		fmt.Printf("GET endpoint=%s response=%s\n", reqURL.String(), string(b))
-	}
+// get sends a GET request, retrying it according to c.Retry. All GET endpoints are idempotent.
+func (c *Client) get(ctx context.Context, method string, params url.Values) ([]byte, error) { // nolint: unparam
+	var data []byte
+	err := c.Retry.Do(ctx, true, classify, func() error {
+		var err error
+		data, err = c.send(ctx, http.MethodGet, method, params)
+		return err
+	})
+	return data, err
+}
 
-	return b, nil
+// post sends a POST request to an idempotent endpoint, retrying it according to c.Retry.
+// Order creation is handled by CreateOrder.
+func (c *Client) post(ctx context.Context, method string, params url.Values) ([]byte, error) {
+	var data []byte
+	err := c.Retry.Do(ctx, true, classify, func() error {
+		var err error
+		data, err = c.send(ctx, http.MethodPost, method, params)
+		return err
+	})
+	return data, err
 }
 
-func (c *Client) post(method string, params url.Values) ([]byte, error) {
+// send sends a request once. POST requests are signed.
+// The body of a response with a 5xx status or a "Too Many Requests" code is returned as an APIError.
+// If ctx is done before the response is read, ctx.Err() is returned.
+func (c *Client) send(ctx context.Context, httpMethod, method string, params url.Values) ([]byte, error) {
+	if err := c.wait(ctx, method); err != nil {
+		return nil, err
+	}
+
 	reqURL := apiroot
+	if c.BaseURL != nil {
+		reqURL = *c.BaseURL
+	}
 	reqURL.Path += method
 
-	if params == nil {
-		params = url.Values{}
-	}
+	var (
+		req  *http.Request
+		body string
+		err  error
+	)
+	if httpMethod == http.MethodGet {
+		reqURL.RawQuery = params.Encode()
+		req, err = http.NewRequest(http.MethodGet, reqURL.String(), nil)
+	} else {
+		// copy the params, the caller may send them again
+		signed := url.Values{}
+		for k, v := range params {
+			signed[k] = v
+		}
 
-	signature := signParams(c.Key, c.Secret, params)
+		signature := signParams(c.Key, c.Secret, signed)
 
-	params.Set("apiKey", c.Key)
-	body := fmt.Sprintf("%s&sign=%s", params.Encode(), signature)
+		signed.Set("apiKey", c.Key)
+		body = fmt.Sprintf("%s&sign=%s", signed.Encode(), signature)
 
-	resp, err := c.HTTPClient.Post(reqURL.String(), "application/x-www-form-urlencoded", strings.NewReader(body))
+		req, err = http.NewRequest(http.MethodPost, reqURL.String(), strings.NewReader(body))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	}
 	if err != nil {
 		return nil, NewOtherError(err)
 	}
 
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, contextError(ctx, err)
+	}
+
 	defer resp.Body.Close() // nolint: errcheck
 
 	b, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, NewOtherError(err)
+		return nil, contextError(ctx, err)
 	}
 
 	// NOTE:
@@ -577,7 +831,17 @@ func (c *Client) post(method string, params url.Values) ([]byte, error) {
 	}
 
 	if c.Debug {
-		fmt.Printf("POST endpoint=%s body=%s response=%s\n", reqURL.String(), body, string(b))
+		if httpMethod == http.MethodGet {
+			fmt.Printf("GET endpoint=%s response=%s\n", reqURL.String(), string(b))
+		} else {
+			fmt.Printf("POST endpoint=%s body=%s response=%s\n", reqURL.String(), body, string(b))
+		}
+	}
+
+	// the rate limit error has the same shape for every endpoint
+	var st status
+	if err := json.Unmarshal(b, &st); err == nil && st.Code == http.StatusBadRequest && st.Message == tooManyRequests {
+		return nil, NewAPIError(method, st.Code, st.Message)
 	}
 
 	return b, nil
@@ -14,6 +14,9 @@ Instead, a non-200 code is set in a field in the JSON response if an error occur
 According to the c2cx docs, requests are ratelimited at 60 requests per minute per endpoint.
 This doesn't seem to be the true ratelimit, but the error is
 {"code":400,"message":"Too Many Requests","data":{}}
+Client.Limiter enforces the documented limit, see NewLimiter.
+Client.Retry retries requests failing with this error, after a delay.
+CreateOrder is only retried with a cid, after looking up that no order with the cid was placed.
 
 For market buy orders, if the amount is below some threshold, the error message is "limit value: <minimum>".
 The <minimum> value is VARIABLE and based upon some other (USD?) exchange rate.
@@ -1,14 +1,24 @@
 package c2cx
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/exchange-api/exchange/internal/httpclient"
+	"github.com/skycoin/exchange-api/exchange/ratelimit"
+	"github.com/skycoin/exchange-api/exchange/retry"
 )
 
 func ExampleMarketBuy() { // nolint: vet
@@ -62,3 +72,284 @@ func TestErrorInterfaces(t *testing.T) {
 	apiErr := NewAPIError(getOrderbookEndpoint, http.StatusBadRequest, "failed")
 	require.Implements(t, (*Error)(nil), apiErr)
 }
+
+func TestNewLimiter(t *testing.T) {
+	c := NewAPIClient("", "")
+	require.NotNil(t, c.Limiter)
+	require.Equal(t, EndpointRate, c.Limiter.Endpoint)
+	require.True(t, c.Limiter.Global.Unlimited())
+
+	l := NewLimiter(ratelimit.Rate{Requests: 100, Per: time.Minute})
+	require.Equal(t, ratelimit.PriorityHigh, l.Priorities[cancelOrderEndpoint])
+	require.Equal(t, ratelimit.PriorityLow, l.Priorities[getTickerEndpoint])
+	require.Equal(t, ratelimit.PriorityNormal, l.Priorities[createOrderEndpoint])
+}
+
+// newTestServer creates a Client of a test server, the returned function closes it
+func newTestServer(t *testing.T, handler http.HandlerFunc) (*Client, func()) {
+	srv := httptest.NewServer(handler)
+
+	c, err := NewAPIClientWithOptions("key", "secret", Options{BaseURL: srv.URL + "/v1"})
+	require.NoError(t, err)
+	c.Limiter = nil
+	c.Retry.BaseDelay = time.Millisecond
+	c.Retry.RateLimitDelay = time.Millisecond
+
+	return c, srv.Close
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestNewAPIClientWithOptions(t *testing.T) {
+	c := NewAPIClient("", "")
+	require.Equal(t, "https://api.c2cx.com/v1/", c.BaseURL.String())
+	require.Equal(t, httpclient.Timeout, c.HTTPClient.Timeout)
+
+	_, err := NewAPIClientWithOptions("", "", Options{BaseURL: "/v1/"})
+	require.Error(t, err)
+
+	var requests []*http.Request
+	c, err = NewAPIClientWithOptions("", "", Options{
+		BaseURL: "http://localhost:8080/c2cx",
+		Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			requests = append(requests, r)
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"code":200,"message":"success","data":{"timestamp":"1520934562"}}`)),
+			}, nil
+		}),
+		Timeout:   time.Second,
+		UserAgent: "exchange-api-test",
+	})
+	require.NoError(t, err)
+	require.Equal(t, time.Second, c.HTTPClient.Timeout)
+
+	_, err = c.GetTicker(BtcSky)
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+	require.Equal(t, "http://localhost:8080/c2cx/ticker?symbol=BTC_SKY", requests[0].URL.String())
+	require.Equal(t, "exchange-api-test", requests[0].Header.Get("User-Agent"))
+}
+
+func TestRetry(t *testing.T) {
+	ticker := `{"code":200,"message":"success","data":{"timestamp":"1520934562","last":0.001}}`
+
+	cases := []struct {
+		name      string
+		responses []string
+		status    []int
+		calls     int
+		err       string
+	}{
+		{
+			name:      "rate limited",
+			responses: []string{`{"code":400,"message":"Too Many Requests","data":{}}`, ticker},
+			status:    []int{http.StatusOK, http.StatusOK},
+			calls:     2,
+		},
+		{
+			name:      "server error",
+			responses: []string{"", "", ticker},
+			status:    []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK},
+			calls:     3,
+		},
+		{
+			name:      "gives up",
+			responses: []string{"", "", "", "", ticker},
+			status:    []int{500, 500, 500, 500, 200},
+			calls:     4,
+			err:       "code=500",
+		},
+		{
+			name:      "permanent",
+			responses: []string{`{"code":400,"message":"invalid symbol","data":{}}`, ticker},
+			status:    []int{http.StatusOK, http.StatusOK},
+			calls:     1,
+			err:       "invalid symbol",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			c, done := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "/v1/ticker", r.URL.Path)
+				w.WriteHeader(tc.status[calls])
+				w.Write([]byte(tc.responses[calls])) // nolint: errcheck
+				calls++
+			})
+			defer done()
+
+			_, err := c.GetTicker(BtcSky)
+			if tc.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.err)
+			}
+			require.Equal(t, tc.calls, calls)
+		})
+	}
+}
+
+func TestCreateOrderRetry(t *testing.T) {
+	created := `{"code":200,"message":"success","data":{"orderId":42}}`
+	placed := `{"code":200,"message":"success","data":{"rows":[{"orderId":41,"cid":"other","status":2},
+		{"orderId":43,"cid":"my-order","status":2}],"pageindex":1,"pagesize":100,"recordcount":2,"pagecount":1}}`
+	notPlaced := `{"code":200,"message":"success","data":{"rows":[],"pageindex":1,"pagesize":100,"recordcount":0,"pagecount":1}}`
+
+	cases := []struct {
+		name       string
+		customerID *string
+		create     []int
+		orders     string
+		orderID    OrderID
+		creates    int
+		err        bool
+	}{
+		{
+			name:    "without cid",
+			create:  []int{http.StatusServiceUnavailable, http.StatusOK},
+			creates: 1,
+			err:     true,
+		},
+		{
+			name:       "placed",
+			customerID: &[]string{"my-order"}[0],
+			create:     []int{http.StatusServiceUnavailable, http.StatusOK},
+			orders:     placed,
+			orderID:    43,
+			creates:    1,
+		},
+		{
+			name:       "not placed",
+			customerID: &[]string{"my-order"}[0],
+			create:     []int{http.StatusServiceUnavailable, http.StatusOK},
+			orders:     notPlaced,
+			orderID:    42,
+			creates:    2,
+		},
+		{
+			name:       "lookup failed",
+			customerID: &[]string{"my-order"}[0],
+			create:     []int{http.StatusServiceUnavailable, http.StatusOK},
+			orders:     `{"code":400,"message":"bad request","data":{}}`,
+			creates:    1,
+			err:        true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			creates := 0
+			var signature string
+			c, done := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				require.NoError(t, r.ParseForm())
+				switch r.URL.Path {
+				case "/v1/createorder":
+					// retries send the same signed request
+					if creates > 0 {
+						require.Equal(t, signature, r.PostForm.Get("sign"))
+					}
+					signature = r.PostForm.Get("sign")
+
+					w.WriteHeader(tc.create[creates])
+					creates++
+					w.Write([]byte(created)) // nolint: errcheck
+				case "/v1/getorderbystatus":
+					w.Write([]byte(tc.orders)) // nolint: errcheck
+				default:
+					t.Fatalf("unexpected request %s", r.URL.Path)
+				}
+			})
+			defer done()
+
+			orderID, err := c.LimitBuy(BtcSky, decimal.New(1, -3), decimal.New(10, 0), tc.customerID)
+			if tc.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.orderID, orderID)
+			}
+			require.Equal(t, tc.creates, creates)
+		})
+	}
+}
+
+func TestClassify(t *testing.T) {
+	require.Equal(t, retry.RateLimited, classify(NewAPIError(getTickerEndpoint, 400, tooManyRequests)))
+	require.Equal(t, retry.Transient, classify(NewAPIError(getTickerEndpoint, 502, "Bad Gateway")))
+	require.Equal(t, retry.Permanent, classify(NewAPIError(getTickerEndpoint, 400, "invalid symbol")))
+	require.Equal(t, retry.Permanent, classify(NewOtherError(errors.New("invalid character"))))
+	require.Equal(t, retry.Transient, classify(NewOtherError(&net.DNSError{IsTimeout: true})))
+}
+
+func TestContext_Deadline(t *testing.T) {
+	c, done := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	})
+	defer done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := c.GetTickerContext(ctx, BtcSky)
+	require.Equal(t, context.DeadlineExceeded, err)
+}
+
+// cancelOnCall returns a handler which cancels the request context on call n and waits for the request to be aborted
+func cancelOnCall(n int, calls *int, cancel func(), response string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		if *calls == n {
+			// the connection is only watched once the body is read
+			ioutil.ReadAll(r.Body) // nolint: errcheck
+			cancel()
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		w.Write([]byte(response)) // nolint: errcheck
+	}
+}
+
+func TestGetOrderByStatusContext_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	calls := 0
+	page := `{"code":200,"message":"success","data":{"rows":[{"orderId":41,"status":2}],"pageindex":1,"pagesize":1,"recordcount":3,"pagecount":3}}`
+	c, done := newTestServer(t, cancelOnCall(2, &calls, cancel, page))
+	defer done()
+
+	_, err := c.GetOrderByStatusContext(ctx, BtcSky, StatusAll)
+	require.Equal(t, context.Canceled, err)
+	require.Equal(t, 2, calls)
+}
+
+func TestCancelMultipleContext_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	calls := 0
+	c, done := newTestServer(t, cancelOnCall(2, &calls, cancel, `{"code":200,"message":"success","data":{}}`))
+	defer done()
+
+	cancelled, err := c.CancelMultipleContext(ctx, []OrderID{1, 2, 3})
+	require.Equal(t, []OrderID{1}, cancelled)
+	require.Equal(t, 2, calls)
+
+	multiErr, ok := err.(*CancelMultiError)
+	require.True(t, ok)
+	require.Equal(t, []OrderID{2, 3}, multiErr.OrderIDs)
+	require.Equal(t, []error{context.Canceled, context.Canceled}, multiErr.Errors)
+}
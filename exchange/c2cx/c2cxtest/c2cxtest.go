@@ -0,0 +1,662 @@
+// Package c2cxtest provides a fake of the C2CX v1 API for tests, so that c2cx.Client can be used without API keys.
+// The fake checks request signatures and reproduces the quirks described in the c2cx package's api_notes.go.
+// It does not match orders: limit orders stay active until a test changes their status.
+package c2cxtest
+
+import (
+	"crypto/md5" // nolint: gas
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/skycoin/exchange-api/exchange"
+	"github.com/skycoin/exchange-api/exchange/c2cx"
+)
+
+// Error messages of the fake. The messages of the real API are not documented, except for TooManyRequests.
+const (
+	TooManyRequests  = "Too Many Requests"
+	InvalidSign      = "invalid sign"
+	InvalidSymbol    = "invalid symbol"
+	InvalidParameter = "invalid parameter"
+	OrderNotFound    = "order not found"
+	DuplicateCID     = "cid already exists"
+	NoOrders         = "no orders"
+)
+
+const apiPath = "/v1/"
+
+type failure struct {
+	code    int
+	message string
+}
+
+type balance struct {
+	total  decimal.Decimal
+	frozen decimal.Decimal
+}
+
+type order struct {
+	symbol c2cx.TradePair
+	order  c2cx.Order
+	// frozen is the amount of the spent currency held by the order while it is active
+	frozen decimal.Decimal
+}
+
+// Server is a fake C2CX API server. It is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	// Key and Secret are the API credentials the POST requests must be signed with
+	Key    string
+	Secret string
+	// MarketBuyMinimum, if positive, is the minimum amount of a market buy order
+	MarketBuyMinimum decimal.Decimal
+	// Now returns the time of the responses' timestamps
+	Now func() time.Time
+
+	mu       sync.Mutex
+	books    map[c2cx.TradePair]c2cx.Orderbook
+	tickers  map[c2cx.TradePair]c2cx.TickerData
+	balances map[string]*balance
+	orders   []*order
+	cids     map[string]bool
+	nextID   c2cx.OrderID
+	failures map[string][]failure
+	requests map[string]int
+}
+
+// NewServer starts a Server accepting requests signed with key and secret. It must be closed with Close.
+func NewServer(key, secret string) *Server {
+	s := &Server{
+		Key:      key,
+		Secret:   secret,
+		Now:      time.Now,
+		books:    make(map[c2cx.TradePair]c2cx.Orderbook),
+		tickers:  make(map[c2cx.TradePair]c2cx.TickerData),
+		balances: make(map[string]*balance),
+		cids:     make(map[string]bool),
+		nextID:   1,
+		failures: make(map[string][]failure),
+		requests: make(map[string]int),
+	}
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// Client returns a c2cx.Client of the server, without rate limit and with short retry delays
+func (s *Server) Client() *c2cx.Client {
+	c, err := c2cx.NewAPIClientWithOptions(s.Key, s.Secret, c2cx.Options{BaseURL: s.URL + apiPath})
+	if err != nil {
+		panic(err)
+	}
+
+	c.Limiter = nil
+	c.Retry.BaseDelay = time.Millisecond
+	c.Retry.RateLimitDelay = time.Millisecond
+	return c
+}
+
+// SetOrderbook sets the orderbook of a symbol, which also makes the symbol tradable
+func (s *Server) SetOrderbook(symbol c2cx.TradePair, bids, asks exchange.MarketOrders) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.books[symbol] = c2cx.Orderbook{
+		TradePair: symbol,
+		Bids:      bids,
+		Asks:      asks,
+	}
+}
+
+// SetTicker sets the ticker of a symbol. Without one, the ticker's buy and sell prices are the best
+// prices of the orderbook.
+func (s *Server) SetTicker(symbol c2cx.TradePair, ticker c2cx.TickerData) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tickers[symbol] = ticker
+}
+
+// SetBalance sets the total balance of a currency, e.g. "btc". Active orders freeze part of it.
+func (s *Server) SetBalance(currency string, total decimal.Decimal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.balance(currency).total = total
+}
+
+// Fail makes the next request to an endpoint, e.g. "ticker", fail with a code and a message.
+// Codes of 500 and above are sent as the HTTP status, other codes in the JSON response like the real API.
+// Failures are queued, each one is used once.
+func (s *Server) Fail(endpoint string, code int, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures[endpoint] = append(s.failures[endpoint], failure{code: code, message: message})
+}
+
+// Requests returns the number of requests received by an endpoint, including failed ones
+func (s *Server) Requests(endpoint string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests[endpoint]
+}
+
+// Orders returns the orders created on the server, in creation order
+func (s *Server) Orders() []c2cx.Order {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	orders := make([]c2cx.Order, len(s.orders))
+	for i, o := range s.orders {
+		orders[i] = o.order
+	}
+	return orders
+}
+
+// SetOrderStatus changes the status of an order, e.g. to complete it. Completing an active order spends
+// its frozen balance, other statuses release it. It returns false if the order doesn't exist.
+func (s *Server) SetOrderStatus(orderID c2cx.OrderID, status c2cx.OrderStatus) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o := s.order(orderID)
+	if o == nil {
+		return false
+	}
+
+	s.setStatus(o, status)
+	return true
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, apiPath) {
+		http.NotFound(w, r)
+		return
+	}
+	endpoint := strings.TrimPrefix(r.URL.Path, apiPath)
+
+	var handler func(w http.ResponseWriter, r *http.Request)
+	method := http.MethodPost
+	switch endpoint {
+	case "getorderbook":
+		handler, method = s.getOrderbook, http.MethodGet
+	case "ticker":
+		handler, method = s.getTicker, http.MethodGet
+	case "getbalance":
+		handler = s.getBalance
+	case "createorder":
+		handler = s.createOrder
+	case "getorderinfo":
+		handler = s.getOrderInfo
+	case "cancelorder":
+		handler = s.cancelOrder
+	case "getorderbystatus":
+		handler = s.getOrderByStatus
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.Method != method {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, InvalidParameter)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests[endpoint]++
+
+	if f := s.failures[endpoint]; len(f) != 0 {
+		s.failures[endpoint] = f[1:]
+		if f[0].code >= 500 {
+			w.WriteHeader(f[0].code)
+			return
+		}
+		writeError(w, f[0].code, f[0].message)
+		return
+	}
+
+	if method == http.MethodPost && !s.verify(r) {
+		writeError(w, http.StatusBadRequest, InvalidSign)
+		return
+	}
+
+	handler(w, r)
+}
+
+// verify checks the apiKey and sign parameters of a POST request
+func (s *Server) verify(r *http.Request) bool {
+	if r.PostForm.Get("apiKey") != s.Key {
+		return false
+	}
+
+	var keys []string
+	for k := range r.PostForm {
+		if k != "apiKey" && k != "sign" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	params := []string{"apiKey=" + s.Key}
+	for _, k := range keys {
+		params = append(params, k+"="+r.PostForm.Get(k))
+	}
+	params = append(params, "secretKey="+s.Secret)
+
+	sum := md5.Sum([]byte(strings.Join(params, "&"))) // nolint: gas
+	return r.PostForm.Get("sign") == strings.ToUpper(fmt.Sprintf("%x", sum))
+}
+
+func (s *Server) getOrderbook(w http.ResponseWriter, r *http.Request) {
+	book, ok := s.books[c2cx.TradePair(r.Form.Get("symbol"))]
+	if !ok {
+		writeError(w, http.StatusBadRequest, InvalidSymbol)
+		return
+	}
+
+	writeResponse(w, map[string]interface{}{
+		"timestamp": unixString(s.Now()),
+		"bids":      levels(book.Bids),
+		"asks":      levels(book.Asks),
+	})
+}
+
+func (s *Server) getTicker(w http.ResponseWriter, r *http.Request) {
+	symbol := c2cx.TradePair(r.Form.Get("symbol"))
+	book, hasBook := s.books[symbol]
+	ticker, hasTicker := s.tickers[symbol]
+	if !hasBook && !hasTicker {
+		writeError(w, http.StatusBadRequest, InvalidSymbol)
+		return
+	}
+
+	if !hasTicker {
+		if len(book.Bids) != 0 {
+			ticker.Buy = &book.Bids[0].Price
+		}
+		if len(book.Asks) != 0 {
+			ticker.Sell = &book.Asks[0].Price
+		}
+	}
+
+	data := map[string]interface{}{
+		"timestamp": unixString(s.Now()),
+	}
+	for k, v := range map[string]*decimal.Decimal{
+		"high":   ticker.High,
+		"last":   ticker.Last,
+		"low":    ticker.Low,
+		"buy":    ticker.Buy,
+		"sell":   ticker.Sell,
+		"volume": ticker.Volume,
+	} {
+		if v != nil {
+			data[k] = number(*v)
+		}
+	}
+
+	writeResponse(w, data)
+}
+
+func (s *Server) getBalance(w http.ResponseWriter, r *http.Request) {
+	total := map[string]json.Number{}
+	frozen := map[string]json.Number{}
+	for currency, b := range s.balances {
+		total[currency] = number(b.total)
+		frozen[currency] = number(b.frozen)
+	}
+
+	writeResponse(w, map[string]interface{}{
+		"balance": total,
+		"frozen":  frozen,
+	})
+}
+
+func (s *Server) createOrder(w http.ResponseWriter, r *http.Request) {
+	symbol := c2cx.TradePair(r.Form.Get("symbol"))
+	if _, ok := s.books[symbol]; !ok {
+		writeError(w, http.StatusBadRequest, InvalidSymbol)
+		return
+	}
+
+	price, err := decimal.NewFromString(r.Form.Get("price"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, InvalidParameter)
+		return
+	}
+	quantity, err := decimal.NewFromString(r.Form.Get("quantity"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, InvalidParameter)
+		return
+	}
+
+	orderType := c2cx.OrderType(r.Form.Get("orderType"))
+	priceType := c2cx.PriceType(r.Form.Get("priceTypeId"))
+	if (orderType != c2cx.OrderTypeBuy && orderType != c2cx.OrderTypeSell) ||
+		(priceType != c2cx.PriceTypeLimit && priceType != c2cx.PriceTypeMarket) {
+		writeError(w, http.StatusBadRequest, InvalidParameter)
+		return
+	}
+
+	var cid *string
+	if _, ok := r.Form["cid"]; ok {
+		v := r.Form.Get("cid")
+		if s.cids[v] {
+			writeError(w, http.StatusBadRequest, DuplicateCID)
+			return
+		}
+		cid = &v
+	}
+
+	// buy orders spend the first currency of the pair, sell orders the second one
+	currencies := strings.SplitN(strings.ToLower(string(symbol)), "_", 2)
+	if len(currencies) != 2 {
+		writeError(w, http.StatusBadRequest, InvalidSymbol)
+		return
+	}
+	spent := currencies[1]
+	if orderType == c2cx.OrderTypeBuy {
+		spent = currencies[0]
+	}
+
+	// the cost is the amount of the spent currency
+	var cost, amount decimal.Decimal
+	switch {
+	case priceType == c2cx.PriceTypeLimit && orderType == c2cx.OrderTypeBuy:
+		cost, amount = price.Mul(quantity), quantity
+	case priceType == c2cx.PriceTypeMarket && orderType == c2cx.OrderTypeBuy:
+		// c2cx.Client.MarketBuy sends the amount to spend as the price
+		cost = quantity
+		if cost.Sign() == 0 {
+			cost = price
+		}
+		amount = cost
+		if s.MarketBuyMinimum.Sign() > 0 && cost.LessThan(s.MarketBuyMinimum) {
+			writeError(w, http.StatusBadRequest, "limit value: "+s.MarketBuyMinimum.String())
+			return
+		}
+		price = decimal.Zero
+	default:
+		cost, amount = quantity, quantity
+	}
+
+	if cost.Sign() <= 0 {
+		writeError(w, http.StatusBadRequest, InvalidParameter)
+		return
+	}
+
+	o := &order{
+		symbol: symbol,
+		order: c2cx.Order{
+			Amount:     amount,
+			CreateDate: s.Now(),
+			OrderID:    s.nextID,
+			Price:      price,
+			Status:     c2cx.StatusActive,
+			Type:       orderType,
+			CustomerID: cid,
+			Source:     "api",
+		},
+	}
+	s.nextID++
+	if cid != nil {
+		s.cids[*cid] = true
+	}
+
+	b := s.balance(spent)
+	enough := !b.total.Sub(b.frozen).LessThan(cost)
+	switch {
+	case priceType == c2cx.PriceTypeLimit && !enough:
+		// limit orders exceeding the available balance are pending
+		o.order.Status = c2cx.StatusPending
+	case priceType == c2cx.PriceTypeLimit:
+		o.frozen = cost
+		b.frozen = b.frozen.Add(cost)
+	case !enough:
+		// market orders exceeding the available balance are cancelled
+		o.order.Status = c2cx.StatusCancelled
+	default:
+		o.order.Status = c2cx.StatusCompleted
+		o.order.CompletedAmount = amount
+		o.order.CompleteDate = o.order.CreateDate
+		b.total = b.total.Sub(cost)
+	}
+
+	s.orders = append(s.orders, o)
+
+	writeResponse(w, map[string]interface{}{
+		"orderId": o.order.OrderID,
+	})
+}
+
+func (s *Server) getOrderInfo(w http.ResponseWriter, r *http.Request) {
+	symbol := c2cx.TradePair(r.Form.Get("symbol"))
+	orderID, err := strconv.Atoi(r.Form.Get("orderId"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, InvalidParameter)
+		return
+	}
+
+	// the success message of this endpoint is misspelled
+	if orderID == -1 {
+		var orders []c2cx.Order
+		for _, o := range s.orders {
+			if o.symbol == symbol {
+				orders = append(orders, o.order)
+			}
+		}
+
+		if len(orders) == 0 {
+			writeError(w, http.StatusBadRequest, NoOrders)
+			return
+		}
+		writeMessage(w, "succcess", orders)
+		return
+	}
+
+	o := s.order(c2cx.OrderID(orderID))
+	if o == nil || o.symbol != symbol {
+		writeError(w, http.StatusBadRequest, OrderNotFound)
+		return
+	}
+	writeMessage(w, "succcess", o.order)
+}
+
+func (s *Server) cancelOrder(w http.ResponseWriter, r *http.Request) {
+	orderID, err := strconv.Atoi(r.Form.Get("orderId"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, InvalidParameter)
+		return
+	}
+
+	o := s.order(c2cx.OrderID(orderID))
+	if o == nil {
+		writeError(w, http.StatusBadRequest, OrderNotFound)
+		return
+	}
+
+	switch o.order.Status {
+	case c2cx.StatusCompleted, c2cx.StatusCancelled, c2cx.StatusErrored, c2cx.StatusExpired:
+		writeError(w, http.StatusBadRequest, OrderNotFound)
+		return
+	}
+
+	s.setStatus(o, c2cx.StatusCancelled)
+	writeResponse(w, nil)
+}
+
+func (s *Server) getOrderByStatus(w http.ResponseWriter, r *http.Request) {
+	symbol := c2cx.TradePair(r.Form.Get("symbol"))
+	status, ok := parseStatus(r.Form.Get("status"))
+	if !ok {
+		writeError(w, http.StatusBadRequest, InvalidParameter)
+		return
+	}
+
+	var err error
+	page, pageSize := 1, 100
+	if v := r.Form.Get("pageindex"); v != "" {
+		if page, err = strconv.Atoi(v); err != nil || page < 0 {
+			writeError(w, http.StatusBadRequest, InvalidParameter)
+			return
+		}
+	}
+	if v := r.Form.Get("pagesize"); v != "" {
+		if pageSize, err = strconv.Atoi(v); err != nil || pageSize < 1 {
+			writeError(w, http.StatusBadRequest, InvalidParameter)
+			return
+		}
+	}
+
+	// pageindex 0 and 1 are the same page
+	if page == 0 {
+		page = 1
+	}
+
+	var orders []c2cx.Order
+	for _, o := range s.orders {
+		if o.symbol == symbol && (status == c2cx.StatusAll || o.order.Status == status) {
+			orders = append(orders, o.order)
+		}
+	}
+
+	rows := []c2cx.Order{}
+	if start := (page - 1) * pageSize; start < len(orders) {
+		end := start + pageSize
+		if end > len(orders) {
+			end = len(orders)
+		}
+		rows = orders[start:end]
+	}
+
+	// pageindex and pagesize are null like in the real API, see api_notes.go in package c2cx
+	writeResponse(w, map[string]interface{}{
+		"rows":        rows,
+		"pageindex":   nil,
+		"pagesize":    nil,
+		"recordcount": len(orders),
+		"pagecount":   (len(orders) + pageSize - 1) / pageSize,
+	})
+}
+
+// parseStatus parses an order status, either its number or its name since c2cx.Client sends the name
+func parseStatus(s string) (c2cx.OrderStatus, bool) {
+	if n, err := strconv.Atoi(s); err == nil {
+		return c2cx.OrderStatus(n), true
+	}
+
+	for status := c2cx.StatusAll; status <= c2cx.StatusCancelling; status++ {
+		if status.String() == s {
+			return status, true
+		}
+	}
+	return 0, false
+}
+
+// balance returns the balance of a currency, creating it if needed
+func (s *Server) balance(currency string) *balance {
+	currency = strings.ToLower(currency)
+	b, ok := s.balances[currency]
+	if !ok {
+		b = &balance{}
+		s.balances[currency] = b
+	}
+	return b
+}
+
+// order returns an order, nil if it doesn't exist
+func (s *Server) order(orderID c2cx.OrderID) *order {
+	for _, o := range s.orders {
+		if o.order.OrderID == orderID {
+			return o
+		}
+	}
+	return nil
+}
+
+// setStatus changes the status of an order, spending or releasing its frozen balance when it stops being active
+func (s *Server) setStatus(o *order, status c2cx.OrderStatus) {
+	o.order.Status = status
+	if o.frozen.Sign() == 0 || status == c2cx.StatusActive || status == c2cx.StatusPartial {
+		return
+	}
+
+	currencies := strings.SplitN(strings.ToLower(string(o.symbol)), "_", 2)
+	spent := currencies[1]
+	if o.order.Type == c2cx.OrderTypeBuy {
+		spent = currencies[0]
+	}
+
+	b := s.balance(spent)
+	b.frozen = b.frozen.Sub(o.frozen)
+	if status == c2cx.StatusCompleted {
+		b.total = b.total.Sub(o.frozen)
+		o.order.CompletedAmount = o.order.Amount
+		o.order.CompleteDate = s.Now()
+	}
+	o.frozen = decimal.Zero
+}
+
+// levels converts orders to the [price, volume] pairs of an orderbook response
+func levels(orders exchange.MarketOrders) [][2]json.Number {
+	l := make([][2]json.Number, len(orders))
+	for i, o := range orders {
+		l[i] = [2]json.Number{number(o.Price), number(o.Volume)}
+	}
+	return l
+}
+
+// number returns a decimal as a JSON number, as sent by the API
+func number(d decimal.Decimal) json.Number {
+	return json.Number(d.String())
+}
+
+// unixString returns a timestamp in unix seconds as a string, as sent by the orderbook and ticker endpoints
+func unixString(t time.Time) string {
+	return strconv.FormatInt(t.Unix(), 10)
+}
+
+func writeResponse(w http.ResponseWriter, data interface{}) {
+	writeMessage(w, "success", data)
+}
+
+func writeMessage(w http.ResponseWriter, message string, data interface{}) {
+	write(w, http.StatusOK, message, data)
+}
+
+// writeError writes an error response. Like the real API, the HTTP status is 200 and the code is in the JSON.
+func writeError(w http.ResponseWriter, code int, message string) {
+	write(w, code, message, nil)
+}
+
+func write(w http.ResponseWriter, code int, message string, data interface{}) {
+	if data == nil {
+		data = struct{}{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{ // nolint: errcheck
+		"code":    code,
+		"message": message,
+		"data":    data,
+	})
+}
@@ -0,0 +1,253 @@
+package c2cxtest
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/exchange-api/exchange"
+	"github.com/skycoin/exchange-api/exchange/c2cx"
+)
+
+var now = time.Date(2018, 3, 20, 10, 0, 0, 0, time.UTC)
+
+func d(s string) decimal.Decimal {
+	v, err := decimal.NewFromString(s)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func newServer() *Server {
+	s := NewServer("key", "secret")
+	s.Now = func() time.Time { return now }
+	s.SetOrderbook(c2cx.BtcSky,
+		exchange.MarketOrders{{Price: d("0.001"), Volume: d("10")}},
+		exchange.MarketOrders{{Price: d("0.002"), Volume: d("5")}})
+	s.SetBalance("btc", d("1"))
+	s.SetBalance("sky", d("100"))
+	return s
+}
+
+func TestMarketData(t *testing.T) {
+	s := newServer()
+	defer s.Close()
+	c := s.Client()
+
+	book, err := c.GetOrderbook(c2cx.BtcSky)
+	require.NoError(t, err)
+	require.True(t, now.Equal(book.Timestamp))
+	require.Len(t, book.Bids, 1)
+	require.True(t, book.Bids[0].Price.Equal(d("0.001")))
+	require.True(t, book.Asks[0].Volume.Equal(d("5")))
+
+	ticker, err := c.GetTicker(c2cx.BtcSky)
+	require.NoError(t, err)
+	require.True(t, ticker.Buy.Equal(d("0.001")))
+	require.True(t, ticker.Sell.Equal(d("0.002")))
+	require.Nil(t, ticker.Last)
+
+	last := d("0.0015")
+	s.SetTicker(c2cx.BtcSky, c2cx.TickerData{Last: &last})
+	ticker, err = c.GetTicker(c2cx.BtcSky)
+	require.NoError(t, err)
+	require.True(t, ticker.Last.Equal(last))
+	require.Nil(t, ticker.Buy)
+
+	_, err = c.GetOrderbook(c2cx.BtcEth)
+	require.Equal(t, c2cx.NewAPIError("getorderbook", http.StatusBadRequest, InvalidSymbol), err)
+}
+
+func TestSignature(t *testing.T) {
+	s := newServer()
+	defer s.Close()
+
+	c := s.Client()
+	c.Secret = "wrong"
+	_, err := c.GetBalanceSummary()
+	require.Equal(t, c2cx.NewAPIError("getbalance", http.StatusBadRequest, InvalidSign), err)
+
+	// values are signed unescaped
+	c.Secret = s.Secret
+	_, err = c.LimitBuy(c2cx.BtcSky, d("0.001"), d("1"), &[]string{"a:b c"}[0])
+	require.NoError(t, err)
+}
+
+func TestOrders(t *testing.T) {
+	s := newServer()
+	defer s.Close()
+	c := s.Client()
+
+	// an active limit order freezes its cost
+	buyID, err := c.LimitBuy(c2cx.BtcSky, d("0.001"), d("100"), nil)
+	require.NoError(t, err)
+
+	summary, err := c.GetBalanceSummary()
+	require.NoError(t, err)
+	require.True(t, summary.Balance.Btc.Equal(d("1")))
+	require.True(t, summary.Frozen.Btc.Equal(d("0.1")))
+	require.True(t, summary.Spendable().Btc.Equal(d("0.9")))
+
+	// limit orders exceeding the available balance are pending
+	pendingID, err := c.LimitSell(c2cx.BtcSky, d("0.002"), d("1000"), nil)
+	require.NoError(t, err)
+
+	// market orders exceeding the available balance are cancelled
+	cancelledID, err := c.MarketBuy(c2cx.BtcSky, d("5"), nil)
+	require.NoError(t, err)
+
+	// other market orders complete
+	soldID, err := c.MarketSell(c2cx.BtcSky, d("10"), nil)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		orderID c2cx.OrderID
+		status  c2cx.OrderStatus
+	}{
+		{buyID, c2cx.StatusActive},
+		{pendingID, c2cx.StatusPending},
+		{cancelledID, c2cx.StatusCancelled},
+		{soldID, c2cx.StatusCompleted},
+	} {
+		o, err := c.GetOrderInfo(c2cx.BtcSky, tc.orderID)
+		require.NoError(t, err)
+		require.Equal(t, tc.status, o.Status, "order %d", tc.orderID)
+		require.True(t, now.Equal(o.CreateDate))
+	}
+
+	summary, err = c.GetBalanceSummary()
+	require.NoError(t, err)
+	require.True(t, summary.Balance.Sky.Equal(d("90")))
+
+	orders, err := c.GetOrderInfoAll(c2cx.BtcSky)
+	require.NoError(t, err)
+	require.Len(t, orders, 4)
+
+	_, err = c.GetOrderInfoAll(c2cx.BtcEth)
+	require.Equal(t, c2cx.NewAPIError("getorderinfo", http.StatusBadRequest, NoOrders), err)
+
+	// cancelling releases the frozen balance
+	require.NoError(t, c.CancelOrder(buyID))
+	summary, err = c.GetBalanceSummary()
+	require.NoError(t, err)
+	require.True(t, summary.Frozen.Btc.Equal(decimal.Zero))
+
+	require.Equal(t, c2cx.NewAPIError("cancelorder", http.StatusBadRequest, OrderNotFound), c.CancelOrder(buyID))
+	require.Equal(t, c2cx.NewAPIError("cancelorder", http.StatusBadRequest, OrderNotFound), c.CancelOrder(1000))
+}
+
+func TestSetOrderStatus(t *testing.T) {
+	s := newServer()
+	defer s.Close()
+	c := s.Client()
+
+	orderID, err := c.LimitSell(c2cx.BtcSky, d("0.002"), d("40"), nil)
+	require.NoError(t, err)
+
+	require.True(t, s.SetOrderStatus(orderID, c2cx.StatusCompleted))
+	require.False(t, s.SetOrderStatus(1000, c2cx.StatusCompleted))
+
+	summary, err := c.GetBalanceSummary()
+	require.NoError(t, err)
+	require.True(t, summary.Balance.Sky.Equal(d("60")))
+	require.True(t, summary.Frozen.Sky.Equal(decimal.Zero))
+
+	orders := s.Orders()
+	require.Len(t, orders, 1)
+	require.Equal(t, c2cx.StatusCompleted, orders[0].Status)
+	require.True(t, orders[0].CompletedAmount.Equal(d("40")))
+}
+
+func TestCreateOrderErrors(t *testing.T) {
+	s := newServer()
+	defer s.Close()
+	s.MarketBuyMinimum = d("0.0001")
+	c := s.Client()
+
+	_, err := c.MarketBuy(c2cx.BtcSky, d("0.00001"), nil)
+	require.Equal(t, c2cx.NewAPIError("createorder", http.StatusBadRequest, "limit value: 0.0001"), err)
+
+	// a cid cannot be reused
+	cid := "order-1"
+	_, err = c.LimitBuy(c2cx.BtcSky, d("0.001"), d("1"), &cid)
+	require.NoError(t, err)
+	_, err = c.LimitBuy(c2cx.BtcSky, d("0.001"), d("1"), &cid)
+	require.Equal(t, c2cx.NewAPIError("createorder", http.StatusBadRequest, DuplicateCID), err)
+
+	_, err = c.LimitBuy(c2cx.BtcEth, d("0.001"), d("1"), nil)
+	require.Equal(t, c2cx.NewAPIError("createorder", http.StatusBadRequest, InvalidSymbol), err)
+}
+
+func TestGetOrderByStatus(t *testing.T) {
+	s := newServer()
+	defer s.Close()
+	s.SetBalance("btc", d("1000"))
+	c := s.Client()
+
+	for i := 0; i < 150; i++ {
+		_, err := c.LimitBuy(c2cx.BtcSky, d("0.001"), d("1"), nil)
+		require.NoError(t, err)
+	}
+	require.True(t, s.SetOrderStatus(3, c2cx.StatusCompleted))
+
+	// the page index is null in the response, so the client returns the requested page
+	orders, page, pages, err := c.GetOrderByStatusPaged(c2cx.BtcSky, c2cx.StatusAll, 2)
+	require.NoError(t, err)
+	require.Len(t, orders, 50)
+	require.Equal(t, 2, page)
+	require.Equal(t, 2, pages)
+	require.Equal(t, c2cx.OrderID(101), orders[0].OrderID)
+
+	// pageindex 0 and 1 are the same page
+	orders0, page, _, err := c.GetOrderByStatusPaged(c2cx.BtcSky, c2cx.StatusAll, 0)
+	require.NoError(t, err)
+	orders1, _, _, err := c.GetOrderByStatusPaged(c2cx.BtcSky, c2cx.StatusAll, 1)
+	require.NoError(t, err)
+	require.Equal(t, 1, page)
+	require.Equal(t, orders1, orders0)
+
+	orders, err = c.GetOrderByStatus(c2cx.BtcSky, c2cx.StatusAll)
+	require.NoError(t, err)
+	require.Len(t, orders, 150)
+
+	orders, err = c.GetOrderByStatus(c2cx.BtcSky, c2cx.StatusActive)
+	require.NoError(t, err)
+	require.Len(t, orders, 149)
+
+	orders, err = c.GetOrderByStatus(c2cx.BtcSky, c2cx.StatusCompleted)
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	require.Equal(t, c2cx.OrderID(3), orders[0].OrderID)
+}
+
+func TestFail(t *testing.T) {
+	s := newServer()
+	defer s.Close()
+	c := s.Client()
+
+	// retried by the client
+	s.Fail("ticker", http.StatusBadRequest, TooManyRequests)
+	s.Fail("ticker", http.StatusServiceUnavailable, "")
+	_, err := c.GetTicker(c2cx.BtcSky)
+	require.NoError(t, err)
+	require.Equal(t, 3, s.Requests("ticker"))
+
+	s.Fail("getbalance", http.StatusBadRequest, "maintenance")
+	_, err = c.GetBalanceSummary()
+	require.Equal(t, c2cx.NewAPIError("getbalance", http.StatusBadRequest, "maintenance"), err)
+	require.Equal(t, 1, s.Requests("getbalance"))
+
+	// the order was not placed, so the client retries with its cid
+	cid := "order-1"
+	s.Fail("createorder", http.StatusBadGateway, "")
+	orderID, err := c.LimitBuy(c2cx.BtcSky, d("0.001"), d("1"), &cid)
+	require.NoError(t, err)
+	require.Equal(t, 2, s.Requests("createorder"))
+	require.Equal(t, 1, s.Requests("getorderbystatus"))
+	require.Len(t, s.Orders(), 1)
+	require.Equal(t, s.Orders()[0].OrderID, orderID)
+}
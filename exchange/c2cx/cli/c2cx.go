@@ -369,6 +369,43 @@ the amount is the amount of SKY you want to sell for BTC.
 				handleResult(map[string]c2cx.OrderID{"orderID": orderID}, err)
 			},
 		},
+		"getMarketImpact": {
+			Use:   "get_market_impact",
+			Short: "Prints the cost and slippage of buying and selling the given sizes against the orderbook",
+			Long: `
+Prints the VWAP, worst price and slippage in basis points versus the mid price and the best quote
+of buying and selling each of the given sizes against the current orderbook.
+	Params:
+		trade_pair - market trade pair
+		size[] - list of sizes, in the trade pair's second coin`,
+			Example: "c2cx get_market_impact <trade_pair> <size> <size> <size>",
+			Args:    cobra.MinimumNArgs(2),
+			Run: func(cmd *cobra.Command, args []string) {
+				var sizes []decimal.Decimal
+				for _, arg := range args[1:] {
+					size, err := decimal.NewFromString(arg)
+					if err != nil {
+						printErrorWithExit(err)
+					}
+					sizes = append(sizes, size)
+				}
+				pair, err := c2cx.DecodePair(c2cx.TradePair(args[0]))
+				if err != nil {
+					printErrorWithExit(err)
+				}
+				orderbook, err := c2cx.NewAdapter(client).GetOrderbook(pair)
+				if err != nil {
+					printErrorWithExit(err)
+				}
+				curve, err := orderbook.ImpactCurve(sizes)
+				if err != nil {
+					printErrorWithExit(err)
+				}
+				if err := curve.WriteTable(os.Stdout); err != nil {
+					printErrorWithExit(err)
+				}
+			},
+		},
 		"getTicker": {
 			Use:   "get_ticker",
 			Short: "The public ticker API returns key pricing data for a give currency pair",
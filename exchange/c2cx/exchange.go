@@ -0,0 +1,269 @@
+package c2cx
+
+import (
+	"github.com/shopspring/decimal"
+
+	"github.com/skycoin/exchange-api/exchange"
+)
+
+// Adapter implements exchange.Exchange on top of a Client.
+// Pairs are encoded with Codec as C2CX trade pairs, e.g. "BTC_SKY".
+type Adapter struct {
+	Client *Client
+}
+
+// NewAdapter creates an Adapter for the given Client
+func NewAdapter(c *Client) *Adapter {
+	return &Adapter{
+		Client: c,
+	}
+}
+
+// Name returns the exchange's name
+func (a *Adapter) Name() string {
+	return "c2cx"
+}
+
+// Codec returns Codec
+func (a *Adapter) Codec() exchange.PairCodec {
+	return Codec
+}
+
+// GetOrderbook returns the current orderbook of a trade pair
+func (a *Adapter) GetOrderbook(pair exchange.Pair) (*exchange.MarketRecord, error) {
+	symbol := EncodePair(pair)
+	orderbook, err := a.Client.GetOrderbook(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	return &exchange.MarketRecord{
+		Timestamp: orderbook.Timestamp,
+		Symbol:    string(symbol),
+		Bids:      orderbook.Bids,
+		Asks:      orderbook.Asks,
+	}, nil
+}
+
+// GetTicker returns pricing data of a trade pair
+func (a *Adapter) GetTicker(pair exchange.Pair) (*exchange.Ticker, error) {
+	symbol := EncodePair(pair)
+	ticker, err := a.Client.GetTicker(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	return &exchange.Ticker{
+		Symbol:    string(symbol),
+		Timestamp: ticker.Timestamp,
+		Last:      orZero(ticker.Last),
+		High:      orZero(ticker.High),
+		Low:       orZero(ticker.Low),
+		Bid:       orZero(ticker.Buy),
+		Ask:       orZero(ticker.Sell),
+		Volume:    orZero(ticker.Volume),
+	}, nil
+}
+
+// GetBalances returns the account's balances of all currencies
+func (a *Adapter) GetBalances() (exchange.Balances, error) {
+	summary, err := a.Client.GetBalanceSummary()
+	if err != nil {
+		return nil, err
+	}
+
+	return convertBalances(*summary), nil
+}
+
+// LimitBuy places a limit buy order
+func (a *Adapter) LimitBuy(pair exchange.Pair, price, volume decimal.Decimal) (exchange.OrderID, error) {
+	orderID, err := a.Client.LimitBuy(EncodePair(pair), price, volume, nil)
+	return exchange.OrderID(orderID), err
+}
+
+// LimitSell places a limit sell order
+func (a *Adapter) LimitSell(pair exchange.Pair, price, volume decimal.Decimal) (exchange.OrderID, error) {
+	orderID, err := a.Client.LimitSell(EncodePair(pair), price, volume, nil)
+	return exchange.OrderID(orderID), err
+}
+
+// MarketBuy places a market buy order, amount is the quantity of the quote currency to spend
+func (a *Adapter) MarketBuy(pair exchange.Pair, amount decimal.Decimal) (exchange.OrderID, error) {
+	orderID, err := a.Client.MarketBuy(EncodePair(pair), amount, nil)
+	return exchange.OrderID(orderID), err
+}
+
+// MarketSell places a market sell order, volume is the quantity of the base currency to sell
+func (a *Adapter) MarketSell(pair exchange.Pair, volume decimal.Decimal) (exchange.OrderID, error) {
+	orderID, err := a.Client.MarketSell(EncodePair(pair), volume, nil)
+	return exchange.OrderID(orderID), err
+}
+
+// CancelOrder cancels an order
+func (a *Adapter) CancelOrder(pair exchange.Pair, orderID exchange.OrderID) error {
+	return a.Client.CancelOrder(OrderID(orderID))
+}
+
+// GetOpenOrders returns all active and partially completed orders of a trade pair
+func (a *Adapter) GetOpenOrders(pair exchange.Pair) ([]exchange.Order, error) {
+	symbol := EncodePair(pair)
+
+	var result []exchange.Order
+	seen := make(map[OrderID]struct{})
+
+	// GetOrderByStatus may return orders with a different status than requested, filter them here
+	for _, status := range []OrderStatus{StatusActive, StatusPartial} {
+		orders, err := a.Client.GetOrderByStatus(symbol, status)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, o := range orders {
+			if _, ok := seen[o.OrderID]; ok {
+				continue
+			}
+			seen[o.OrderID] = struct{}{}
+
+			order := convertOrder(string(symbol), o)
+			if order.Status.Open() {
+				result = append(result, order)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// GetOrderStatus returns the latest state of an order
+func (a *Adapter) GetOrderStatus(pair exchange.Pair, orderID exchange.OrderID) (*exchange.Order, error) {
+	symbol := EncodePair(pair)
+	o, err := a.Client.GetOrderInfo(symbol, OrderID(orderID))
+	if err != nil {
+		return nil, err
+	}
+
+	order := convertOrder(string(symbol), *o)
+	return &order, nil
+}
+
+// Codec converts exchange.Pairs to and from trade pairs, which list the quote currency first,
+// e.g. "BTC_SKY" is SKY priced in BTC
+var Codec exchange.PairCodec = exchange.SeparatorCodec{Separator: "_", QuoteFirst: true}
+
+// EncodePair returns the trade pair of an exchange.Pair
+func EncodePair(p exchange.Pair) TradePair {
+	return TradePair(Codec.EncodePair(p))
+}
+
+// DecodePair returns the exchange.Pair of a trade pair
+func DecodePair(tp TradePair) (exchange.Pair, error) {
+	return Codec.DecodePair(string(tp))
+}
+
+// Rules returns the trading rules of the trade pairs in TradePairRulesTable, for use with an exchange.RulesRegistry
+func Rules() map[exchange.Pair]exchange.Rules {
+	result := make(map[exchange.Pair]exchange.Rules, len(TradePairRulesTable))
+	for tp, r := range TradePairRulesTable {
+		pair, err := DecodePair(tp)
+		if err != nil {
+			continue
+		}
+		result[pair] = exchange.Rules{
+			PricePrecision:  exchange.Precision(int32(r.PricePrecision)),
+			VolumePrecision: exchange.Precision(int32(r.VolumePrecision)),
+			MinVolume:       r.VolumeMinimum,
+		}
+	}
+	return result
+}
+
+func convertStatus(s OrderStatus) exchange.OrderStatus {
+	switch s {
+	case StatusPending, StatusSuspended, StatusTriggerPending, StatusStopLossPending:
+		return exchange.StatusPending
+	case StatusActive:
+		return exchange.StatusOpened
+	case StatusPartial:
+		return exchange.StatusPartial
+	case StatusCompleted:
+		return exchange.StatusCompleted
+	case StatusCancelled, StatusCancelling, StatusExpired:
+		return exchange.StatusCancelled
+	case StatusErrored:
+		return exchange.StatusError
+	default:
+		return exchange.StatusUnknown
+	}
+}
+
+func convertOrder(symbol string, o Order) exchange.Order {
+	action := exchange.ActionBuy
+	if o.Type == OrderTypeSell {
+		action = exchange.ActionSell
+	}
+
+	return exchange.Order{
+		OrderID:   exchange.OrderID(o.OrderID),
+		Symbol:    symbol,
+		Action:    action,
+		Status:    convertStatus(o.Status),
+		Price:     o.Price,
+		Volume:    o.Amount,
+		Filled:    o.CompletedAmount,
+		AvgPrice:  o.AvgPrice,
+		Fee:       o.Fee,
+		Created:   o.CreateDate,
+		Completed: o.CompleteDate,
+	}
+}
+
+func convertBalances(summary BalanceSummary) exchange.Balances {
+	total := summary.Balance.currencies()
+	frozen := summary.Frozen.currencies()
+
+	result := make(exchange.Balances, len(total))
+	for currency, amount := range total {
+		result[currency] = exchange.Balance{
+			Currency:  currency,
+			Total:     amount,
+			Available: amount.Sub(frozen[currency]),
+			Frozen:    frozen[currency],
+		}
+	}
+
+	return result
+}
+
+// currencies maps upper case currency symbols to their balance, omitting the Total field
+func (b Balances) currencies() map[string]decimal.Decimal {
+	return map[string]decimal.Decimal{
+		"BTC":   b.Btc,
+		"ETC":   b.Etc,
+		"ETH":   b.Eth,
+		"CNY":   b.Cny,
+		"SKY":   b.Sky,
+		"LTC":   b.Ltc,
+		"BCC":   b.Bcc,
+		"SHL":   b.Shl,
+		"BCH":   b.Bch,
+		"ZEC":   b.Zec,
+		"DRG":   b.Drg,
+		"USDT":  b.Usdt,
+		"BTG":   b.Btg,
+		"FCABS": b.Fcabs,
+		"CABS":  b.Cabs,
+		"DASH":  b.Dash,
+		"ZRX":   b.Zrx,
+		"FUN":   b.Fun,
+		"TNB":   b.Tnb,
+		"ETP":   b.Etp,
+		"UCASH": b.Ucash,
+	}
+}
+
+func orZero(d *decimal.Decimal) decimal.Decimal {
+	if d == nil {
+		return decimal.Zero
+	}
+	return *d
+}
@@ -0,0 +1,112 @@
+package c2cx
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/exchange-api/exchange"
+)
+
+func TestAdapterImplementsExchange(t *testing.T) {
+	require.Implements(t, (*exchange.Exchange)(nil), NewAdapter(NewAPIClient("", "")))
+}
+
+func TestConvertStatus(t *testing.T) {
+	tt := []struct {
+		status   OrderStatus
+		expected exchange.OrderStatus
+	}{
+		{StatusPending, exchange.StatusPending},
+		{StatusSuspended, exchange.StatusPending},
+		{StatusActive, exchange.StatusOpened},
+		{StatusPartial, exchange.StatusPartial},
+		{StatusCompleted, exchange.StatusCompleted},
+		{StatusCancelled, exchange.StatusCancelled},
+		{StatusExpired, exchange.StatusCancelled},
+		{StatusErrored, exchange.StatusError},
+		{OrderStatus(100), exchange.StatusUnknown},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.status.String(), func(t *testing.T) {
+			require.Equal(t, tc.expected, convertStatus(tc.status))
+		})
+	}
+}
+
+func TestConvertOrder(t *testing.T) {
+	o := Order{
+		Amount:          decimal.New(2, 0),
+		AvgPrice:        decimal.New(102, -5),
+		CompletedAmount: decimal.New(1, 0),
+		Fee:             decimal.New(1, -3),
+		CreateDate:      fromUnixMilli(1520934562420),
+		OrderID:         3266582,
+		Price:           decimal.New(102, -5),
+		Status:          StatusPartial,
+		Type:            OrderTypeSell,
+	}
+
+	order := convertOrder(string(BtcSky), o)
+	require.Equal(t, exchange.Order{
+		OrderID:  3266582,
+		Symbol:   string(BtcSky),
+		Action:   exchange.ActionSell,
+		Status:   exchange.StatusPartial,
+		Price:    o.Price,
+		Volume:   o.Amount,
+		Filled:   o.CompletedAmount,
+		AvgPrice: o.AvgPrice,
+		Fee:      o.Fee,
+		Created:  o.CreateDate,
+	}, order)
+}
+
+func TestConvertBalances(t *testing.T) {
+	summary := BalanceSummary{
+		Balance: Balances{
+			Btc: decimal.New(3, 0),
+			Sky: decimal.New(10, 0),
+		},
+		Frozen: Balances{
+			Btc: decimal.New(1, 0),
+		},
+	}
+
+	balances := convertBalances(summary)
+	require.Equal(t, "BTC", balances["BTC"].Currency)
+	require.True(t, balances["BTC"].Total.Equal(decimal.New(3, 0)))
+	require.True(t, balances["BTC"].Available.Equal(decimal.New(2, 0)))
+	require.True(t, balances["BTC"].Frozen.Equal(decimal.New(1, 0)))
+	require.True(t, balances["SKY"].Available.Equal(decimal.New(10, 0)))
+	require.True(t, balances["ETH"].Total.Equal(decimal.Zero))
+}
+
+func TestPairCodec(t *testing.T) {
+	skyBtc := exchange.NewPair(exchange.SKY, exchange.BTC)
+	require.Equal(t, BtcSky, EncodePair(skyBtc))
+
+	pair, err := DecodePair(BtcSky)
+	require.NoError(t, err)
+	require.Equal(t, skyBtc, pair)
+
+	pair, err = DecodePair("usdt_btc")
+	require.NoError(t, err)
+	require.Equal(t, exchange.NewPair(exchange.BTC, exchange.USDT), pair)
+
+	for _, s := range []TradePair{"", "BTCSKY", "BTC_", "SKY/BTC", "BTC_SKY_ETH"} {
+		_, err = DecodePair(s)
+		require.Equal(t, exchange.ErrInvalidSymbol, err, s)
+	}
+}
+
+func TestRules(t *testing.T) {
+	rules := Rules()
+	require.Equal(t, exchange.Rules{
+		PricePrecision:  exchange.Precision(5),
+		VolumePrecision: exchange.Precision(2),
+		MinVolume:       decimal.New(1, 0),
+	}, rules[exchange.NewPair(exchange.SKY, exchange.BTC)])
+}
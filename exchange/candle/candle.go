@@ -0,0 +1,207 @@
+// Package candle aggregates trades and ticker samples into OHLCV candles
+package candle
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/skycoin/exchange-api/exchange"
+	"github.com/skycoin/exchange-api/exchange/internal/dedupe"
+)
+
+// ErrInvalidInterval is returned when creating a Builder with a non-positive interval
+var ErrInvalidInterval = errors.New("candle interval must be positive")
+
+// Candle is the open, high, low and close price and the traded volume of an interval
+type Candle struct {
+	Start  time.Time       `json:"start"`
+	Open   decimal.Decimal `json:"open"`
+	High   decimal.Decimal `json:"high"`
+	Low    decimal.Decimal `json:"low"`
+	Close  decimal.Decimal `json:"close"`
+	Volume decimal.Decimal `json:"volume"`
+	// QuoteVolume is the traded volume in the quote currency, zero for candles built from ticker samples
+	QuoteVolume decimal.Decimal `json:"quote_volume"`
+	// Trades is the number of trades or ticker samples, zero for a gap filled by FillGaps
+	Trades int `json:"trades"`
+}
+
+// Builder aggregates a stream of trades, or of ticker samples, into candles of a fixed interval.
+// Candles start at multiples of the interval since the unix epoch.
+//
+// Trade history is usually fetched as overlapping windows, e.g. the last 24 hours every minute. AddTrades only
+// counts the trades that are newer than the ones already added, so a window can be added in full every time.
+// Trades sharing the latest timestamp are told apart by their price, volume and side.
+type Builder struct {
+	Interval time.Duration
+
+	candles map[int64]*Candle
+	// last is the timestamp of the latest trade or sample
+	last time.Time
+	// trades dedupes the trades of overlapping windows
+	trades dedupe.Window
+	// lastVolume is the 24h volume of the latest ticker sample
+	lastVolume decimal.Decimal
+}
+
+// NewBuilder creates a Builder of candles of the given interval
+func NewBuilder(interval time.Duration) (*Builder, error) {
+	if interval <= 0 {
+		return nil, ErrInvalidInterval
+	}
+
+	return &Builder{
+		Interval: interval,
+		candles:  make(map[int64]*Candle),
+	}, nil
+}
+
+// start returns the start of the interval containing t, in unix nanoseconds
+func (b *Builder) start(t time.Time) int64 {
+	ns := t.UnixNano()
+	offset := ns % int64(b.Interval)
+	if offset < 0 {
+		offset += int64(b.Interval)
+	}
+	return ns - offset
+}
+
+// AddTrades adds trades, in any order, and returns how many of them were new
+func (b *Builder) AddTrades(trades ...exchange.Trade) int {
+	sorted := make([]exchange.Trade, len(trades))
+	copy(sorted, trades)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+
+	items := make([]dedupe.Item, len(sorted))
+	for i, t := range sorted {
+		items[i] = dedupe.Item{Time: t.Timestamp, Key: tradeKey(t)}
+	}
+
+	fresh, next := b.trades.Next(items)
+	b.trades = next
+	if last := next.Last(); last.After(b.last) {
+		b.last = last
+	}
+
+	for _, i := range fresh {
+		t := sorted[i]
+		b.add(t.Timestamp, t.Price, t.Volume, t.Price.Mul(t.Volume))
+	}
+
+	return len(fresh)
+}
+
+// tradeKey identifies a trade within a timestamp. Decimals can't be compared with ==.
+func tradeKey(t exchange.Trade) string {
+	return fmt.Sprintf("%s %s %s", t.Action, t.Price, t.Volume)
+}
+
+// AddTicker adds a ticker sample, e.g. from c2cx which has no public trade history, and returns false if it isn't newer
+// than the previous sample. The candle prices are the sampled last prices. Volume is estimated from the increases of the
+// 24h volume between consecutive samples, it is a lower bound as trades leaving the 24h window hide newer ones.
+func (b *Builder) AddTicker(t exchange.Ticker) bool {
+	if !t.Timestamp.After(b.last) {
+		return false
+	}
+
+	volume := decimal.Zero
+	if !b.last.IsZero() && t.Volume.GreaterThan(b.lastVolume) {
+		volume = t.Volume.Sub(b.lastVolume)
+	}
+
+	b.last = t.Timestamp
+	b.lastVolume = t.Volume
+	b.add(t.Timestamp, t.Last, volume, decimal.Zero)
+	return true
+}
+
+func (b *Builder) add(ts time.Time, price, volume, quoteVolume decimal.Decimal) {
+	start := b.start(ts)
+	c, ok := b.candles[start]
+	if !ok {
+		b.candles[start] = &Candle{
+			Start:       time.Unix(0, start).UTC(),
+			Open:        price,
+			High:        price,
+			Low:         price,
+			Close:       price,
+			Volume:      volume,
+			QuoteVolume: quoteVolume,
+			Trades:      1,
+		}
+		return
+	}
+
+	if price.GreaterThan(c.High) {
+		c.High = price
+	}
+	if price.LessThan(c.Low) {
+		c.Low = price
+	}
+	c.Close = price
+	c.Volume = c.Volume.Add(volume)
+	c.QuoteVolume = c.QuoteVolume.Add(quoteVolume)
+	c.Trades++
+}
+
+// Candles returns the candles of the intervals with trades or samples, oldest first
+func (b *Builder) Candles() []Candle {
+	result := make([]Candle, 0, len(b.candles))
+	for _, c := range b.candles {
+		result = append(result, *c)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Start.Before(result[j].Start)
+	})
+	return result
+}
+
+// Prune drops the candles starting before t, e.g. once they were exported
+func (b *Builder) Prune(t time.Time) {
+	for start, c := range b.candles {
+		if c.Start.Before(t) {
+			delete(b.candles, start)
+		}
+	}
+}
+
+// FillGaps returns candles sorted oldest first, with a candle for every interval between the first and the last one.
+// An interval without trades gets a flat candle at the previous close, with no volume and no trades.
+// The candles passed in are not modified.
+func FillGaps(candles []Candle, interval time.Duration) []Candle {
+	if len(candles) == 0 || interval <= 0 {
+		return candles
+	}
+
+	sorted := make([]Candle, len(candles))
+	copy(sorted, candles)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Start.Before(sorted[j].Start)
+	})
+
+	result := make([]Candle, 0, len(candles))
+	for i, c := range sorted {
+		if i > 0 {
+			prev := result[len(result)-1]
+			for start := prev.Start.Add(interval); start.Before(c.Start); start = start.Add(interval) {
+				result = append(result, Candle{
+					Start:  start,
+					Open:   prev.Close,
+					High:   prev.Close,
+					Low:    prev.Close,
+					Close:  prev.Close,
+					Volume: decimal.Zero,
+				})
+			}
+		}
+		result = append(result, c)
+	}
+	return result
+}
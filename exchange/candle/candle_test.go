@@ -0,0 +1,158 @@
+package candle
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/exchange-api/exchange"
+)
+
+var start = time.Date(2018, 3, 20, 10, 0, 0, 0, time.UTC)
+
+func d(f float64) decimal.Decimal {
+	return decimal.NewFromFloat(f)
+}
+
+func trade(offset time.Duration, price, volume float64) exchange.Trade {
+	return exchange.Trade{
+		Timestamp: start.Add(offset),
+		Price:     d(price),
+		Volume:    d(volume),
+		Action:    exchange.ActionBuy,
+	}
+}
+
+func newBuilder(t *testing.T, interval time.Duration) *Builder {
+	b, err := NewBuilder(interval)
+	require.NoError(t, err)
+	return b
+}
+
+func requireCandle(t *testing.T, c Candle, offset time.Duration, open, high, low, close, volume float64, trades int) {
+	require.Equal(t, start.Add(offset), c.Start)
+	require.True(t, c.Open.Equal(d(open)), "open %s", c.Open)
+	require.True(t, c.High.Equal(d(high)), "high %s", c.High)
+	require.True(t, c.Low.Equal(d(low)), "low %s", c.Low)
+	require.True(t, c.Close.Equal(d(close)), "close %s", c.Close)
+	require.True(t, c.Volume.Equal(d(volume)), "volume %s", c.Volume)
+	require.Equal(t, trades, c.Trades)
+}
+
+func TestNewBuilder(t *testing.T) {
+	_, err := NewBuilder(0)
+	require.Equal(t, ErrInvalidInterval, err)
+}
+
+func TestAddTrades(t *testing.T) {
+	b := newBuilder(t, time.Minute)
+
+	n := b.AddTrades(
+		trade(70*time.Second, 5, 1),
+		trade(10*time.Second, 2, 1),
+		trade(20*time.Second, 4, 2),
+		trade(50*time.Second, 3, 1),
+		trade(4*time.Minute, 6, 3),
+	)
+	require.Equal(t, 5, n)
+
+	candles := b.Candles()
+	require.Len(t, candles, 3)
+	requireCandle(t, candles[0], 0, 2, 4, 2, 3, 4, 3)
+	require.True(t, candles[0].QuoteVolume.Equal(d(13)))
+	requireCandle(t, candles[1], time.Minute, 5, 5, 5, 5, 1, 1)
+	requireCandle(t, candles[2], 4*time.Minute, 6, 6, 6, 6, 3, 1)
+}
+
+func TestAddTrades_OverlappingWindows(t *testing.T) {
+	b := newBuilder(t, time.Minute)
+
+	// two identical trades in the same second
+	first := []exchange.Trade{trade(0, 2, 1), trade(30*time.Second, 3, 1), trade(30*time.Second, 3, 1)}
+	require.Equal(t, 3, b.AddTrades(first...))
+
+	// the same window again, with fresh decimals
+	again := []exchange.Trade{trade(0, 2, 1), trade(30*time.Second, 3, 1), trade(30*time.Second, 3, 1)}
+	require.Equal(t, 0, b.AddTrades(again...))
+
+	// an overlapping window with a third identical trade in the last second and a newer trade
+	next := []exchange.Trade{
+		trade(30*time.Second, 3, 1),
+		trade(30*time.Second, 3, 1),
+		trade(30*time.Second, 3, 1),
+		trade(90*time.Second, 4, 1),
+	}
+	require.Equal(t, 2, b.AddTrades(next...))
+
+	candles := b.Candles()
+	require.Len(t, candles, 2)
+	requireCandle(t, candles[0], 0, 2, 3, 2, 3, 4, 4)
+	requireCandle(t, candles[1], time.Minute, 4, 4, 4, 4, 1, 1)
+}
+
+func TestAddTrades_NewTimestamp(t *testing.T) {
+	b := newBuilder(t, time.Minute)
+	require.Equal(t, 1, b.AddTrades(trade(0, 2, 1)))
+
+	// a window without the previous last trade, with two trades identical to it at a newer timestamp
+	require.Equal(t, 2, b.AddTrades(trade(10*time.Second, 2, 1), trade(10*time.Second, 2, 1)))
+
+	candles := b.Candles()
+	require.Len(t, candles, 1)
+	requireCandle(t, candles[0], 0, 2, 2, 2, 2, 3, 3)
+}
+
+func TestAddTicker(t *testing.T) {
+	b := newBuilder(t, 5*time.Minute)
+
+	sample := func(offset time.Duration, last, volume float64) exchange.Ticker {
+		return exchange.Ticker{Timestamp: start.Add(offset), Last: d(last), Volume: d(volume)}
+	}
+
+	require.True(t, b.AddTicker(sample(0, 3, 100)))
+	require.True(t, b.AddTicker(sample(time.Minute, 4, 110)))
+	require.False(t, b.AddTicker(sample(time.Minute, 9, 200)))
+	// trades left the 24h window
+	require.True(t, b.AddTicker(sample(2*time.Minute, 2, 90)))
+	require.True(t, b.AddTicker(sample(6*time.Minute, 5, 95)))
+
+	candles := b.Candles()
+	require.Len(t, candles, 2)
+	requireCandle(t, candles[0], 0, 3, 4, 2, 2, 10, 3)
+	requireCandle(t, candles[1], 5*time.Minute, 5, 5, 5, 5, 5, 1)
+}
+
+func TestFillGaps(t *testing.T) {
+	b := newBuilder(t, time.Minute)
+	b.AddTrades(trade(0, 2, 1), trade(30*time.Second, 3, 1), trade(3*time.Minute, 4, 1))
+
+	candles := FillGaps(b.Candles(), b.Interval)
+	require.Len(t, candles, 4)
+	requireCandle(t, candles[0], 0, 2, 3, 2, 3, 2, 2)
+	requireCandle(t, candles[1], time.Minute, 3, 3, 3, 3, 0, 0)
+	requireCandle(t, candles[2], 2*time.Minute, 3, 3, 3, 3, 0, 0)
+	requireCandle(t, candles[3], 3*time.Minute, 4, 4, 4, 4, 1, 1)
+
+	// unsorted candles are sorted first
+	unsorted := b.Candles()
+	unsorted[0], unsorted[1] = unsorted[1], unsorted[0]
+	require.Equal(t, candles, FillGaps(unsorted, b.Interval))
+	require.Equal(t, 3*time.Minute, unsorted[0].Start.Sub(start))
+
+	require.Empty(t, FillGaps(nil, time.Minute))
+}
+
+func TestPrune(t *testing.T) {
+	b := newBuilder(t, time.Minute)
+	b.AddTrades(trade(0, 2, 1), trade(3*time.Minute, 4, 1))
+
+	b.Prune(start.Add(time.Minute))
+	candles := b.Candles()
+	require.Len(t, candles, 1)
+	require.Equal(t, start.Add(3*time.Minute), candles[0].Start)
+
+	// pruned trades are not added again
+	require.Equal(t, 0, b.AddTrades(trade(0, 2, 1)))
+}
@@ -0,0 +1,39 @@
+package candle
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+// csvHeader is the first row written by WriteCSV
+var csvHeader = []string{"start", "open", "high", "low", "close", "volume", "quote_volume", "trades"}
+
+// WriteCSV writes candles as CSV with a header row. Start is formatted as RFC 3339 in UTC.
+func WriteCSV(w io.Writer, candles []Candle) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, c := range candles {
+		err := cw.Write([]string{
+			c.Start.UTC().Format(time.RFC3339Nano),
+			c.Open.String(),
+			c.High.String(),
+			c.Low.String(),
+			c.Close.String(),
+			c.Volume.String(),
+			c.QuoteVolume.String(),
+			strconv.Itoa(c.Trades),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,23 @@
+package candle
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteCSV(t *testing.T) {
+	b := newBuilder(t, time.Minute)
+	b.AddTrades(trade(0, 0.5, 2), trade(30*time.Second, 0.25, 1), trade(2*time.Minute, 1, 1))
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteCSV(&buf, FillGaps(b.Candles(), b.Interval)))
+
+	require.Equal(t, `start,open,high,low,close,volume,quote_volume,trades
+2018-03-20T10:00:00Z,0.5,0.5,0.25,0.25,3,1.25,2
+2018-03-20T10:01:00Z,0.25,0.25,0.25,0.25,0,0,0
+2018-03-20T10:02:00Z,1,1,1,1,1,1,1
+`, buf.String())
+}
@@ -0,0 +1,193 @@
+package exchange
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// ErrNoOrderbooks is returned when there is nothing to consolidate
+	ErrNoOrderbooks = errors.New("no orderbooks to consolidate")
+	// ErrSymbolMismatch is returned when orderbooks of different markets are consolidated
+	ErrSymbolMismatch = errors.New("orderbooks are for different markets")
+	// ErrInvalidFee is returned when a fee rate is negative or not below 1
+	ErrInvalidFee = errors.New("fee must be at least 0 and below 1")
+	// ErrNoOrderbook is returned when a venue has no orderbook record
+	ErrNoOrderbook = errors.New("no orderbook")
+	one            = decimal.New(1, 0)
+)
+
+// VenueBook is one venue's orderbook to be consolidated
+type VenueBook struct {
+	Venue  string
+	Record *MarketRecord
+	// Fee is the venue's taker fee rate, e.g. 0.002 for 0.2%
+	Fee decimal.Decimal
+	// Codec decodes the record's symbol, CanonicalCodec if nil
+	Codec PairCodec
+}
+
+// VenueLevel is a price level of a consolidated orderbook
+type VenueLevel struct {
+	Venue  string          `json:"venue"`
+	Price  decimal.Decimal `json:"price"`
+	Volume decimal.Decimal `json:"volume"`
+	// EffectivePrice includes the venue's fee, it is higher than Price for asks and lower for bids
+	EffectivePrice decimal.Decimal `json:"effective_price"`
+}
+
+// ConsolidatedBook merges the orderbooks of one market on several venues.
+// Bids are sorted from the highest and asks from the lowest effective price.
+type ConsolidatedBook struct {
+	// Timestamp is the oldest of the venue orderbooks' timestamps
+	Timestamp time.Time    `json:"timestamp"`
+	Pair      Pair         `json:"pair"`
+	Venues    []string     `json:"venues"`
+	Bids      []VenueLevel `json:"bids"`
+	Asks      []VenueLevel `json:"asks"`
+}
+
+// Consolidate merges orderbooks of the same market from several venues. Each level keeps its venue and
+// is priced with the venue's fee, so the best levels are the best executable prices across venues.
+func Consolidate(books ...VenueBook) (*ConsolidatedBook, error) {
+	if len(books) == 0 {
+		return nil, ErrNoOrderbooks
+	}
+
+	var c ConsolidatedBook
+	for i, b := range books {
+		if !b.Fee.GreaterThanOrEqual(decimal.Zero) || !b.Fee.LessThan(one) {
+			return nil, ErrInvalidFee
+		}
+
+		if b.Record == nil {
+			return nil, fmt.Errorf("%s: %v", b.Venue, ErrNoOrderbook)
+		}
+
+		pair, err := b.Record.Pair(b.Codec)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", b.Venue, err)
+		}
+
+		if i == 0 {
+			c.Pair = pair
+			c.Timestamp = b.Record.Timestamp
+		} else if pair != c.Pair {
+			return nil, ErrSymbolMismatch
+		}
+
+		if b.Record.Timestamp.Before(c.Timestamp) {
+			c.Timestamp = b.Record.Timestamp
+		}
+
+		c.Venues = append(c.Venues, b.Venue)
+
+		for _, o := range b.Record.Bids {
+			c.Bids = append(c.Bids, VenueLevel{
+				Venue:          b.Venue,
+				Price:          o.Price,
+				Volume:         o.Volume,
+				EffectivePrice: o.Price.Mul(one.Sub(b.Fee)),
+			})
+		}
+
+		for _, o := range b.Record.Asks {
+			c.Asks = append(c.Asks, VenueLevel{
+				Venue:          b.Venue,
+				Price:          o.Price,
+				Volume:         o.Volume,
+				EffectivePrice: o.Price.Mul(one.Add(b.Fee)),
+			})
+		}
+	}
+
+	sortLevels(c.Bids, func(a, b decimal.Decimal) bool { return a.GreaterThan(b) })
+	sortLevels(c.Asks, func(a, b decimal.Decimal) bool { return a.LessThan(b) })
+
+	return &c, nil
+}
+
+// sortLevels sorts levels by effective price, then by venue to keep the order deterministic
+func sortLevels(levels []VenueLevel, better func(a, b decimal.Decimal) bool) {
+	sort.SliceStable(levels, func(i, j int) bool {
+		a, b := levels[i], levels[j]
+		if !a.EffectivePrice.Equal(b.EffectivePrice) {
+			return better(a.EffectivePrice, b.EffectivePrice)
+		}
+		return a.Venue < b.Venue
+	})
+}
+
+// BestBid returns the bid with the highest effective price
+func (c *ConsolidatedBook) BestBid() *VenueLevel {
+	if len(c.Bids) == 0 {
+		return nil
+	}
+	level := c.Bids[0]
+	return &level
+}
+
+// BestAsk returns the ask with the lowest effective price
+func (c *ConsolidatedBook) BestAsk() *VenueLevel {
+	if len(c.Asks) == 0 {
+		return nil
+	}
+	level := c.Asks[0]
+	return &level
+}
+
+// Liquidity is the volume offered on each side of an orderbook
+type Liquidity struct {
+	BidVolume decimal.Decimal `json:"bid_volume"`
+	AskVolume decimal.Decimal `json:"ask_volume"`
+}
+
+// Liquidity returns the total volume of all venues and the volume of each venue
+func (c *ConsolidatedBook) Liquidity() (Liquidity, map[string]Liquidity) {
+	var total Liquidity
+	venues := make(map[string]Liquidity, len(c.Venues))
+	for _, v := range c.Venues {
+		venues[v] = Liquidity{}
+	}
+
+	for _, l := range c.Bids {
+		v := venues[l.Venue]
+		v.BidVolume = v.BidVolume.Add(l.Volume)
+		venues[l.Venue] = v
+		total.BidVolume = total.BidVolume.Add(l.Volume)
+	}
+
+	for _, l := range c.Asks {
+		v := venues[l.Venue]
+		v.AskVolume = v.AskVolume.Add(l.Volume)
+		venues[l.Venue] = v
+		total.AskVolume = total.AskVolume.Add(l.Volume)
+	}
+
+	return total, venues
+}
+
+// MarketRecord converts the consolidated book to a MarketRecord priced at effective prices, dropping the venues.
+// It can be used with the fill planners to estimate the cost of trading across all venues.
+func (c *ConsolidatedBook) MarketRecord() MarketRecord {
+	r := MarketRecord{
+		Timestamp: c.Timestamp,
+		Symbol:    c.Pair.String(),
+		Bids:      make([]MarketOrder, len(c.Bids)),
+		Asks:      make([]MarketOrder, len(c.Asks)),
+	}
+
+	for i, l := range c.Bids {
+		r.Bids[i] = MarketOrder{Price: l.EffectivePrice, Volume: l.Volume}
+	}
+
+	for i, l := range c.Asks {
+		r.Asks[i] = MarketOrder{Price: l.EffectivePrice, Volume: l.Volume}
+	}
+
+	return r
+}
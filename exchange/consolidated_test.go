@@ -0,0 +1,115 @@
+package exchange
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+// quoteFirstCodec decodes "QUOTE_BASE" symbols, as used by C2CX
+var quoteFirstCodec = SeparatorCodec{Separator: "_", QuoteFirst: true}
+
+func newVenueBooks() []VenueBook {
+	cryptopia := newSortedMarketRecord()
+	cryptopia.Timestamp = time.Unix(1500000010, 0).UTC()
+
+	c2cx := MarketRecord{
+		Timestamp: time.Unix(1500000000, 0).UTC(),
+		Symbol:    "BTC_SKY",
+		Bids: []MarketOrder{
+			{Price: decimal.NewFromFloat(3.01), Volume: decimal.NewFromFloat(2.0)},
+		},
+		Asks: []MarketOrder{
+			{Price: decimal.NewFromFloat(4.0), Volume: decimal.NewFromFloat(1.0)},
+			{Price: decimal.NewFromFloat(4.5), Volume: decimal.NewFromFloat(4.0)},
+		},
+	}
+
+	return []VenueBook{
+		{Venue: "cryptopia", Record: &cryptopia, Fee: decimal.NewFromFloat(0.002)},
+		{Venue: "c2cx", Record: &c2cx, Codec: quoteFirstCodec},
+	}
+}
+
+func TestConsolidate(t *testing.T) {
+	c, err := Consolidate(newVenueBooks()...)
+	require.NoError(t, err)
+
+	require.Equal(t, NewPair(SKY, BTC), c.Pair)
+	require.True(t, c.Timestamp.Equal(time.Unix(1500000000, 0)))
+	require.Equal(t, []string{"cryptopia", "c2cx"}, c.Venues)
+
+	type level struct {
+		venue, price, effective string
+	}
+	levels := func(l []VenueLevel) []level {
+		var result []level
+		for _, v := range l {
+			result = append(result, level{v.Venue, v.Price.String(), v.EffectivePrice.String()})
+		}
+		return result
+	}
+
+	require.Equal(t, []level{
+		{"c2cx", "3.01", "3.01"},
+		{"cryptopia", "3", "2.994"},
+		{"cryptopia", "2", "1.996"},
+	}, levels(c.Bids))
+
+	// the fee makes cryptopia's ask at 4 worse than c2cx's
+	require.Equal(t, []level{
+		{"c2cx", "4", "4"},
+		{"cryptopia", "4", "4.008"},
+		{"c2cx", "4.5", "4.5"},
+		{"cryptopia", "5", "5.01"},
+	}, levels(c.Asks))
+
+	require.Equal(t, "c2cx", c.BestBid().Venue)
+	require.Equal(t, "c2cx", c.BestAsk().Venue)
+
+	total, venues := c.Liquidity()
+	require.True(t, total.BidVolume.Equal(decimal.NewFromFloat(15.0)))
+	require.True(t, total.AskVolume.Equal(decimal.NewFromFloat(17.0)))
+	require.True(t, venues["c2cx"].BidVolume.Equal(decimal.NewFromFloat(2.0)))
+	require.True(t, venues["c2cx"].AskVolume.Equal(decimal.NewFromFloat(5.0)))
+	require.True(t, venues["cryptopia"].BidVolume.Equal(decimal.NewFromFloat(13.0)))
+	require.True(t, venues["cryptopia"].AskVolume.Equal(decimal.NewFromFloat(12.0)))
+
+	r := c.MarketRecord()
+	require.NoError(t, r.Validate())
+	plan, err := r.BuyVolume(decimal.NewFromFloat(2.0), nil)
+	require.NoError(t, err)
+	require.True(t, plan.Cost.Equal(decimal.NewFromFloat(8.008)))
+}
+
+func TestConsolidateErrors(t *testing.T) {
+	_, err := Consolidate()
+	require.Equal(t, ErrNoOrderbooks, err)
+
+	books := newVenueBooks()
+	books[0].Fee = decimal.NewFromFloat(-0.1)
+	_, err = Consolidate(books...)
+	require.Equal(t, ErrInvalidFee, err)
+
+	books = newVenueBooks()
+	books[1].Codec = nil
+	_, err = Consolidate(books...)
+	require.EqualError(t, err, "c2cx: invalid symbol")
+
+	books = newVenueBooks()
+	books[1].Record = nil
+	_, err = Consolidate(books...)
+	require.EqualError(t, err, "c2cx: no orderbook")
+
+	books = newVenueBooks()
+	books[0].Record.Symbol = "SKY/ETH"
+	_, err = Consolidate(books...)
+	require.Equal(t, ErrSymbolMismatch, err)
+
+	c, err := Consolidate(VenueBook{Venue: "c2cx", Record: &MarketRecord{Symbol: "SKY/BTC"}})
+	require.NoError(t, err)
+	require.Nil(t, c.BestBid())
+	require.Nil(t, c.BestAsk())
+}
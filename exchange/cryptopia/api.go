@@ -2,6 +2,7 @@ package cryptopia
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -11,10 +12,11 @@ import (
 
 	"errors"
 
-	"net"
-	"time"
-
 	"github.com/shopspring/decimal"
+
+	"github.com/skycoin/exchange-api/exchange/internal/httpclient"
+	"github.com/skycoin/exchange-api/exchange/ratelimit"
+	"github.com/skycoin/exchange-api/exchange/retry"
 )
 
 const (
@@ -22,12 +24,6 @@ const (
 	InstantOrderID = -1
 )
 
-const (
-	dialTimeout         = 60 * time.Second
-	httpClientTimeout   = 120 * time.Second
-	tlsHandshakeTimeout = 60 * time.Second
-)
-
 var (
 	apiroot = url.URL{
 		Scheme: "https",
@@ -48,38 +44,111 @@ type response struct {
 	Data    json.RawMessage `json:"Data"`
 }
 
-// Client implements a wrapper around the Cryptopia API interface
+// Client implements a wrapper around the Cryptopia API interface.
+// The methods ending with Context cancel their requests when the context is done, and then return ctx.Err().
 type Client struct {
-	Key           string
-	Secret        string
-	httpClient    *http.Client
+	Key    string
+	Secret string
+	// HTTPClient sends the requests, http.DefaultClient if nil
+	HTTPClient *http.Client
+	// BaseURL is the root of the API endpoints, https://www.cryptopia.co.nz/api/ if nil
+	BaseURL *url.URL
+	// UserAgent, if set, is sent in the User-Agent header
+	UserAgent     string
 	currencyCache map[string]CurrencyInfo
 	marketCache   map[string]int
+	// Limiter, if set, delays requests to stay within the rate limits. It can be shared by the clients of an API key.
+	Limiter *ratelimit.Limiter
+	// Retry, if set, retries failed requests. Requests which move funds or create orders are only retried
+	// when they were rate limited.
+	Retry *retry.Policy
 }
 
-func NewAPIClient(key string, secret string) *Client {
-	var netTransport = http.Transport{
-		Dial: (&net.Dialer{
-			Timeout: dialTimeout,
-		}).Dial,
-		TLSHandshakeTimeout: tlsHandshakeTimeout,
+// StatusError is returned when a response has an HTTP error status
+type StatusError struct {
+	Endpoint   string
+	StatusCode int
+}
+
+func (e StatusError) Error() string {
+	return fmt.Sprintf("Cryptopia request failed: endpoint=%s status=%d %s", e.Endpoint, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// nonIdempotent are the endpoints which must not be sent twice
+var nonIdempotent = map[string]bool{
+	"submittrade":    true,
+	"submittip":      true,
+	"submitwithdraw": true,
+	"submittransfer": true,
+}
+
+// classify classifies the errors of requests for retries
+func classify(err error) retry.Class {
+	if e, ok := err.(StatusError); ok {
+		switch {
+		case e.StatusCode == http.StatusTooManyRequests:
+			return retry.RateLimited
+		case e.StatusCode >= 500 && e.StatusCode < 600:
+			return retry.Transient
+		}
+		return retry.Permanent
 	}
-	var client = &http.Client{
-		Transport: &netTransport,
-		Timeout:   httpClientTimeout,
+	return retry.NetClass(err)
+}
+
+// NewLimiter creates a Limiter for a Client, with a global rate and a rate for every endpoint.
+// Cancelling trades has priority over other requests, and polling market data has the lowest priority.
+func NewLimiter(global, endpoint ratelimit.Rate) *ratelimit.Limiter {
+	l := ratelimit.New(global, endpoint)
+	l.Priorities["CancelTrade"] = ratelimit.PriorityHigh
+	for _, e := range []string{"getmarket", "getmarkets", "getmarkethistory", "getmarketorders", "getmarketordergroups"} {
+		l.Priorities[e] = ratelimit.PriorityLow
 	}
+	return l
+}
+
+// Options configures the HTTP requests of a Client created by NewAPIClientWithOptions. Zero fields use the defaults.
+type Options = httpclient.Options
+
+// NewAPIClient creates a Client retrying with the default retry.Policy.
+// Unlike the c2cx client its Limiter is nil: Cryptopia doesn't publish its rate limits, so rate limited
+// requests are only retried after a delay. Set a Limiter created with NewLimiter to throttle requests.
+func NewAPIClient(key string, secret string) *Client {
+	// the default options are valid
+	c, _ := NewAPIClientWithOptions(key, secret, Options{})
+	return c
+}
+
+// NewAPIClientWithOptions creates a Client like NewAPIClient, configured by opts.
+// An error is returned if opts.BaseURL is not an absolute URL.
+// Private requests are signed with their URL, so a BaseURL other than Cryptopia's only works with public endpoints
+// unless the server it points to accepts the signature.
+func NewAPIClientWithOptions(key string, secret string, opts Options) (*Client, error) {
+	baseURL, err := opts.URL(apiroot)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Client{
 		Key:        key,
 		Secret:     secret,
-		httpClient: client,
-	}
+		HTTPClient: opts.Client(),
+		BaseURL:    baseURL,
+		UserAgent:  opts.UserAgent,
+		Retry:      retry.NewPolicy(),
+	}, nil
 }
 
 //Public API functions
 
 // GetCurrencies gets all currencies
 func (c *Client) GetCurrencies() ([]CurrencyInfo, error) {
-	resp, err := c.get("getcurrencies", "")
+	return c.GetCurrenciesContext(context.Background())
+}
+
+// GetCurrenciesContext is GetCurrencies with a context
+func (c *Client) GetCurrenciesContext(ctx context.Context) ([]CurrencyInfo, error) {
+	resp, err := c.get(ctx, "getcurrencies", "")
 	if err != nil {
 		return nil, err
 	}
@@ -96,9 +165,37 @@ func (c *Client) GetCurrencies() ([]CurrencyInfo, error) {
 	return result, nil
 }
 
+// GetWithdrawFees gets the flat withdrawal fee of every currency, keyed by upper case symbol
+func (c *Client) GetWithdrawFees() (map[string]decimal.Decimal, error) {
+	return c.GetWithdrawFeesContext(context.Background())
+}
+
+// GetWithdrawFeesContext is GetWithdrawFees with a context
+func (c *Client) GetWithdrawFeesContext(ctx context.Context) (map[string]decimal.Decimal, error) {
+	currencies, err := c.GetCurrenciesContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return withdrawFees(currencies), nil
+}
+
+func withdrawFees(currencies []CurrencyInfo) map[string]decimal.Decimal {
+	result := make(map[string]decimal.Decimal, len(currencies))
+	for _, v := range currencies {
+		result[strings.ToUpper(v.Symbol)] = v.WithdrawFee
+	}
+	return result
+}
+
 // GetTradePairs gets all TradePairs on exchange
 func (c *Client) GetTradePairs() ([]TradepairInfo, error) {
-	resp, err := c.get("gettradepairs", "")
+	return c.GetTradePairsContext(context.Background())
+}
+
+// GetTradePairsContext is GetTradePairs with a context
+func (c *Client) GetTradePairsContext(ctx context.Context) ([]TradepairInfo, error) {
+	resp, err := c.get(ctx, "gettradepairs", "")
 	if err != nil {
 		return nil, err
 	}
@@ -119,10 +216,15 @@ func (c *Client) GetTradePairs() ([]TradepairInfo, error) {
 // if baseMarket is empty or "all" getMarkets return all markets
 // if hours < 1 it will be omitted, default value is 24
 func (c *Client) GetMarkets(baseMarket string, hours int) ([]MarketInfo, error) {
+	return c.GetMarketsContext(context.Background(), baseMarket, hours)
+}
+
+// GetMarketsContext is GetMarkets with a context
+func (c *Client) GetMarketsContext(ctx context.Context, baseMarket string, hours int) ([]MarketInfo, error) {
 	var requestParams string
 
 	if len(baseMarket) > 0 && strings.ToUpper(baseMarket) != "ALL" {
-		if _, err := c.GetCurrencyID(baseMarket); err != nil {
+		if _, err := c.GetCurrencyIDContext(ctx, baseMarket); err != nil {
 			return nil, err
 		}
 		requestParams += normalize(baseMarket)
@@ -132,7 +234,7 @@ func (c *Client) GetMarkets(baseMarket string, hours int) ([]MarketInfo, error)
 		requestParams += "/" + strconv.Itoa(hours)
 	}
 
-	resp, err := c.get("getmarkets", requestParams)
+	resp, err := c.get(ctx, "getmarkets", requestParams)
 	if err != nil {
 		return nil, err
 	}
@@ -152,7 +254,12 @@ func (c *Client) GetMarkets(baseMarket string, hours int) ([]MarketInfo, error)
 // GetMarket return market with given label
 // if hours < 1, it will be omitted, default value is 24
 func (c *Client) GetMarket(market string, hours int) (*MarketInfo, error) {
-	marketID, err := c.GetMarketID(market)
+	return c.GetMarketContext(context.Background(), market, hours)
+}
+
+// GetMarketContext is GetMarket with a context
+func (c *Client) GetMarketContext(ctx context.Context, market string, hours int) (*MarketInfo, error) {
+	marketID, err := c.GetMarketIDContext(ctx, market)
 	if err != nil {
 		return nil, err
 	}
@@ -163,7 +270,7 @@ func (c *Client) GetMarket(market string, hours int) (*MarketInfo, error) {
 		requestParams += "/" + strconv.Itoa(hours)
 	}
 
-	resp, err := c.get("getmarket", requestParams)
+	resp, err := c.get(ctx, "getmarket", requestParams)
 	if err != nil {
 		return nil, err
 	}
@@ -183,7 +290,12 @@ func (c *Client) GetMarket(market string, hours int) (*MarketInfo, error) {
 // GetMarketHistory return market history with given label
 // if hours < 1, it will be omitted, default value is 24
 func (c *Client) GetMarketHistory(market string, hours int) ([]MarketHistory, error) {
-	marketID, err := c.GetMarketID(market)
+	return c.GetMarketHistoryContext(context.Background(), market, hours)
+}
+
+// GetMarketHistoryContext is GetMarketHistory with a context
+func (c *Client) GetMarketHistoryContext(ctx context.Context, market string, hours int) ([]MarketHistory, error) {
+	marketID, err := c.GetMarketIDContext(ctx, market)
 	if err != nil {
 		return nil, err
 	}
@@ -194,7 +306,7 @@ func (c *Client) GetMarketHistory(market string, hours int) ([]MarketHistory, er
 		requestParams += "/" + strconv.Itoa(hours)
 	}
 
-	resp, err := c.get("getmarkethistory", requestParams)
+	resp, err := c.get(ctx, "getmarkethistory", requestParams)
 	if err != nil {
 		return nil, err
 	}
@@ -214,7 +326,12 @@ func (c *Client) GetMarketHistory(market string, hours int) ([]MarketHistory, er
 // GetMarketOrders returns count orders from market with given label
 // if count < 1, its will be omitted, default value is 100
 func (c *Client) GetMarketOrders(market string, count int) (*MarketOrders, error) {
-	marketID, err := c.GetMarketID(market)
+	return c.GetMarketOrdersContext(context.Background(), market, count)
+}
+
+// GetMarketOrdersContext is GetMarketOrders with a context
+func (c *Client) GetMarketOrdersContext(ctx context.Context, market string, count int) (*MarketOrders, error) {
+	marketID, err := c.GetMarketIDContext(ctx, market)
 	if err != nil {
 		return nil, err
 	}
@@ -225,7 +342,7 @@ func (c *Client) GetMarketOrders(market string, count int) (*MarketOrders, error
 		requestParams += "/" + strconv.Itoa(count)
 	}
 
-	resp, err := c.get("getmarketorders", requestParams)
+	resp, err := c.get(ctx, "getmarketorders", requestParams)
 	if err != nil {
 		return nil, err
 	}
@@ -245,6 +362,11 @@ func (c *Client) GetMarketOrders(market string, count int) (*MarketOrders, error
 // GetMarketOrderGroups returns count Orders to each market
 // If count < 1, it will be omitted
 func (c *Client) GetMarketOrderGroups(count int, markets []string) ([]MarketOrdersWithLabel, error) {
+	return c.GetMarketOrderGroupsContext(context.Background(), count, markets)
+}
+
+// GetMarketOrderGroupsContext is GetMarketOrderGroups with a context
+func (c *Client) GetMarketOrderGroupsContext(ctx context.Context, count int, markets []string) ([]MarketOrdersWithLabel, error) {
 	if len(markets) == 0 {
 		return nil, errors.New("markets must not be empty")
 	}
@@ -252,7 +374,7 @@ func (c *Client) GetMarketOrderGroups(count int, markets []string) ([]MarketOrde
 	var requestParams string
 
 	for _, v := range markets {
-		marketID, err := c.GetMarketID(v)
+		marketID, err := c.GetMarketIDContext(ctx, v)
 		if err != nil {
 			return nil, err
 		}
@@ -265,7 +387,7 @@ func (c *Client) GetMarketOrderGroups(count int, markets []string) ([]MarketOrde
 		requestParams += "/" + strconv.Itoa(count)
 	}
 
-	resp, err := c.get("getmarketordergroups", requestParams)
+	resp, err := c.get(ctx, "getmarketordergroups", requestParams)
 	if err != nil {
 		return nil, fmt.Errorf("GetMarketOrderGroups failed, markets: %s; original error: %v", strings.Join(markets, " "), err)
 	}
@@ -286,13 +408,18 @@ func (c *Client) GetMarketOrderGroups(count int, markets []string) ([]MarketOrde
 
 // GetBalance return a string representation of balance by given currency
 func (c *Client) GetBalance(currency string) (decimal.Decimal, error) {
-	cID, err := c.GetCurrencyID(currency)
+	return c.GetBalanceContext(context.Background(), currency)
+}
+
+// GetBalanceContext is GetBalance with a context
+func (c *Client) GetBalanceContext(ctx context.Context, currency string) (decimal.Decimal, error) {
+	cID, err := c.GetCurrencyIDContext(ctx, currency)
 	if err != nil {
 		return decimal.Zero, fmt.Errorf("Currency %s does not found", currency)
 	}
 	params := make(map[string]interface{})
 	params["CurrencyId"] = cID
-	resp, err := c.post("getbalance", params)
+	resp, err := c.post(ctx, "getbalance", params)
 	if err != nil {
 		return decimal.Zero, err
 	}
@@ -313,9 +440,38 @@ func (c *Client) GetBalance(currency string) (decimal.Decimal, error) {
 	return decimal.Zero, errors.New("currency was not found")
 }
 
+// GetBalances returns the balances of all currencies
+func (c *Client) GetBalances() ([]Balance, error) {
+	return c.GetBalancesContext(context.Background())
+}
+
+// GetBalancesContext is GetBalances with a context
+func (c *Client) GetBalancesContext(ctx context.Context) ([]Balance, error) {
+	resp, err := c.post(ctx, "getbalance", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if !resp.Success {
+		return nil, fmt.Errorf("GetBalances failed: %s", resp.Message)
+	}
+
+	var result []Balance
+	if err := json.Unmarshal(resp.Data, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
 // GetDepositAddress returns a deposit address of given currency
 func (c *Client) GetDepositAddress(currency string) (*DepositAddress, error) {
-	cID, err := c.GetCurrencyID(currency)
+	return c.GetDepositAddressContext(context.Background(), currency)
+}
+
+// GetDepositAddressContext is GetDepositAddress with a context
+func (c *Client) GetDepositAddressContext(ctx context.Context, currency string) (*DepositAddress, error) {
+	cID, err := c.GetCurrencyIDContext(ctx, currency)
 	if err != nil {
 		return nil, fmt.Errorf("Currency %s does not found", currency)
 	}
@@ -323,7 +479,7 @@ func (c *Client) GetDepositAddress(currency string) (*DepositAddress, error) {
 	params := make(map[string]interface{})
 	params["CurrencyId"] = cID
 
-	resp, err := c.post("getdepositaddress", params)
+	resp, err := c.post(ctx, "getdepositaddress", params)
 	if err != nil {
 		return nil, err
 	}
@@ -341,10 +497,15 @@ func (c *Client) GetDepositAddress(currency string) (*DepositAddress, error) {
 
 // GetOpenOrders return a list of opened orders by specific market or all markets
 func (c *Client) GetOpenOrders(market *string, count *int) ([]Order, error) {
+	return c.GetOpenOrdersContext(context.Background(), market, count)
+}
+
+// GetOpenOrdersContext is GetOpenOrders with a context
+func (c *Client) GetOpenOrdersContext(ctx context.Context, market *string, count *int) ([]Order, error) {
 	params := make(map[string]interface{})
 
 	if market != nil {
-		mID, err := c.GetMarketID(*market)
+		mID, err := c.GetMarketIDContext(ctx, *market)
 		if err != nil {
 			return nil, err
 		}
@@ -354,7 +515,7 @@ func (c *Client) GetOpenOrders(market *string, count *int) ([]Order, error) {
 	if count != nil {
 		params["Count"] = *count
 	}
-	resp, err := c.post("getopenorders", params)
+	resp, err := c.post(ctx, "getopenorders", params)
 	if err != nil {
 		return nil, err
 	}
@@ -372,10 +533,15 @@ func (c *Client) GetOpenOrders(market *string, count *int) ([]Order, error) {
 
 // GetTradeHistory return a list of all executed orders by specific market or all markets
 func (c *Client) GetTradeHistory(market *string, count *int) ([]Order, error) {
+	return c.GetTradeHistoryContext(context.Background(), market, count)
+}
+
+// GetTradeHistoryContext is GetTradeHistory with a context
+func (c *Client) GetTradeHistoryContext(ctx context.Context, market *string, count *int) ([]Order, error) {
 	params := make(map[string]interface{})
 
 	if market != nil {
-		mID, err := c.GetMarketID(*market)
+		mID, err := c.GetMarketIDContext(ctx, *market)
 		if err != nil {
 			return nil, err
 		}
@@ -386,7 +552,7 @@ func (c *Client) GetTradeHistory(market *string, count *int) ([]Order, error) {
 		params["Count"] = count
 	}
 
-	resp, err := c.post("gettradehistory", params)
+	resp, err := c.post(ctx, "gettradehistory", params)
 	if err != nil {
 		return nil, err
 	}
@@ -406,6 +572,11 @@ func (c *Client) GetTradeHistory(market *string, count *int) ([]Order, error) {
 // GetTransactions returns a list of transactions by given type
 // if count < 1, it will be omitted
 func (c *Client) GetTransactions(txType string, count int) ([]Transaction, error) {
+	return c.GetTransactionsContext(context.Background(), txType, count)
+}
+
+// GetTransactionsContext is GetTransactions with a context
+func (c *Client) GetTransactionsContext(ctx context.Context, txType string, count int) ([]Transaction, error) {
 	if txType = strings.Title(txType); txType != TxTypeDeposit && txType != TxTypeWithdraw {
 		return nil, fmt.Errorf("Icorrect trasnaction type %s; avalible types: %s %s", txType, TxTypeDeposit, TxTypeWithdraw)
 	}
@@ -416,7 +587,7 @@ func (c *Client) GetTransactions(txType string, count int) ([]Transaction, error
 		params["Count"] = count
 	}
 
-	resp, err := c.post("gettransactions", params)
+	resp, err := c.post(ctx, "gettransactions", params)
 	if err != nil {
 		return nil, err
 	}
@@ -435,11 +606,16 @@ func (c *Client) GetTransactions(txType string, count int) ([]Transaction, error
 
 // SubmitTrade submits a new trade offer
 func (c *Client) SubmitTrade(market, offerType string, rate, amount decimal.Decimal) (int, error) {
+	return c.SubmitTradeContext(context.Background(), market, offerType, rate, amount)
+}
+
+// SubmitTradeContext is SubmitTrade with a context
+func (c *Client) SubmitTradeContext(ctx context.Context, market, offerType string, rate, amount decimal.Decimal) (int, error) {
 	if offerType = strings.Title(offerType); offerType != OfferTypeBuy && offerType != OfferTypeSell {
 		return 0, fmt.Errorf("incorrect offer type %s; avalible types: %s %s", offerType, OfferTypeBuy, OfferTypeSell)
 	}
 
-	mID, err := c.GetMarketID(market)
+	mID, err := c.GetMarketIDContext(ctx, market)
 	if err != nil {
 		return 0, err
 	}
@@ -450,7 +626,7 @@ func (c *Client) SubmitTrade(market, offerType string, rate, amount decimal.Deci
 	params["Rate"] = rate
 	params["Amount"] = amount
 
-	resp, err := c.post("submittrade", params)
+	resp, err := c.post(ctx, "submittrade", params)
 	if err != nil {
 		return 0, err
 	}
@@ -474,6 +650,11 @@ func (c *Client) SubmitTrade(market, offerType string, rate, amount decimal.Deci
 // CancelTrade cancel trades by given orderID, market or add active
 // depends of type argument
 func (c *Client) CancelTrade(tradeType string, TradePair *string, orderID *int) ([]int, error) {
+	return c.CancelTradeContext(context.Background(), tradeType, TradePair, orderID)
+}
+
+// CancelTradeContext is CancelTrade with a context
+func (c *Client) CancelTradeContext(ctx context.Context, tradeType string, TradePair *string, orderID *int) ([]int, error) {
 	params := map[string]interface{}{
 		"Type": tradeType,
 	}
@@ -488,7 +669,7 @@ func (c *Client) CancelTrade(tradeType string, TradePair *string, orderID *int)
 		if TradePair == nil {
 			return nil, errors.New("for this type TradePair should be valid")
 		}
-		if tradepairID, err := c.GetMarketID(*TradePair); err == nil {
+		if tradepairID, err := c.GetMarketIDContext(ctx, *TradePair); err == nil {
 			params["TradePairId"] = tradepairID
 		} else {
 			return nil, errors.New("invalid tradepair")
@@ -499,7 +680,7 @@ func (c *Client) CancelTrade(tradeType string, TradePair *string, orderID *int)
 		return nil, errors.New("invalid cancel type")
 	}
 
-	resp, err := c.post("CancelTrade", params)
+	resp, err := c.post(ctx, "CancelTrade", params)
 	if err != nil {
 		return nil, err
 	}
@@ -518,11 +699,16 @@ func (c *Client) CancelTrade(tradeType string, TradePair *string, orderID *int)
 
 // SubmitTip submits a tip to Trollbox
 func (c *Client) SubmitTip(currency string, activeUsers int, amount decimal.Decimal) (string, error) {
+	return c.SubmitTipContext(context.Background(), currency, activeUsers, amount)
+}
+
+// SubmitTipContext is SubmitTip with a context
+func (c *Client) SubmitTipContext(ctx context.Context, currency string, activeUsers int, amount decimal.Decimal) (string, error) {
 	if activeUsers < 2 || activeUsers > 100 {
 		return "", errors.New("activeUsers range 2-100")
 	}
 
-	cID, err := c.GetCurrencyID(currency)
+	cID, err := c.GetCurrencyIDContext(ctx, currency)
 	if err != nil {
 		return "", err
 	}
@@ -532,7 +718,7 @@ func (c *Client) SubmitTip(currency string, activeUsers int, amount decimal.Deci
 	params["CurrencyId"] = cID
 	params["Amount"] = amount
 
-	resp, err := c.post("submittip", params)
+	resp, err := c.post(ctx, "submittip", params)
 	if err != nil {
 		return "", err
 	}
@@ -552,7 +738,12 @@ func (c *Client) SubmitTip(currency string, activeUsers int, amount decimal.Deci
 // SubmitWithdraw submits a withdrawal request. If address does not exists in you AddressBook, it will fail
 // paymentid will be used only for currencies, based of CryptoNote algorhitm
 func (c *Client) SubmitWithdraw(currency, address, paymentid string, amount decimal.Decimal) (int, error) {
-	cID, err := c.GetCurrencyID(currency)
+	return c.SubmitWithdrawContext(context.Background(), currency, address, paymentid, amount)
+}
+
+// SubmitWithdrawContext is SubmitWithdraw with a context
+func (c *Client) SubmitWithdrawContext(ctx context.Context, currency, address, paymentid string, amount decimal.Decimal) (int, error) {
+	cID, err := c.GetCurrencyIDContext(ctx, currency)
 	if err != nil {
 		return 0, err
 	}
@@ -568,7 +759,7 @@ func (c *Client) SubmitWithdraw(currency, address, paymentid string, amount deci
 	params["Address"] = address
 	params["Amount"] = amount
 
-	resp, err := c.post("submitwithdraw", params)
+	resp, err := c.post(ctx, "submitwithdraw", params)
 	if err != nil {
 		return 0, err
 	}
@@ -587,7 +778,12 @@ func (c *Client) SubmitWithdraw(currency, address, paymentid string, amount deci
 
 // SubmitTransfer submit a transfer funds to another user
 func (c *Client) SubmitTransfer(currency, username string, amount decimal.Decimal) (string, error) {
-	cID, err := c.GetCurrencyID(currency)
+	return c.SubmitTransferContext(context.Background(), currency, username, amount)
+}
+
+// SubmitTransferContext is SubmitTransfer with a context
+func (c *Client) SubmitTransferContext(ctx context.Context, currency, username string, amount decimal.Decimal) (string, error) {
+	cID, err := c.GetCurrencyIDContext(ctx, currency)
 	if err != nil {
 		return "", err
 	}
@@ -597,7 +793,7 @@ func (c *Client) SubmitTransfer(currency, username string, amount decimal.Decima
 	params["Username"] = username
 	params["Amount"] = amount
 
-	resp, err := c.post("submittransfer", params)
+	resp, err := c.post(ctx, "submittransfer", params)
 	if err != nil {
 		return "", err
 	}
@@ -614,24 +810,58 @@ func (c *Client) SubmitTransfer(currency, username string, amount decimal.Decima
 	return result, nil
 }
 
-func (c *Client) get(endpoint string, params string) (*response, error) {
-	reqURL := apiroot
-	reqURL.Path += endpoint
+// wait waits for the Limiter to allow a request to an endpoint
+func (c *Client) wait(ctx context.Context, endpoint string) error {
+	if c.Limiter == nil {
+		return nil
+	}
+	return c.Limiter.Wait(ctx, endpoint)
+}
+
+func (c *Client) get(ctx context.Context, endpoint string, params string) (*response, error) {
+	var resp *response
+	err := c.Retry.Do(ctx, true, classify, func() error {
+		var err error
+		resp, err = c.sendGet(ctx, endpoint, params)
+		return err
+	})
+	return resp, err
+}
+
+func (c *Client) sendGet(ctx context.Context, endpoint string, params string) (*response, error) {
+	if err := c.wait(ctx, endpoint); err != nil {
+		return nil, err
+	}
+
+	reqURL := c.endpointURL(endpoint)
 	if len(params) > 0 {
 		reqURL.Path += "/" + params
 	}
 
-	resp, err := http.DefaultClient.Get(reqURL.String())
+	req, err := http.NewRequest("GET", reqURL.String(), nil)
 	if err != nil {
 		return nil, err
 	}
 
-	return readResponse(resp.Body)
+	return c.do(ctx, endpoint, req)
+}
+
+func (c *Client) post(ctx context.Context, endpoint string, params map[string]interface{}) (*response, error) {
+	var resp *response
+	err := c.Retry.Do(ctx, !nonIdempotent[endpoint], classify, func() error {
+		var err error
+		resp, err = c.sendPost(ctx, endpoint, params)
+		return err
+	})
+	return resp, err
 }
 
-func (c *Client) post(endpoint string, params map[string]interface{}) (*response, error) {
-	reqURL := apiroot
-	reqURL.Path += endpoint
+func (c *Client) sendPost(ctx context.Context, endpoint string, params map[string]interface{}) (*response, error) {
+	if err := c.wait(ctx, endpoint); err != nil {
+		return nil, err
+	}
+
+	reqURL := c.endpointURL(endpoint)
 	reqData, err := encodeValues(params)
 	if err != nil {
 		return nil, err
@@ -640,10 +870,52 @@ func (c *Client) post(endpoint string, params map[string]interface{}) (*response
 	req, _ := http.NewRequest("POST", reqURL.String(), bytes.NewReader(reqData))
 	req.Header.Add("Content-Type", "application/json; charset=utf-8")
 	req.Header.Add("Authorization", header(c.Key, c.Secret, nonce(), reqURL, reqData))
-	resp, err := http.DefaultClient.Do(req)
 
+	return c.do(ctx, endpoint, req)
+}
+
+// endpointURL returns the URL of an endpoint under BaseURL
+func (c *Client) endpointURL(endpoint string) url.URL {
+	u := apiroot
+	if c.BaseURL != nil {
+		u = *c.BaseURL
+	}
+	u.Path += endpoint
+	return u
+}
+
+// do sends a request with HTTPClient, canceling it when ctx is done
+func (c *Client) do(ctx context.Context, endpoint string, req *http.Request) (*response, error) {
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req.WithContext(ctx))
 	if err != nil {
-		return nil, err
+		return nil, contextError(ctx, err)
+	}
+
+	return checkResponse(endpoint, resp)
+}
+
+// contextError returns ctx.Err() if ctx is done, since it caused err, otherwise err
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
+// checkResponse reads the response of a request, or returns a StatusError for an HTTP error status
+func checkResponse(endpoint string, resp *http.Response) (*response, error) {
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		resp.Body.Close() // nolint: errcheck
+		return nil, StatusError{Endpoint: endpoint, StatusCode: resp.StatusCode}
 	}
 
 	return readResponse(resp.Body)
@@ -651,12 +923,17 @@ func (c *Client) post(endpoint string, params map[string]interface{}) (*response
 
 // GetCurrencyID returns the ID of a currency
 func (c *Client) GetCurrencyID(currency string) (int, error) {
+	return c.GetCurrencyIDContext(context.Background(), currency)
+}
+
+// GetCurrencyIDContext is GetCurrencyID with a context
+func (c *Client) GetCurrencyIDContext(ctx context.Context, currency string) (int, error) {
 	if v, ok := c.currencyCache[normalize(currency)]; ok {
 		return v.ID, nil
 	}
 
 	// If not found, try update first
-	if err := c.updateCurrencyCache(); err != nil {
+	if err := c.updateCurrencyCache(ctx); err != nil {
 		return 0, err
 	}
 
@@ -667,8 +944,8 @@ func (c *Client) GetCurrencyID(currency string) (int, error) {
 	return 0, ErrCurrencyNotFound
 }
 
-func (c *Client) updateCurrencyCache() error {
-	crs, err := c.GetCurrencies()
+func (c *Client) updateCurrencyCache(ctx context.Context) error {
+	crs, err := c.GetCurrenciesContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -683,12 +960,17 @@ func (c *Client) updateCurrencyCache() error {
 
 // GetMarketID returns the ID of a trade pair
 func (c *Client) GetMarketID(market string) (int, error) {
+	return c.GetMarketIDContext(context.Background(), market)
+}
+
+// GetMarketIDContext is GetMarketID with a context
+func (c *Client) GetMarketIDContext(ctx context.Context, market string) (int, error) {
 	if v, ok := c.marketCache[normalize(market)]; ok {
 		return v, nil
 	}
 
 	// If not found, try update first
-	if err := c.updateMarketCache(); err != nil {
+	if err := c.updateMarketCache(ctx); err != nil {
 		return 0, err
 	}
 
@@ -699,8 +981,8 @@ func (c *Client) GetMarketID(market string) (int, error) {
 	return 0, ErrTradePairNotFound
 }
 
-func (c *Client) updateMarketCache() error {
-	mrkts, err := c.GetTradePairs()
+func (c *Client) updateMarketCache(ctx context.Context) error {
+	mrkts, err := c.GetTradePairsContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -715,7 +997,12 @@ func (c *Client) updateMarketCache() error {
 
 // CancelAll cancels all executed orders on account
 func (c *Client) CancelAll() ([]int, error) {
-	orderIDs, err := c.CancelTrade(All, nil, nil)
+	return c.CancelAllContext(context.Background())
+}
+
+// CancelAllContext is CancelAll with a context
+func (c *Client) CancelAllContext(ctx context.Context) ([]int, error) {
+	orderIDs, err := c.CancelTradeContext(ctx, All, nil, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -724,7 +1011,12 @@ func (c *Client) CancelAll() ([]int, error) {
 
 // CancelMarket cancel all orders opened in given market
 func (c *Client) CancelMarket(symbol string) ([]int, error) {
-	orderIDs, err := c.CancelTrade(ByMarket, &symbol, nil)
+	return c.CancelMarketContext(context.Background(), symbol)
+}
+
+// CancelMarketContext is CancelMarket with a context
+func (c *Client) CancelMarketContext(ctx context.Context, symbol string) ([]int, error) {
+	orderIDs, err := c.CancelTradeContext(ctx, ByMarket, &symbol, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -733,7 +1025,12 @@ func (c *Client) CancelMarket(symbol string) ([]int, error) {
 
 // Buy places buy order
 func (c *Client) Buy(symbol string, rate, amount decimal.Decimal) (int, error) {
-	orderID, err := c.SubmitTrade(symbol, Buy, rate, amount)
+	return c.BuyContext(context.Background(), symbol, rate, amount)
+}
+
+// BuyContext is Buy with a context
+func (c *Client) BuyContext(ctx context.Context, symbol string, rate, amount decimal.Decimal) (int, error) {
+	orderID, err := c.SubmitTradeContext(ctx, symbol, Buy, rate, amount)
 	if err != nil {
 		return 0, err
 	}
@@ -743,7 +1040,12 @@ func (c *Client) Buy(symbol string, rate, amount decimal.Decimal) (int, error) {
 
 // Sell places sell order
 func (c *Client) Sell(symbol string, rate, amount decimal.Decimal) (int, error) {
-	orderID, err := c.SubmitTrade(symbol, Sell, rate, amount)
+	return c.SellContext(context.Background(), symbol, rate, amount)
+}
+
+// SellContext is Sell with a context
+func (c *Client) SellContext(ctx context.Context, symbol string, rate, amount decimal.Decimal) (int, error) {
+	orderID, err := c.SubmitTradeContext(ctx, symbol, Sell, rate, amount)
 	if err != nil {
 		return 0, err
 	}
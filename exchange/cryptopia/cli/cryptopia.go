@@ -75,6 +75,48 @@ get_market returns all Market info by given baseMarket
 				handleResult(marketsResult, err)
 			},
 		},
+		"get_market_impact": {
+			Use:   "get_market_impact",
+			Short: "get_market_impact prints the cost and slippage of buying and selling the given sizes",
+			Long: `
+get_market_impact prints the VWAP, worst price and slippage in basis points versus the mid price
+and the best quote of buying and selling each of the given sizes against the current orderbook.
+	Params:
+		market - the market symbol of the trade e.g. 'SKY/BTC'
+		size[] - list of sizes, in the market's first coin
+`,
+			Example: "cryptopia get_market_impact <market> <size> <size> <size>",
+			Args:    cobra.MinimumNArgs(2),
+			Run: func(cmd *cobra.Command, args []string) {
+				var sizes []decimal.Decimal
+				for _, arg := range args[1:] {
+					size, err := decimal.NewFromString(arg)
+					if err != nil {
+						printErrorWithExit(err)
+						return
+					}
+					sizes = append(sizes, size)
+				}
+				pair, err := cryptopia.DecodePair(args[0])
+				if err != nil {
+					printErrorWithExit(err)
+					return
+				}
+				orderbook, err := cryptopia.NewAdapter(client).GetOrderbook(pair)
+				if err != nil {
+					printErrorWithExit(err)
+					return
+				}
+				curve, err := orderbook.ImpactCurve(sizes)
+				if err != nil {
+					printErrorWithExit(err)
+					return
+				}
+				if err := curve.WriteTable(os.Stdout); err != nil {
+					printErrorWithExit(err)
+				}
+			},
+		},
 		"get_market": {
 			Use:   "get_market",
 			Short: "get_market returns market with given label",
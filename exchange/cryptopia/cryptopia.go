@@ -123,6 +123,21 @@ type MarketHistory struct {
 	Timestamp   int             `json:"Timestamp"`
 }
 
+// Balance represents the balance of a single currency
+type Balance struct {
+	CurrencyID      int             `json:"CurrencyId"`
+	Symbol          string          `json:"Symbol"`
+	Total           decimal.Decimal `json:"Total"`
+	Available       decimal.Decimal `json:"Available"`
+	Unconfirmed     decimal.Decimal `json:"Unconfirmed"`
+	HeldForTrades   decimal.Decimal `json:"HeldForTrades"`
+	PendingWithdraw decimal.Decimal `json:"PendingWithdraw"`
+	Address         string          `json:"Address"`
+	BaseAddress     string          `json:"BaseAddress"`
+	Status          string          `json:"Status"`
+	StatusMessage   string          `json:"StatusMessage"`
+}
+
 // DepositAddress is a representation of deposit address for single currency
 type DepositAddress struct {
 	Currency    string `json:"Currency"`
@@ -158,8 +173,10 @@ const (
 
 // Order represents single opened or closed order
 // If order was closed, fee > 0 && remaining == 0
+// Rows of the trade history are trades: TradeID identifies the trade and OrderID, if present, its order.
 type Order struct {
 	OrderID     int
+	TradeID     int
 	TradePairID int
 	Market      string
 	Type        string
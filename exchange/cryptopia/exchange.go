@@ -0,0 +1,420 @@
+package cryptopia
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/skycoin/exchange-api/exchange"
+)
+
+// Adapter implements exchange.Exchange on top of a Client.
+// Pairs are encoded with Codec as Cryptopia market labels, e.g. "SKY/BTC".
+// Orders filled as they are placed return exchange.ErrFilledInstantly, since Cryptopia doesn't assign them an ID.
+type Adapter struct {
+	Client *Client
+
+	mu sync.Mutex
+	// placed holds the orders placed by the Adapter, whose amounts the trade history doesn't report
+	placed map[exchange.OrderID]exchange.Order
+}
+
+// NewAdapter creates an Adapter for the given Client
+func NewAdapter(c *Client) *Adapter {
+	return &Adapter{
+		Client: c,
+		placed: make(map[exchange.OrderID]exchange.Order),
+	}
+}
+
+// Name returns the exchange's name
+func (a *Adapter) Name() string {
+	return "cryptopia"
+}
+
+// Codec returns Codec
+func (a *Adapter) Codec() exchange.PairCodec {
+	return Codec
+}
+
+// GetOrderbook returns the current orderbook of a market
+func (a *Adapter) GetOrderbook(pair exchange.Pair) (*exchange.MarketRecord, error) {
+	symbol := EncodePair(pair)
+	orders, err := a.Client.GetMarketOrders(symbol, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertMarketOrders(symbol, time.Now().UTC(), *orders), nil
+}
+
+// GetTicker returns pricing data of a market
+func (a *Adapter) GetTicker(pair exchange.Pair) (*exchange.Ticker, error) {
+	symbol := EncodePair(pair)
+	market, err := a.Client.GetMarket(symbol, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &exchange.Ticker{
+		Symbol:    symbol,
+		Timestamp: time.Now().UTC(),
+		Last:      market.LastPrice,
+		High:      market.High,
+		Low:       market.Low,
+		Bid:       market.BidPrice,
+		Ask:       market.AskPrice,
+		Volume:    market.Volume,
+	}, nil
+}
+
+// GetBalances returns the account's balances of all currencies
+func (a *Adapter) GetBalances() (exchange.Balances, error) {
+	balances, err := a.Client.GetBalances()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(exchange.Balances, len(balances))
+	for _, b := range balances {
+		currency := strings.ToUpper(b.Symbol)
+		result[currency] = exchange.Balance{
+			Currency:  currency,
+			Total:     b.Total,
+			Available: b.Available,
+			Frozen:    b.HeldForTrades,
+		}
+	}
+
+	return result, nil
+}
+
+// LimitBuy places a limit buy order
+func (a *Adapter) LimitBuy(pair exchange.Pair, price, volume decimal.Decimal) (exchange.OrderID, error) {
+	symbol := EncodePair(pair)
+	orderID, err := a.Client.Buy(symbol, price, volume)
+	return a.place(symbol, exchange.ActionBuy, price, volume, orderID, err)
+}
+
+// LimitSell places a limit sell order
+func (a *Adapter) LimitSell(pair exchange.Pair, price, volume decimal.Decimal) (exchange.OrderID, error) {
+	symbol := EncodePair(pair)
+	orderID, err := a.Client.Sell(symbol, price, volume)
+	return a.place(symbol, exchange.ActionSell, price, volume, orderID, err)
+}
+
+func (a *Adapter) place(symbol string, action exchange.Action, price, volume decimal.Decimal, orderID int, err error) (exchange.OrderID, error) {
+	if err != nil {
+		return 0, err
+	}
+	if orderID == InstantOrderID {
+		return 0, exchange.ErrFilledInstantly
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.placed == nil {
+		a.placed = make(map[exchange.OrderID]exchange.Order)
+	}
+	a.placed[exchange.OrderID(orderID)] = exchange.Order{
+		OrderID: exchange.OrderID(orderID),
+		Symbol:  symbol,
+		Action:  action,
+		Price:   price,
+		Volume:  volume,
+	}
+
+	return exchange.OrderID(orderID), nil
+}
+
+// MarketBuy emulates a market buy order, since Cryptopia only supports limit orders.
+// It places a limit buy order priced at the most expensive ask needed to spend amount.
+func (a *Adapter) MarketBuy(pair exchange.Pair, amount decimal.Decimal) (exchange.OrderID, error) {
+	if !amount.GreaterThan(decimal.Zero) {
+		return 0, exchange.ErrNegativeAmount
+	}
+
+	orderbook, err := a.GetOrderbook(pair)
+	if err != nil {
+		return 0, err
+	}
+
+	fills, err := orderbook.SpendItAll(amount)
+	if err != nil {
+		return 0, err
+	}
+
+	price := fills[len(fills)-1].Price
+	return a.LimitBuy(pair, price, fills.Volume())
+}
+
+// MarketSell emulates a market sell order, since Cryptopia only supports limit orders.
+// It places a limit sell order priced at the cheapest bid needed to sell volume.
+func (a *Adapter) MarketSell(pair exchange.Pair, volume decimal.Decimal) (exchange.OrderID, error) {
+	if !volume.GreaterThan(decimal.Zero) {
+		return 0, exchange.ErrNegativeAmount
+	}
+
+	orderbook, err := a.GetOrderbook(pair)
+	if err != nil {
+		return 0, err
+	}
+
+	plan, err := orderbook.SellItAll(volume, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	price := plan.Fills[len(plan.Fills)-1].Price
+	return a.LimitSell(pair, price, volume)
+}
+
+// CancelOrder cancels an order
+func (a *Adapter) CancelOrder(pair exchange.Pair, orderID exchange.OrderID) error {
+	id := int(orderID)
+	_, err := a.Client.CancelTrade(ByOrderID, nil, &id)
+	return err
+}
+
+// GetOpenOrders returns all open orders of a market
+func (a *Adapter) GetOpenOrders(pair exchange.Pair) ([]exchange.Order, error) {
+	symbol := EncodePair(pair)
+	orders, err := a.Client.GetOpenOrders(&symbol, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]exchange.Order, len(orders))
+	for i, o := range orders {
+		result[i] = convertOrder(o)
+	}
+
+	return result, nil
+}
+
+// GetOrderStatus returns the latest state of an order.
+// Cryptopia does not report a single order, so the open orders are searched first, then the trades of the
+// trade history are summed up. An order no longer open is completed if its trades add up to its amount,
+// which is only known for orders placed by this Adapter. Otherwise it is partial, since it may have been
+// cancelled after a partial fill or the history may lag, or unknown if its amount is unknown.
+// Orders which are no longer open and not found in the trade history return exchange.ErrOrderNotFound.
+func (a *Adapter) GetOrderStatus(pair exchange.Pair, orderID exchange.OrderID) (*exchange.Order, error) {
+	symbol := EncodePair(pair)
+	open, err := a.Client.GetOpenOrders(&symbol, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, o := range open {
+		if o.OrderID == int(orderID) {
+			order := convertOrder(o)
+			return &order, nil
+		}
+	}
+
+	history, err := a.Client.GetTradeHistory(&symbol, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	placed, ok := a.placed[orderID]
+	a.mu.Unlock()
+
+	order := sumTrades(orderID, history)
+	if order == nil {
+		return nil, exchange.ErrOrderNotFound
+	}
+
+	order.Status = exchange.StatusUnknown
+	if ok {
+		order.Price = placed.Price
+		order.Volume = placed.Volume
+		order.Status = exchange.StatusPartial
+		if !order.Filled.LessThan(placed.Volume) {
+			order.Status = exchange.StatusCompleted
+		}
+	}
+	if order.Status != exchange.StatusCompleted {
+		order.Completed = time.Time{}
+	}
+
+	return order, nil
+}
+
+// sumTrades merges the trades of an order from the trade history, or returns nil if it has none.
+// Volume is the filled volume, AvgPrice the volume weighted rate and Completed the time of the last trade.
+func sumTrades(orderID exchange.OrderID, history []Order) *exchange.Order {
+	var order *exchange.Order
+	total := decimal.Zero
+	for _, o := range history {
+		if o.OrderID == 0 || exchange.OrderID(o.OrderID) != orderID {
+			continue
+		}
+
+		if order == nil {
+			converted := convertOrder(o)
+			converted.Volume = decimal.Zero
+			converted.Filled = decimal.Zero
+			converted.Fee = decimal.Zero
+			order = &converted
+		}
+
+		order.Filled = order.Filled.Add(o.Amount)
+		order.Fee = order.Fee.Add(o.Fee)
+		total = total.Add(o.Rate.Mul(o.Amount))
+		if o.Timestamp.Before(order.Created) {
+			order.Created = o.Timestamp
+		}
+		if o.Timestamp.After(order.Completed) {
+			order.Completed = o.Timestamp
+		}
+	}
+
+	if order == nil {
+		return nil
+	}
+
+	order.Volume = order.Filled
+	if order.Filled.GreaterThan(decimal.Zero) {
+		order.AvgPrice = total.Div(order.Filled)
+	}
+
+	return order
+}
+
+// pricePrecision is the number of decimals Cryptopia accepts for prices and volumes
+const pricePrecision = 8
+
+// GetRules returns the trading rules of all markets, for use with an exchange.RulesRegistry.
+// Markets whose labels can't be decoded are skipped.
+func (a *Adapter) GetRules() (map[exchange.Pair]exchange.Rules, error) {
+	pairs, err := a.Client.GetTradePairs()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[exchange.Pair]exchange.Rules, len(pairs))
+	for _, p := range pairs {
+		pair, err := DecodePair(p.Label)
+		if err != nil {
+			continue
+		}
+		result[pair] = convertRules(p)
+	}
+
+	return result, nil
+}
+
+func convertRules(p TradepairInfo) exchange.Rules {
+	return exchange.Rules{
+		PricePrecision:  exchange.Precision(pricePrecision),
+		VolumePrecision: exchange.Precision(pricePrecision),
+		MinVolume:       p.MinimumTrade,
+		MaxVolume:       p.MaximumTrade,
+		MinCost:         p.MinimumBaseTrade,
+		MaxCost:         p.MaximumBaseTrade,
+		MinPrice:        p.MinimumPrice,
+		MaxPrice:        p.MaximumPrice,
+		// TradeFee is a percentage
+		Fee: p.TradeFee.Div(decimal.New(100, 0)),
+	}
+}
+
+type pairCodec struct{}
+
+// EncodePair encodes a Pair as a market label
+func (pairCodec) EncodePair(p exchange.Pair) string {
+	return p.String()
+}
+
+// DecodePair decodes a market label, also accepting "_" as separator like the Client does
+func (pairCodec) DecodePair(s string) (exchange.Pair, error) {
+	return exchange.ParsePair(normalize(s))
+}
+
+// Codec converts exchange.Pairs to and from market labels, e.g. "SKY/BTC" is SKY priced in BTC
+var Codec exchange.PairCodec = pairCodec{}
+
+// EncodePair returns the market label of an exchange.Pair
+func EncodePair(p exchange.Pair) string {
+	return Codec.EncodePair(p)
+}
+
+// DecodePair returns the exchange.Pair of a market label
+func DecodePair(label string) (exchange.Pair, error) {
+	return Codec.DecodePair(label)
+}
+
+func convertOrder(o Order) exchange.Order {
+	action := exchange.ActionBuy
+	if o.Type == Sell {
+		action = exchange.ActionSell
+	}
+
+	filled := o.Amount.Sub(o.Remaining)
+	status := exchange.StatusOpened
+	if filled.GreaterThan(decimal.Zero) {
+		status = exchange.StatusPartial
+	}
+
+	return exchange.Order{
+		OrderID:  exchange.OrderID(o.OrderID),
+		Symbol:   o.Market,
+		Action:   action,
+		Status:   status,
+		Price:    o.Rate,
+		Volume:   o.Amount,
+		Filled:   filled,
+		AvgPrice: o.Rate,
+		Fee:      o.Fee,
+		Created:  o.Timestamp,
+	}
+}
+
+// ConvertMarketHistory converts the market history, which is ordered from the newest trade, to exchange.Trades,
+// oldest first
+func ConvertMarketHistory(history []MarketHistory) []exchange.Trade {
+	result := make([]exchange.Trade, len(history))
+	for i, h := range history {
+		action := exchange.ActionBuy
+		if strings.EqualFold(h.Type, Sell) {
+			action = exchange.ActionSell
+		}
+
+		result[len(history)-1-i] = exchange.Trade{
+			Timestamp: time.Unix(int64(h.Timestamp), 0).UTC(),
+			Price:     h.Price,
+			Volume:    h.Amount,
+			Action:    action,
+		}
+	}
+	return result
+}
+
+func convertMarketOrders(symbol string, ts time.Time, orders MarketOrders) *exchange.MarketRecord {
+	record := exchange.MarketRecord{
+		Timestamp: ts,
+		Symbol:    symbol,
+		Bids:      make([]exchange.MarketOrder, len(orders.Buy)),
+		Asks:      make([]exchange.MarketOrder, len(orders.Sell)),
+	}
+
+	for i, o := range orders.Buy {
+		record.Bids[i] = exchange.MarketOrder{
+			Price:  o.Price,
+			Volume: o.Volume,
+		}
+	}
+
+	for i, o := range orders.Sell {
+		record.Asks[i] = exchange.MarketOrder{
+			Price:  o.Price,
+			Volume: o.Volume,
+		}
+	}
+
+	return &record
+}
@@ -0,0 +1,202 @@
+package cryptopia
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/exchange-api/exchange"
+)
+
+var skyBtc = exchange.NewPair(exchange.SKY, exchange.BTC)
+
+func TestAdapterImplementsExchange(t *testing.T) {
+	require.Implements(t, (*exchange.Exchange)(nil), NewAdapter(NewAPIClient("", "")))
+}
+
+func TestConvertOrder(t *testing.T) {
+	ts := time.Date(2018, 3, 20, 10, 0, 0, 0, time.UTC)
+	o := Order{
+		OrderID:   42,
+		Market:    "SKY/BTC",
+		Type:      Buy,
+		Rate:      decimal.New(1, -3),
+		Amount:    decimal.New(10, 0),
+		Remaining: decimal.New(10, 0),
+		Timestamp: ts,
+	}
+
+	order := convertOrder(o)
+	require.Equal(t, exchange.OrderID(42), order.OrderID)
+	require.Equal(t, "SKY/BTC", order.Symbol)
+	require.Equal(t, exchange.ActionBuy, order.Action)
+	require.Equal(t, exchange.StatusOpened, order.Status)
+	require.True(t, order.Filled.Equal(decimal.Zero))
+	require.Equal(t, ts, order.Created)
+
+	o.Type = Sell
+	o.Remaining = decimal.New(4, 0)
+	order = convertOrder(o)
+	require.Equal(t, exchange.ActionSell, order.Action)
+	require.Equal(t, exchange.StatusPartial, order.Status)
+	require.True(t, order.Filled.Equal(decimal.New(6, 0)))
+}
+
+func TestConvertMarketOrders(t *testing.T) {
+	ts := time.Now()
+	orders := MarketOrders{
+		Buy: []MarketOrder{
+			{Price: decimal.New(2, 0), Volume: decimal.New(7, 0)},
+		},
+		Sell: []MarketOrder{
+			{Price: decimal.New(4, 0), Volume: decimal.New(3, 0)},
+			{Price: decimal.New(5, 0), Volume: decimal.New(9, 0)},
+		},
+	}
+
+	record := convertMarketOrders("SKY/BTC", ts, orders)
+	require.Equal(t, "SKY/BTC", record.Symbol)
+	require.Equal(t, ts, record.Timestamp)
+	require.Len(t, record.Bids, 1)
+	require.Len(t, record.Asks, 2)
+	require.True(t, record.CheapestAsk().Price.Equal(decimal.New(4, 0)))
+}
+
+func TestConvertMarketHistory(t *testing.T) {
+	start := time.Date(2018, 3, 20, 10, 0, 0, 0, time.UTC)
+	trades := ConvertMarketHistory([]MarketHistory{
+		{Type: Sell, Price: decimal.New(3, 0), Amount: decimal.New(1, 0), Timestamp: int(start.Add(time.Minute).Unix())},
+		{Type: Buy, Price: decimal.New(4, 0), Amount: decimal.New(2, 0), Timestamp: int(start.Unix())},
+	})
+
+	require.Len(t, trades, 2)
+	require.Equal(t, start, trades[0].Timestamp)
+	require.Equal(t, exchange.ActionBuy, trades[0].Action)
+	require.True(t, trades[0].Volume.Equal(decimal.New(2, 0)))
+	require.Equal(t, exchange.ActionSell, trades[1].Action)
+	require.True(t, trades[1].Price.Equal(decimal.New(3, 0)))
+}
+
+func TestWithdrawFees(t *testing.T) {
+	fees := withdrawFees([]CurrencyInfo{
+		{Symbol: "BTC", WithdrawFee: decimal.NewFromFloat(0.002)},
+		{Symbol: "sky", WithdrawFee: decimal.NewFromFloat(1.0)},
+	})
+
+	require.Len(t, fees, 2)
+	require.True(t, fees["BTC"].Equal(decimal.NewFromFloat(0.002)))
+	require.True(t, fees["SKY"].Equal(decimal.NewFromFloat(1.0)))
+}
+
+func TestPairCodec(t *testing.T) {
+	require.Equal(t, "SKY/BTC", EncodePair(skyBtc))
+
+	for _, s := range []string{"SKY/BTC", "sky_btc"} {
+		pair, err := DecodePair(s)
+		require.NoError(t, err)
+		require.Equal(t, skyBtc, pair)
+	}
+
+	_, err := DecodePair("SKYBTC")
+	require.Equal(t, exchange.ErrInvalidSymbol, err)
+}
+
+func TestConvertRules(t *testing.T) {
+	rules := convertRules(TradepairInfo{
+		Label:            "SKY/BTC",
+		TradeFee:         decimal.NewFromFloat(0.2),
+		MinimumTrade:     decimal.NewFromFloat(0.0001),
+		MaximumTrade:     decimal.NewFromFloat(100000000.0),
+		MinimumBaseTrade: decimal.NewFromFloat(0.0005),
+		MaximumBaseTrade: decimal.NewFromFloat(100000000.0),
+		MinimumPrice:     decimal.NewFromFloat(0.00000001),
+		MaximumPrice:     decimal.NewFromFloat(100000000.0),
+	})
+
+	require.Equal(t, int32(8), *rules.PricePrecision)
+	require.Equal(t, int32(8), *rules.VolumePrecision)
+	require.True(t, rules.MinVolume.Equal(decimal.NewFromFloat(0.0001)))
+	require.True(t, rules.MinCost.Equal(decimal.NewFromFloat(0.0005)))
+	require.True(t, rules.MinPrice.Equal(decimal.NewFromFloat(0.00000001)))
+	require.True(t, rules.MaxPrice.Equal(decimal.NewFromFloat(100000000.0)))
+	require.True(t, rules.Fee.Equal(decimal.NewFromFloat(0.002)))
+}
+
+// newAdapterServer returns an Adapter of a test server replying to each endpoint with the given data
+func newAdapterServer(t *testing.T, data map[string]string) (*Adapter, func()) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Success":true,"Message":null,"Data":` + data[path.Base(r.URL.Path)] + `}`)) // nolint: errcheck
+	}))
+
+	c, err := NewAPIClientWithOptions("key", "c2VjcmV0", Options{BaseURL: srv.URL + "/api"})
+	require.NoError(t, err)
+	c.marketCache = map[string]int{"SKY/BTC": 1}
+
+	return NewAdapter(c), srv.Close
+}
+
+func TestAdapterFilledInstantly(t *testing.T) {
+	a, closeServer := newAdapterServer(t, map[string]string{
+		"submittrade": `{"OrderId":null,"FilledOrders":[7]}`,
+	})
+	defer closeServer()
+
+	orderID, err := a.LimitBuy(skyBtc, decimal.New(1, -3), decimal.New(10, 0))
+	require.Equal(t, exchange.ErrFilledInstantly, err)
+	require.Equal(t, exchange.OrderID(0), orderID)
+}
+
+func TestAdapterGetOrderStatus(t *testing.T) {
+	// trades 1 and 3 fill order 42, trade 2 belongs to another order, trade 42 to none
+	history := `[
+		{"TradeId":1,"OrderId":42,"TradePairId":1,"Market":"SKY/BTC","Type":"Buy","Rate":0.001,"Amount":4,"Total":0.004,"Fee":0.00001,"TimeStamp":"2018-03-20T10:00:00.0000000"},
+		{"TradeId":2,"OrderId":43,"TradePairId":1,"Market":"SKY/BTC","Type":"Buy","Rate":0.001,"Amount":10,"Total":0.01,"Fee":0.00002,"TimeStamp":"2018-03-20T10:01:00.0000000"},
+		{"TradeId":3,"OrderId":42,"TradePairId":1,"Market":"SKY/BTC","Type":"Buy","Rate":0.002,"Amount":6,"Total":0.012,"Fee":0.00003,"TimeStamp":"2018-03-20T10:02:00.0000000"},
+		{"TradeId":42,"TradePairId":1,"Market":"SKY/BTC","Type":"Buy","Rate":0.001,"Amount":1,"Total":0.001,"Fee":0,"TimeStamp":"2018-03-20T10:03:00.0000000"}
+	]`
+	data := map[string]string{
+		"getopenorders":   `[]`,
+		"gettradehistory": history,
+		"submittrade":     `{"OrderId":42,"FilledOrders":[]}`,
+	}
+	a, closeServer := newAdapterServer(t, data)
+	defer closeServer()
+
+	// the amount of orders placed elsewhere is unknown
+	o, err := a.GetOrderStatus(skyBtc, 42)
+	require.NoError(t, err)
+	require.Equal(t, exchange.StatusUnknown, o.Status)
+	require.True(t, o.Filled.Equal(decimal.New(10, 0)))
+	require.True(t, o.Completed.IsZero())
+
+	orderID, err := a.LimitBuy(skyBtc, decimal.New(2, -3), decimal.New(10, 0))
+	require.NoError(t, err)
+	require.Equal(t, exchange.OrderID(42), orderID)
+
+	o, err = a.GetOrderStatus(skyBtc, 42)
+	require.NoError(t, err)
+	require.Equal(t, exchange.StatusCompleted, o.Status)
+	require.True(t, o.Volume.Equal(decimal.New(10, 0)))
+	require.True(t, o.Filled.Equal(decimal.New(10, 0)))
+	require.True(t, o.AvgPrice.Equal(decimal.New(16, -4)))
+	require.True(t, o.Fee.Equal(decimal.New(4, -5)))
+	require.Equal(t, time.Date(2018, 3, 20, 10, 2, 0, 0, time.UTC), o.Completed)
+
+	// an order no longer open whose trades don't add up to its amount
+	data["submittrade"] = `{"OrderId":43,"FilledOrders":[]}`
+	_, err = a.LimitBuy(skyBtc, decimal.New(1, -3), decimal.New(20, 0))
+	require.NoError(t, err)
+	o, err = a.GetOrderStatus(skyBtc, 43)
+	require.NoError(t, err)
+	require.Equal(t, exchange.StatusPartial, o.Status)
+	require.True(t, o.Filled.Equal(decimal.New(10, 0)))
+
+	// trade IDs are not order IDs
+	_, err = a.GetOrderStatus(skyBtc, 1)
+	require.Equal(t, exchange.ErrOrderNotFound, err)
+}
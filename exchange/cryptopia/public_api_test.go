@@ -1,7 +1,17 @@
 package cryptopia
 
 import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/skycoin/exchange-api/exchange/internal/httpclient"
 )
 
 func TestRequestSignature(t *testing.T) {
@@ -129,3 +139,128 @@ func TestGetMarketOrderGroups(t *testing.T) {
 		t.Fatal("count of groups should be 2")
 	}
 }
+
+// newTestServer creates a Client of a test server which replies with the given statuses, the returned function closes the server
+func newTestServer(t *testing.T, status []int, calls *int) (*Client, func()) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status[*calls])
+		*calls++
+		w.Write([]byte(`{"Success":true,"Message":null,"Data":[]}`)) // nolint: errcheck
+	}))
+
+	c, err := NewAPIClientWithOptions("key", "c2VjcmV0", Options{BaseURL: srv.URL + "/api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Retry.BaseDelay = time.Millisecond
+	c.Retry.RateLimitDelay = time.Millisecond
+
+	return c, srv.Close
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestNewAPIClientWithOptions(t *testing.T) {
+	c := NewAPIClient("", "")
+	if got := c.BaseURL.String(); got != "https://www.cryptopia.co.nz/api/" {
+		t.Errorf("BaseURL %s", got)
+	}
+	if c.HTTPClient.Timeout != httpclient.Timeout {
+		t.Errorf("Timeout %v", c.HTTPClient.Timeout)
+	}
+
+	if _, err := NewAPIClientWithOptions("", "", Options{BaseURL: "api"}); err == nil {
+		t.Error("relative base URL accepted")
+	}
+
+	var requests []*http.Request
+	c, err := NewAPIClientWithOptions("", "", Options{
+		BaseURL: "http://localhost:8080/cryptopia",
+		Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			requests = append(requests, r)
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"Success":true,"Message":null,"Data":[]}`)),
+			}, nil
+		}),
+		Timeout:   time.Second,
+		UserAgent: "exchange-api-test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.HTTPClient.Timeout != time.Second {
+		t.Errorf("Timeout %v", c.HTTPClient.Timeout)
+	}
+
+	if _, err := c.GetCurrencies(); err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 1 {
+		t.Fatalf("%d requests", len(requests))
+	}
+	if got := requests[0].URL.String(); got != "http://localhost:8080/cryptopia/getcurrencies" {
+		t.Errorf("URL %s", got)
+	}
+	if got := requests[0].Header.Get("User-Agent"); got != "exchange-api-test" {
+		t.Errorf("User-Agent %s", got)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	cases := []struct {
+		name     string
+		endpoint string
+		status   []int
+		calls    int
+		err      bool
+	}{
+		{"get server error", "getcurrencies", []int{502, 503, 200}, 3, false},
+		{"get rate limited", "getcurrencies", []int{429, 200}, 2, false},
+		{"post rate limited", "getbalance", []int{429, 200}, 2, false},
+		{"trade server error", "submittrade", []int{503, 200}, 1, true},
+		{"trade rate limited", "submittrade", []int{429, 200}, 2, false},
+		{"withdraw server error", "submitwithdraw", []int{500, 200}, 1, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			c, done := newTestServer(t, tc.status, &calls)
+			defer done()
+
+			var err error
+			if tc.endpoint == "getcurrencies" {
+				_, err = c.get(context.Background(), tc.endpoint, "")
+			} else {
+				_, err = c.post(context.Background(), tc.endpoint, map[string]interface{}{})
+			}
+
+			if tc.err != (err != nil) {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if calls != tc.calls {
+				t.Errorf("%d requests, want %d", calls, tc.calls)
+			}
+		})
+	}
+}
+
+func TestContext_Canceled(t *testing.T) {
+	c, done := newTestServer(t, []int{http.StatusOK}, new(int))
+	defer done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := c.GetCurrenciesContext(ctx); err != context.Canceled {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if _, err := c.SubmitTradeContext(ctx, "SKY/BTC", Buy, decimal.New(1, -3), decimal.New(1, 0)); err != context.Canceled {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
@@ -13,27 +13,13 @@ import (
 // balance represents balance of all avalible currencies
 type balance map[string]decimal.Decimal
 
-type currency struct {
-	CurrencyID      int             `json:"CurrencyId"`
-	Symbol          string          `json:"Symbol"`
-	Total           decimal.Decimal `json:"Total"`
-	Available       decimal.Decimal `json:"Available"`
-	Unconfirmed     decimal.Decimal `json:"Unconfirmed"`
-	HeldForTrades   decimal.Decimal `json:"HeldForTrades"`
-	PendingWithdraw decimal.Decimal `json:"PendingWithdraw"`
-	Address         string          `json:"Address"`
-	BaseAddress     string          `json:"BaseAddress"`
-	Status          string          `json:"Status"`
-	StatusMessage   string          `json:"StatusMessage"`
-}
-
 // UnmarshalJSON implements json.Unmarshaler interface
 func (r *balance) UnmarshalJSON(b []byte) error {
 	if r == nil {
 		(*r) = make(map[string]decimal.Decimal)
 	}
 
-	var tmp = make([]currency, 0)
+	var tmp = make([]Balance, 0)
 	if err := json.Unmarshal(b, &tmp); err != nil {
 		return err
 	}
@@ -74,9 +60,9 @@ type orderJSON struct {
 // UnmarshalJSON implements an json.Unmarshaler interface
 func (order *Order) UnmarshalJSON(b []byte) error {
 	var (
-		tmp     = orderJSON{}
-		orderID int
-		ts      time.Time
+		tmp              = orderJSON{}
+		orderID, tradeID int
+		ts               time.Time
 	)
 
 	err := json.Unmarshal(b, &tmp)
@@ -88,8 +74,9 @@ func (order *Order) UnmarshalJSON(b []byte) error {
 	}
 	if tmp.OrderID != nil {
 		orderID = *tmp.OrderID
-	} else {
-		orderID = *tmp.TradeID
+	}
+	if tmp.TradeID != nil {
+		tradeID = *tmp.TradeID
 	}
 	ts, err = time.Parse("2006-01-02T15:04:05.0000000", tmp.Timestamp)
 	if err != nil {
@@ -98,6 +85,7 @@ func (order *Order) UnmarshalJSON(b []byte) error {
 
 	*order = Order{
 		OrderID:     orderID,
+		TradeID:     tradeID,
 		TradePairID: tmp.TradePairID,
 		Market:      tmp.Market,
 		Type:        tmp.Type,
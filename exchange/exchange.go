@@ -0,0 +1,138 @@
+// Package exchange provides common types and interfaces shared by the exchange API wrappers
+package exchange
+
+import (
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Action is an order's direction, buy or sell
+type Action string
+
+const (
+	// ActionBuy is a buy order
+	ActionBuy Action = "buy"
+	// ActionSell is a sell order
+	ActionSell Action = "sell"
+)
+
+// OrderStatus is an order's status, common to all exchanges
+type OrderStatus string
+
+const (
+	// StatusPending order was accepted but is not on the orderbook yet
+	StatusPending OrderStatus = "pending"
+	// StatusOpened order is on the orderbook and nothing has been filled
+	StatusOpened OrderStatus = "opened"
+	// StatusPartial order is on the orderbook and has been partially filled
+	StatusPartial OrderStatus = "partial"
+	// StatusCompleted order was completely filled
+	StatusCompleted OrderStatus = "completed"
+	// StatusCancelled order was cancelled or expired
+	StatusCancelled OrderStatus = "cancelled"
+	// StatusError order was rejected by the exchange
+	StatusError OrderStatus = "error"
+	// StatusUnknown order status could not be determined
+	StatusUnknown OrderStatus = "unknown"
+)
+
+// Open returns true if the order can still be filled
+func (s OrderStatus) Open() bool {
+	switch s {
+	case StatusPending, StatusOpened, StatusPartial:
+		return true
+	default:
+		return false
+	}
+}
+
+// OrderID is an order's ID on an exchange
+type OrderID int
+
+// Order represents an order placed on an exchange
+type Order struct {
+	OrderID   OrderID         `json:"order_id"`
+	Symbol    string          `json:"symbol"`
+	Action    Action          `json:"action"`
+	Status    OrderStatus     `json:"status"`
+	Price     decimal.Decimal `json:"price"`
+	Volume    decimal.Decimal `json:"volume"`
+	Filled    decimal.Decimal `json:"filled"`
+	AvgPrice  decimal.Decimal `json:"avg_price"`
+	Fee       decimal.Decimal `json:"fee"`
+	Created   time.Time       `json:"created"`
+	Completed time.Time       `json:"completed"`
+}
+
+// Balance is the balance of a single currency held on an exchange
+type Balance struct {
+	Currency  string          `json:"currency"`
+	Total     decimal.Decimal `json:"total"`
+	Available decimal.Decimal `json:"available"`
+	Frozen    decimal.Decimal `json:"frozen"`
+}
+
+// Balances maps an upper case currency symbol to its Balance
+type Balances map[string]Balance
+
+// Ticker includes pricing data for a market
+type Ticker struct {
+	Symbol    string          `json:"symbol"`
+	Timestamp time.Time       `json:"timestamp"`
+	Last      decimal.Decimal `json:"last"`
+	High      decimal.Decimal `json:"high"`
+	Low       decimal.Decimal `json:"low"`
+	Bid       decimal.Decimal `json:"bid"`
+	Ask       decimal.Decimal `json:"ask"`
+	Volume    decimal.Decimal `json:"volume"`
+}
+
+// Trade is a public trade of a market
+type Trade struct {
+	Timestamp time.Time       `json:"timestamp"`
+	Price     decimal.Decimal `json:"price"`
+	Volume    decimal.Decimal `json:"volume"`
+	// Action is the side of the taker
+	Action Action `json:"action"`
+}
+
+var (
+	// ErrOrderNotFound is returned when an exchange has no record of an order
+	ErrOrderNotFound = errors.New("order not found")
+	// ErrFilledInstantly is returned instead of an OrderID when an order was completely filled as it was placed
+	// and the exchange did not assign it an ID. The order must not be queried or cancelled.
+	ErrFilledInstantly = errors.New("order filled instantly without an order ID")
+)
+
+// Exchange is the common interface implemented by the adapters of every exchange API wrapper.
+// Markets are given as Pairs, which each adapter encodes with its Codec, e.g. "BTC_SKY" for C2CX and "SKY/BTC"
+// for Cryptopia. The symbols of returned records, tickers and orders are in the exchange's own format.
+// Exchange-specific functionality remains available on the concrete clients.
+type Exchange interface {
+	// Name returns the exchange's name
+	Name() string
+	// Codec returns the codec of the exchange's symbols
+	Codec() PairCodec
+	// GetOrderbook returns the current orderbook of a market
+	GetOrderbook(pair Pair) (*MarketRecord, error)
+	// GetTicker returns pricing data of a market
+	GetTicker(pair Pair) (*Ticker, error)
+	// GetBalances returns the account's balances of all currencies
+	GetBalances() (Balances, error)
+	// LimitBuy places a limit buy order for volume coins at price
+	LimitBuy(pair Pair, price, volume decimal.Decimal) (OrderID, error)
+	// LimitSell places a limit sell order for volume coins at price
+	LimitSell(pair Pair, price, volume decimal.Decimal) (OrderID, error)
+	// MarketBuy places a market buy order spending amount of the quote currency
+	MarketBuy(pair Pair, amount decimal.Decimal) (OrderID, error)
+	// MarketSell places a market sell order selling volume coins
+	MarketSell(pair Pair, volume decimal.Decimal) (OrderID, error)
+	// CancelOrder cancels an order
+	CancelOrder(pair Pair, orderID OrderID) error
+	// GetOpenOrders returns all orders of a market which can still be filled
+	GetOpenOrders(pair Pair) ([]Order, error)
+	// GetOrderStatus returns the latest state of an order
+	GetOrderStatus(pair Pair, orderID OrderID) (*Order, error)
+}
@@ -0,0 +1,157 @@
+package exchange
+
+import (
+	"sync"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	skyBtc = NewPair(SKY, BTC)
+	ltcBtc = NewPair("LTC", BTC)
+)
+
+// fakeExchange is an in-memory Exchange used by the tests
+type fakeExchange struct {
+	sync.Mutex
+	name       string
+	codec      PairCodec
+	orderbooks map[string]MarketRecord
+	balances   Balances
+	orders     map[OrderID]Order
+	nextID     OrderID
+	err        error
+}
+
+func newFakeExchange() *fakeExchange {
+	return &fakeExchange{
+		codec:      CanonicalCodec,
+		orderbooks: make(map[string]MarketRecord),
+		balances:   make(Balances),
+		orders:     make(map[OrderID]Order),
+		nextID:     1,
+	}
+}
+
+func (e *fakeExchange) Name() string {
+	if e.name != "" {
+		return e.name
+	}
+	return "fake"
+}
+
+func (e *fakeExchange) Codec() PairCodec {
+	return e.codec
+}
+
+func (e *fakeExchange) GetOrderbook(pair Pair) (*MarketRecord, error) {
+	e.Lock()
+	defer e.Unlock()
+	if e.err != nil {
+		return nil, e.err
+	}
+	r, ok := e.orderbooks[e.codec.EncodePair(pair)]
+	if !ok {
+		return nil, ErrOrderNotFound
+	}
+	return &r, nil
+}
+
+func (e *fakeExchange) GetTicker(pair Pair) (*Ticker, error) {
+	return &Ticker{Symbol: e.codec.EncodePair(pair)}, nil
+}
+
+func (e *fakeExchange) GetBalances() (Balances, error) {
+	e.Lock()
+	defer e.Unlock()
+	return e.balances, e.err
+}
+
+func (e *fakeExchange) place(pair Pair, action Action, price, volume decimal.Decimal) (OrderID, error) {
+	e.Lock()
+	defer e.Unlock()
+	if e.err != nil {
+		return 0, e.err
+	}
+	id := e.nextID
+	e.nextID++
+	e.orders[id] = Order{
+		OrderID: id,
+		Symbol:  e.codec.EncodePair(pair),
+		Action:  action,
+		Status:  StatusOpened,
+		Price:   price,
+		Volume:  volume,
+	}
+	return id, nil
+}
+
+func (e *fakeExchange) LimitBuy(pair Pair, price, volume decimal.Decimal) (OrderID, error) {
+	return e.place(pair, ActionBuy, price, volume)
+}
+
+func (e *fakeExchange) LimitSell(pair Pair, price, volume decimal.Decimal) (OrderID, error) {
+	return e.place(pair, ActionSell, price, volume)
+}
+
+func (e *fakeExchange) MarketBuy(pair Pair, amount decimal.Decimal) (OrderID, error) {
+	return e.place(pair, ActionBuy, decimal.Zero, amount)
+}
+
+func (e *fakeExchange) MarketSell(pair Pair, volume decimal.Decimal) (OrderID, error) {
+	return e.place(pair, ActionSell, decimal.Zero, volume)
+}
+
+func (e *fakeExchange) CancelOrder(pair Pair, orderID OrderID) error {
+	e.Lock()
+	defer e.Unlock()
+	o, ok := e.orders[orderID]
+	if !ok {
+		return ErrOrderNotFound
+	}
+	o.Status = StatusCancelled
+	e.orders[orderID] = o
+	return nil
+}
+
+func (e *fakeExchange) GetOpenOrders(pair Pair) ([]Order, error) {
+	e.Lock()
+	defer e.Unlock()
+	if e.err != nil {
+		return nil, e.err
+	}
+	var result []Order
+	for _, o := range e.orders {
+		if o.Symbol == e.codec.EncodePair(pair) && o.Status.Open() {
+			result = append(result, o)
+		}
+	}
+	return result, nil
+}
+
+func (e *fakeExchange) GetOrderStatus(pair Pair, orderID OrderID) (*Order, error) {
+	e.Lock()
+	defer e.Unlock()
+	if e.err != nil {
+		return nil, e.err
+	}
+	o, ok := e.orders[orderID]
+	if !ok {
+		return nil, ErrOrderNotFound
+	}
+	return &o, nil
+}
+
+// fill fills volume of an order
+func (e *fakeExchange) fill(orderID OrderID, volume, fee decimal.Decimal) {
+	e.Lock()
+	defer e.Unlock()
+	o := e.orders[orderID]
+	o.Filled = o.Filled.Add(volume)
+	o.Fee = o.Fee.Add(fee)
+	o.Status = StatusPartial
+	if o.Filled.Equal(o.Volume) {
+		o.Status = StatusCompleted
+	}
+	e.orders[orderID] = o
+}
@@ -0,0 +1,134 @@
+package exchange
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// ErrEmptyOrderbook is returned when an orderbook has no bids or no asks
+	ErrEmptyOrderbook = errors.New("orderbook has no bids or no asks")
+
+	bpsFactor = decimal.New(10000, 0)
+	two       = decimal.New(2, 0)
+)
+
+// ImpactRow is the cost of trading one size on one side of an orderbook.
+// Slippage is expressed in basis points, positive values are a cost to the trader.
+type ImpactRow struct {
+	Action Action          `json:"action"`
+	Size   decimal.Decimal `json:"size"`
+	// Filled is less than Size if the orderbook is not deep enough
+	Filled           decimal.Decimal `json:"filled"`
+	Cost             decimal.Decimal `json:"cost"`
+	VWAP             decimal.Decimal `json:"vwap"`
+	WorstPrice       decimal.Decimal `json:"worst_price"`
+	SlippageMidBps   decimal.Decimal `json:"slippage_mid_bps"`
+	SlippageQuoteBps decimal.Decimal `json:"slippage_quote_bps"`
+}
+
+// Complete returns true if the whole size could be filled
+func (r ImpactRow) Complete() bool {
+	return r.Filled.Equal(r.Size)
+}
+
+// ImpactCurve is the market impact of buying and selling a list of sizes against an orderbook
+type ImpactCurve struct {
+	Symbol  string          `json:"symbol"`
+	BestBid decimal.Decimal `json:"best_bid"`
+	BestAsk decimal.Decimal `json:"best_ask"`
+	Mid     decimal.Decimal `json:"mid"`
+	Buys    []ImpactRow     `json:"buys"`
+	Sells   []ImpactRow     `json:"sells"`
+}
+
+// ImpactCurve computes the VWAP, worst price and slippage of buying and selling each of the sizes,
+// in coins, against the orderbook. Slippage is measured against the mid price and against the best
+// quote on the side being traded. The MarketRecord is not modified.
+func (r *MarketRecord) ImpactCurve(sizes []decimal.Decimal) (*ImpactCurve, error) {
+	bid := r.HighestBid()
+	ask := r.CheapestAsk()
+	if bid == nil || ask == nil {
+		return nil, ErrEmptyOrderbook
+	}
+
+	curve := &ImpactCurve{
+		Symbol:  r.Symbol,
+		BestBid: bid.Price,
+		BestAsk: ask.Price,
+		Mid:     bid.Price.Add(ask.Price).Div(two),
+	}
+
+	for _, size := range sizes {
+		plan, err := r.BuyVolume(size, nil)
+		if err != nil && err != ErrOrdersRanOut {
+			return nil, err
+		}
+		curve.Buys = append(curve.Buys, newImpactRow(ActionBuy, size, plan, curve.Mid, curve.BestAsk))
+
+		plan, err = r.SellItAll(size, nil)
+		if err != nil && err != ErrOrdersRanOut {
+			return nil, err
+		}
+		curve.Sells = append(curve.Sells, newImpactRow(ActionSell, size, plan, curve.Mid, curve.BestBid))
+	}
+
+	return curve, nil
+}
+
+func newImpactRow(action Action, size decimal.Decimal, plan *FillPlan, mid, quote decimal.Decimal) ImpactRow {
+	row := ImpactRow{
+		Action: action,
+		Size:   size,
+		Filled: plan.Volume,
+		Cost:   plan.Cost,
+		VWAP:   plan.AvgPrice,
+	}
+
+	if len(plan.Fills) == 0 {
+		return row
+	}
+
+	row.WorstPrice = plan.Fills[len(plan.Fills)-1].Price
+	row.SlippageMidBps = slippageBps(action, row.VWAP, mid)
+	row.SlippageQuoteBps = slippageBps(action, row.VWAP, quote)
+
+	return row
+}
+
+// slippageBps returns how much worse price is than reference, in basis points
+func slippageBps(action Action, price, reference decimal.Decimal) decimal.Decimal {
+	diff := price.Sub(reference)
+	if action == ActionSell {
+		diff = diff.Neg()
+	}
+	return diff.Div(reference).Mul(bpsFactor)
+}
+
+// WriteTable writes the curve as a human readable table
+func (c *ImpactCurve) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprintf(tw, "%s\tbid %s\task %s\tmid %s\t\n", c.Symbol, c.BestBid, c.BestAsk, c.Mid) // nolint: errcheck
+	fmt.Fprintln(tw, "side\tsize\tfilled\tcost\tvwap\tworst\tslip mid bps\tslip quote bps\t")   // nolint: errcheck
+
+	rows := append(append([]ImpactRow{}, c.Buys...), c.Sells...)
+	for _, r := range rows {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n", // nolint: errcheck
+			r.Action,
+			r.Size,
+			r.Filled,
+			r.Cost.StringFixed(8),
+			r.VWAP.StringFixed(8),
+			r.WorstPrice,
+			r.SlippageMidBps.StringFixed(2),
+			r.SlippageQuoteBps.StringFixed(2),
+		)
+	}
+
+	return tw.Flush()
+}
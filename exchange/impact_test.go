@@ -0,0 +1,68 @@
+package exchange
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImpactCurve(t *testing.T) {
+	marketRecord := newFakeMarketRecord()
+
+	curve, err := marketRecord.ImpactCurve([]decimal.Decimal{
+		decimal.NewFromFloat(1.0),
+		decimal.NewFromFloat(10.0),
+		decimal.NewFromFloat(20.0),
+	})
+	require.NoError(t, err)
+
+	require.True(t, curve.BestBid.Equal(decimal.NewFromFloat(3.0)))
+	require.True(t, curve.BestAsk.Equal(decimal.NewFromFloat(4.0)))
+	require.True(t, curve.Mid.Equal(decimal.NewFromFloat(3.5)))
+	require.Len(t, curve.Buys, 3)
+	require.Len(t, curve.Sells, 3)
+
+	// buying 1 SKY at the best ask only pays half the spread
+	buy := curve.Buys[0]
+	require.True(t, buy.Complete())
+	require.True(t, buy.VWAP.Equal(decimal.NewFromFloat(4.0)))
+	require.True(t, buy.SlippageQuoteBps.Equal(decimal.Zero))
+	require.Equal(t, "1428.57", buy.SlippageMidBps.StringFixed(2))
+
+	// 8 SKY at 4 and 2 SKY at 5
+	buy = curve.Buys[1]
+	require.True(t, buy.VWAP.Equal(decimal.NewFromFloat(4.2)))
+	require.True(t, buy.WorstPrice.Equal(decimal.NewFromFloat(5.0)))
+	require.True(t, buy.SlippageQuoteBps.Equal(decimal.NewFromFloat(500.0)))
+	require.True(t, buy.SlippageMidBps.Equal(decimal.NewFromFloat(2000.0)))
+
+	// 7 SKY at 3 and 3 SKY at 2
+	sell := curve.Sells[1]
+	require.Equal(t, ActionSell, sell.Action)
+	require.True(t, sell.VWAP.Equal(decimal.NewFromFloat(2.7)))
+	require.True(t, sell.WorstPrice.Equal(decimal.NewFromFloat(2.0)))
+	require.True(t, sell.SlippageQuoteBps.Equal(decimal.NewFromFloat(1000.0)))
+
+	// the book only holds 13 SKY of bids
+	sell = curve.Sells[2]
+	require.False(t, sell.Complete())
+	require.True(t, sell.Filled.Equal(decimal.NewFromFloat(13.0)))
+
+	var buf bytes.Buffer
+	require.NoError(t, curve.WriteTable(&buf))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 8)
+	require.Contains(t, lines[0], "mid 3.5")
+	require.Contains(t, lines[3], "2000.00")
+}
+
+func TestImpactCurve_EmptyOrderbook(t *testing.T) {
+	marketRecord := newFakeMarketRecord()
+	marketRecord.Bids = nil
+
+	_, err := marketRecord.ImpactCurve([]decimal.Decimal{decimal.NewFromFloat(1.0)})
+	require.Equal(t, ErrEmptyOrderbook, err)
+}
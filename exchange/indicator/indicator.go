@@ -0,0 +1,25 @@
+// Package indicator implements technical indicators over price series.
+//
+// Every indicator has a streaming form, a type whose Update method takes the next value and returns the
+// indicator's current value, and a batch function computing the whole series at once. Values are float64.
+// Until an indicator has seen enough values, Update returns NaN and Ready returns false,
+// batch functions return NaN for the same leading positions.
+package indicator
+
+import (
+	"errors"
+
+	"github.com/skycoin/exchange-api/exchange/candle"
+)
+
+// ErrInvalidPeriod is returned when creating an indicator with a period smaller than 1
+var ErrInvalidPeriod = errors.New("period must be at least 1")
+
+// Closes returns the close prices of candles
+func Closes(candles []candle.Candle) []float64 {
+	result := make([]float64, len(candles))
+	for i, c := range candles {
+		result[i], _ = c.Close.Float64()
+	}
+	return result
+}
@@ -0,0 +1,42 @@
+package indicator
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/exchange-api/exchange/candle"
+)
+
+const delta = 1e-9
+
+// requireValues compares values, NaN matching NaN
+func requireValues(t *testing.T, expected, actual []float64) {
+	require.Len(t, actual, len(expected))
+	for i := range expected {
+		if math.IsNaN(expected[i]) {
+			require.True(t, math.IsNaN(actual[i]), "%d: %v is not NaN", i, actual[i])
+			continue
+		}
+		require.InDelta(t, expected[i], actual[i], delta, "%d", i)
+	}
+}
+
+func newCandle(high, low, last float64) candle.Candle {
+	return candle.Candle{
+		Start:  time.Date(2018, 3, 20, 10, 0, 0, 0, time.UTC),
+		Open:   decimal.NewFromFloat(last),
+		High:   decimal.NewFromFloat(high),
+		Low:    decimal.NewFromFloat(low),
+		Close:  decimal.NewFromFloat(last),
+		Volume: decimal.New(1, 0),
+	}
+}
+
+func TestCloses(t *testing.T) {
+	closes := Closes([]candle.Candle{newCandle(3, 1, 2), newCandle(4, 2, 3.5)})
+	require.Equal(t, []float64{2, 3.5}, closes)
+}
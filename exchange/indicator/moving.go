@@ -0,0 +1,122 @@
+package indicator
+
+import (
+	"math"
+)
+
+// SMA is the simple moving average of the last Period values
+type SMA struct {
+	period int
+	window []float64
+	next   int
+	sum    float64
+}
+
+// NewSMA creates an SMA
+func NewSMA(period int) (*SMA, error) {
+	if period < 1 {
+		return nil, ErrInvalidPeriod
+	}
+
+	return &SMA{
+		period: period,
+		window: make([]float64, 0, period),
+	}, nil
+}
+
+// Update adds a value and returns the average
+func (s *SMA) Update(v float64) float64 {
+	if len(s.window) < s.period {
+		s.window = append(s.window, v)
+	} else {
+		s.sum -= s.window[s.next]
+		s.window[s.next] = v
+		s.next = (s.next + 1) % s.period
+	}
+	s.sum += v
+
+	return s.Value()
+}
+
+// Ready returns true once Period values were added
+func (s *SMA) Ready() bool {
+	return len(s.window) == s.period
+}
+
+// Value returns the average, NaN if not Ready
+func (s *SMA) Value() float64 {
+	if !s.Ready() {
+		return math.NaN()
+	}
+	return s.sum / float64(s.period)
+}
+
+// SMAValues returns the simple moving average of values
+func SMAValues(values []float64, period int) ([]float64, error) {
+	s, err := NewSMA(period)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]float64, len(values))
+	for i, v := range values {
+		result[i] = s.Update(v)
+	}
+	return result, nil
+}
+
+// EMA is the exponential moving average with smoothing factor 2/(Period+1), seeded with the SMA of the first Period values
+type EMA struct {
+	alpha float64
+	seed  *SMA
+	value float64
+}
+
+// NewEMA creates an EMA
+func NewEMA(period int) (*EMA, error) {
+	seed, err := NewSMA(period)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EMA{
+		alpha: 2 / float64(period+1),
+		seed:  seed,
+		value: math.NaN(),
+	}, nil
+}
+
+// Update adds a value and returns the average
+func (e *EMA) Update(v float64) float64 {
+	if !e.seed.Ready() {
+		e.value = e.seed.Update(v)
+		return e.value
+	}
+
+	e.value += e.alpha * (v - e.value)
+	return e.value
+}
+
+// Ready returns true once Period values were added
+func (e *EMA) Ready() bool {
+	return e.seed.Ready()
+}
+
+// Value returns the average, NaN if not Ready
+func (e *EMA) Value() float64 {
+	return e.value
+}
+
+// EMAValues returns the exponential moving average of values
+func EMAValues(values []float64, period int) ([]float64, error) {
+	e, err := NewEMA(period)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]float64, len(values))
+	for i, v := range values {
+		result[i] = e.Update(v)
+	}
+	return result, nil
+}
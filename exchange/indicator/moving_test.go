@@ -0,0 +1,43 @@
+package indicator
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var nan = math.NaN()
+
+func TestSMA(t *testing.T) {
+	_, err := NewSMA(0)
+	require.Equal(t, ErrInvalidPeriod, err)
+
+	values, err := SMAValues([]float64{1, 2, 3, 4, 5}, 3)
+	require.NoError(t, err)
+	requireValues(t, []float64{nan, nan, 2, 3, 4}, values)
+
+	s, err := NewSMA(1)
+	require.NoError(t, err)
+	require.False(t, s.Ready())
+	require.Equal(t, 7.0, s.Update(7))
+	require.True(t, s.Ready())
+	require.Equal(t, 8.0, s.Update(8))
+}
+
+func TestEMA(t *testing.T) {
+	_, err := NewEMA(-1)
+	require.Equal(t, ErrInvalidPeriod, err)
+
+	values, err := EMAValues([]float64{1, 2, 3, 4, 5, 1}, 3)
+	require.NoError(t, err)
+	requireValues(t, []float64{nan, nan, 2, 3, 4, 2.5}, values)
+
+	e, err := NewEMA(3)
+	require.NoError(t, err)
+	for _, v := range []float64{1, 2, 3} {
+		e.Update(v)
+	}
+	require.True(t, e.Ready())
+	require.Equal(t, 2.0, e.Value())
+}
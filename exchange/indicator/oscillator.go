@@ -0,0 +1,176 @@
+package indicator
+
+import (
+	"math"
+)
+
+// RSI is the relative strength index over Period changes, smoothed with Wilder's moving average.
+// It ranges from 0 to 100, 50 when prices didn't change.
+type RSI struct {
+	period  int
+	prev    float64
+	count   int
+	avgGain float64
+	avgLoss float64
+}
+
+// NewRSI creates an RSI
+func NewRSI(period int) (*RSI, error) {
+	if period < 1 {
+		return nil, ErrInvalidPeriod
+	}
+
+	return &RSI{
+		period: period,
+	}, nil
+}
+
+// Update adds a price and returns the index
+func (r *RSI) Update(price float64) float64 {
+	r.count++
+	if r.count == 1 {
+		r.prev = price
+		return math.NaN()
+	}
+
+	change := price - r.prev
+	r.prev = price
+	gain, loss := math.Max(change, 0), math.Max(-change, 0)
+
+	n := float64(r.period)
+	if r.count <= r.period+1 {
+		// the first average is the mean of the first Period changes
+		r.avgGain += gain / n
+		r.avgLoss += loss / n
+	} else {
+		r.avgGain = (r.avgGain*(n-1) + gain) / n
+		r.avgLoss = (r.avgLoss*(n-1) + loss) / n
+	}
+
+	return r.Value()
+}
+
+// Ready returns true once Period+1 prices were added
+func (r *RSI) Ready() bool {
+	return r.count > r.period
+}
+
+// Value returns the index, NaN if not Ready
+func (r *RSI) Value() float64 {
+	switch {
+	case !r.Ready():
+		return math.NaN()
+	case r.avgLoss == 0 && r.avgGain == 0:
+		return 50
+	case r.avgLoss == 0:
+		return 100
+	}
+
+	return 100 - 100/(1+r.avgGain/r.avgLoss)
+}
+
+// RSIValues returns the relative strength index of prices
+func RSIValues(prices []float64, period int) ([]float64, error) {
+	r, err := NewRSI(period)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]float64, len(prices))
+	for i, p := range prices {
+		result[i] = r.Update(p)
+	}
+	return result, nil
+}
+
+// Default MACD periods
+const (
+	DefaultMACDFast   = 12
+	DefaultMACDSlow   = 26
+	DefaultMACDSignal = 9
+)
+
+// MACDValue is a point of the MACD indicator
+type MACDValue struct {
+	// MACD is the fast EMA minus the slow EMA
+	MACD float64
+	// Signal is the EMA of MACD
+	Signal float64
+	// Histogram is MACD minus Signal
+	Histogram float64
+}
+
+func nanMACD() MACDValue {
+	return MACDValue{MACD: math.NaN(), Signal: math.NaN(), Histogram: math.NaN()}
+}
+
+// MACD is the moving average convergence divergence indicator.
+// MACD is available once the slow EMA is ready, Signal and Histogram Signal-1 prices later.
+type MACD struct {
+	fast   *EMA
+	slow   *EMA
+	signal *EMA
+	value  MACDValue
+}
+
+// NewMACD creates a MACD, the fast period must be shorter than the slow one
+func NewMACD(fast, slow, signal int) (*MACD, error) {
+	if fast >= slow {
+		return nil, ErrInvalidPeriod
+	}
+
+	m := &MACD{
+		value: nanMACD(),
+	}
+
+	var err error
+	if m.fast, err = NewEMA(fast); err != nil {
+		return nil, err
+	}
+	if m.slow, err = NewEMA(slow); err != nil {
+		return nil, err
+	}
+	if m.signal, err = NewEMA(signal); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Update adds a price and returns the indicator
+func (m *MACD) Update(price float64) MACDValue {
+	fast := m.fast.Update(price)
+	slow := m.slow.Update(price)
+	if !m.slow.Ready() {
+		return m.value
+	}
+
+	m.value.MACD = fast - slow
+	m.value.Signal = m.signal.Update(m.value.MACD)
+	m.value.Histogram = m.value.MACD - m.value.Signal
+	return m.value
+}
+
+// Ready returns true once the signal line is available
+func (m *MACD) Ready() bool {
+	return m.signal.Ready()
+}
+
+// Value returns the indicator
+func (m *MACD) Value() MACDValue {
+	return m.value
+}
+
+// MACDValues returns the MACD indicator of prices
+func MACDValues(prices []float64, fast, slow, signal int) ([]MACDValue, error) {
+	m, err := NewMACD(fast, slow, signal)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]MACDValue, len(prices))
+	for i, p := range prices {
+		result[i] = m.Update(p)
+	}
+	return result, nil
+}
@@ -0,0 +1,60 @@
+package indicator
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRSI(t *testing.T) {
+	_, err := NewRSI(0)
+	require.Equal(t, ErrInvalidPeriod, err)
+
+	values, err := RSIValues([]float64{1, 2, 3, 2, 1}, 2)
+	require.NoError(t, err)
+	// gains 1, 1 then losses 1, 1 smoothed over 2 periods
+	requireValues(t, []float64{nan, nan, 100, 50, 100 - 100/(1+0.25/0.75)}, values)
+
+	values, err = RSIValues([]float64{3, 3, 3}, 2)
+	require.NoError(t, err)
+	requireValues(t, []float64{nan, nan, 50}, values)
+
+	values, err = RSIValues([]float64{3, 2, 1}, 2)
+	require.NoError(t, err)
+	requireValues(t, []float64{nan, nan, 0}, values)
+}
+
+func TestMACD(t *testing.T) {
+	_, err := NewMACD(3, 3, 2)
+	require.Equal(t, ErrInvalidPeriod, err)
+	_, err = NewMACD(2, 3, 0)
+	require.Equal(t, ErrInvalidPeriod, err)
+
+	values, err := MACDValues([]float64{1, 2, 3, 4, 5, 6}, 2, 3, 2)
+	require.NoError(t, err)
+	require.Len(t, values, 6)
+
+	for i := 0; i < 2; i++ {
+		require.True(t, math.IsNaN(values[i].MACD))
+	}
+
+	// a linear trend keeps the EMAs half a step apart
+	require.InDelta(t, 0.5, values[2].MACD, delta)
+	require.True(t, math.IsNaN(values[2].Signal))
+	for _, v := range values[3:] {
+		require.InDelta(t, 0.5, v.MACD, delta)
+		require.InDelta(t, 0.5, v.Signal, delta)
+		require.InDelta(t, 0, v.Histogram, delta)
+	}
+
+	m, err := NewMACD(DefaultMACDFast, DefaultMACDSlow, DefaultMACDSignal)
+	require.NoError(t, err)
+	for i := 0; i < DefaultMACDSlow+DefaultMACDSignal-2; i++ {
+		m.Update(float64(i))
+	}
+	require.False(t, m.Ready())
+	m.Update(1)
+	require.True(t, m.Ready())
+	require.True(t, m.Value().Histogram < 0)
+}
@@ -0,0 +1,165 @@
+package indicator
+
+import (
+	"math"
+
+	"github.com/skycoin/exchange-api/exchange/candle"
+)
+
+// Default Bollinger band parameters
+const (
+	DefaultBollingerPeriod = 20
+	DefaultBollingerWidth  = 2
+)
+
+// BollingerValue is a point of the Bollinger bands
+type BollingerValue struct {
+	Middle float64
+	Upper  float64
+	Lower  float64
+}
+
+// Bollinger are the Bollinger bands: the SMA of the last Period prices, plus and minus Width times their
+// population standard deviation
+type Bollinger struct {
+	width float64
+	sma   *SMA
+	value BollingerValue
+}
+
+// NewBollinger creates Bollinger bands
+func NewBollinger(period int, width float64) (*Bollinger, error) {
+	sma, err := NewSMA(period)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Bollinger{
+		width: width,
+		sma:   sma,
+		value: BollingerValue{Middle: math.NaN(), Upper: math.NaN(), Lower: math.NaN()},
+	}, nil
+}
+
+// Update adds a price and returns the bands
+func (b *Bollinger) Update(price float64) BollingerValue {
+	mean := b.sma.Update(price)
+	if !b.sma.Ready() {
+		return b.value
+	}
+
+	var variance float64
+	for _, v := range b.sma.window {
+		variance += (v - mean) * (v - mean)
+	}
+	std := math.Sqrt(variance / float64(len(b.sma.window)))
+
+	b.value = BollingerValue{
+		Middle: mean,
+		Upper:  mean + b.width*std,
+		Lower:  mean - b.width*std,
+	}
+	return b.value
+}
+
+// Ready returns true once Period prices were added
+func (b *Bollinger) Ready() bool {
+	return b.sma.Ready()
+}
+
+// Value returns the bands
+func (b *Bollinger) Value() BollingerValue {
+	return b.value
+}
+
+// BollingerValues returns the Bollinger bands of prices
+func BollingerValues(prices []float64, period int, width float64) ([]BollingerValue, error) {
+	b, err := NewBollinger(period, width)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]BollingerValue, len(prices))
+	for i, p := range prices {
+		result[i] = b.Update(p)
+	}
+	return result, nil
+}
+
+// ATR is the average true range over Period candles, smoothed with Wilder's moving average
+type ATR struct {
+	period int
+	count  int
+	prev   float64
+	sum    float64
+	value  float64
+}
+
+// NewATR creates an ATR
+func NewATR(period int) (*ATR, error) {
+	if period < 1 {
+		return nil, ErrInvalidPeriod
+	}
+
+	return &ATR{
+		period: period,
+	}, nil
+}
+
+// Update adds the high, low and last price of a candle and returns the average
+func (a *ATR) Update(high, low, last float64) float64 {
+	tr := high - low
+	if a.count > 0 {
+		tr = math.Max(tr, math.Max(math.Abs(high-a.prev), math.Abs(low-a.prev)))
+	}
+	a.prev = last
+	a.count++
+
+	n := float64(a.period)
+	if a.count > a.period {
+		a.value = (a.value*(n-1) + tr) / n
+		return a.value
+	}
+
+	// the first average is the mean of the first Period true ranges
+	a.sum += tr
+	if a.count == a.period {
+		a.value = a.sum / n
+	}
+	return a.Value()
+}
+
+// UpdateCandle adds a candle and returns the average
+func (a *ATR) UpdateCandle(c candle.Candle) float64 {
+	high, _ := c.High.Float64()
+	low, _ := c.Low.Float64()
+	last, _ := c.Close.Float64()
+	return a.Update(high, low, last)
+}
+
+// Ready returns true once Period candles were added
+func (a *ATR) Ready() bool {
+	return a.count >= a.period
+}
+
+// Value returns the average, NaN if not Ready
+func (a *ATR) Value() float64 {
+	if !a.Ready() {
+		return math.NaN()
+	}
+	return a.value
+}
+
+// ATRValues returns the average true range of candles
+func ATRValues(candles []candle.Candle, period int) ([]float64, error) {
+	a, err := NewATR(period)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]float64, len(candles))
+	for i, c := range candles {
+		result[i] = a.UpdateCandle(c)
+	}
+	return result, nil
+}
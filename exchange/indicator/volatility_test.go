@@ -0,0 +1,57 @@
+package indicator
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/exchange-api/exchange/candle"
+)
+
+func TestBollinger(t *testing.T) {
+	_, err := NewBollinger(0, DefaultBollingerWidth)
+	require.Equal(t, ErrInvalidPeriod, err)
+
+	prices := []float64{2, 4, 4, 4, 5, 5, 7, 9}
+	values, err := BollingerValues(prices, len(prices), DefaultBollingerWidth)
+	require.NoError(t, err)
+	require.Len(t, values, len(prices))
+
+	require.True(t, math.IsNaN(values[6].Middle))
+	// mean 5, standard deviation 2
+	require.InDelta(t, 5, values[7].Middle, delta)
+	require.InDelta(t, 9, values[7].Upper, delta)
+	require.InDelta(t, 1, values[7].Lower, delta)
+
+	b, err := NewBollinger(2, 1)
+	require.NoError(t, err)
+	b.Update(1)
+	require.False(t, b.Ready())
+	v := b.Update(3)
+	require.True(t, b.Ready())
+	require.Equal(t, BollingerValue{Middle: 2, Upper: 3, Lower: 1}, v)
+	require.Equal(t, v, b.Value())
+}
+
+func TestATR(t *testing.T) {
+	_, err := NewATR(0)
+	require.Equal(t, ErrInvalidPeriod, err)
+
+	candles := []candle.Candle{
+		newCandle(10, 8, 9),
+		newCandle(11, 9, 10),
+		// gapped down from 10
+		newCandle(12, 7, 8),
+		newCandle(9, 8, 8.5),
+	}
+
+	values, err := ATRValues(candles, 2)
+	require.NoError(t, err)
+	requireValues(t, []float64{nan, 2, 3.5, 2.25}, values)
+
+	a, err := NewATR(1)
+	require.NoError(t, err)
+	require.Equal(t, 2.0, a.Update(10, 8, 9))
+	require.Equal(t, 3.0, a.Update(12, 11, 11))
+}
@@ -0,0 +1,73 @@
+package indicator
+
+import (
+	"math"
+
+	"github.com/skycoin/exchange-api/exchange/candle"
+)
+
+// VWAP is the volume weighted average price of all the trades or candles added since it was created or Reset
+type VWAP struct {
+	cost   float64
+	volume float64
+}
+
+// NewVWAP creates a VWAP
+func NewVWAP() *VWAP {
+	return &VWAP{}
+}
+
+// Update adds a trade and returns the average
+func (v *VWAP) Update(price, volume float64) float64 {
+	v.cost += price * volume
+	v.volume += volume
+	return v.Value()
+}
+
+// UpdateCandle adds a candle and returns the average. The candle's quote volume is used if it has one,
+// e.g. a candle built from trades, otherwise its volume is valued at the typical price (high + low + close) / 3.
+func (v *VWAP) UpdateCandle(c candle.Candle) float64 {
+	volume, _ := c.Volume.Float64()
+
+	if c.QuoteVolume.Sign() > 0 {
+		cost, _ := c.QuoteVolume.Float64()
+		v.cost += cost
+		v.volume += volume
+		return v.Value()
+	}
+
+	high, _ := c.High.Float64()
+	low, _ := c.Low.Float64()
+	last, _ := c.Close.Float64()
+	return v.Update((high+low+last)/3, volume)
+}
+
+// Reset starts a new session
+func (v *VWAP) Reset() {
+	v.cost = 0
+	v.volume = 0
+}
+
+// Ready returns true once some volume was added
+func (v *VWAP) Ready() bool {
+	return v.volume > 0
+}
+
+// Value returns the average, NaN if not Ready
+func (v *VWAP) Value() float64 {
+	if !v.Ready() {
+		return math.NaN()
+	}
+	return v.cost / v.volume
+}
+
+// VWAPValues returns the cumulative volume weighted average price of candles
+func VWAPValues(candles []candle.Candle) []float64 {
+	v := NewVWAP()
+
+	result := make([]float64, len(candles))
+	for i, c := range candles {
+		result[i] = v.UpdateCandle(c)
+	}
+	return result
+}
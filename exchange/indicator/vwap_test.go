@@ -0,0 +1,37 @@
+package indicator
+
+import (
+	"math"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/exchange-api/exchange/candle"
+)
+
+func TestVWAP(t *testing.T) {
+	v := NewVWAP()
+	require.False(t, v.Ready())
+	require.True(t, math.IsNaN(v.Value()))
+
+	v.Update(2, 1)
+	require.Equal(t, 3.5, v.Update(4, 3))
+
+	v.Reset()
+	require.False(t, v.Ready())
+	require.Equal(t, 5.0, v.Update(5, 2))
+}
+
+func TestVWAPValues(t *testing.T) {
+	// built from trades, 2 coins for 5
+	traded := newCandle(3, 2, 2)
+	traded.Volume = decimal.New(2, 0)
+	traded.QuoteVolume = decimal.New(5, 0)
+
+	// built from ticker samples, 1 coin at the typical price 5
+	sampled := newCandle(6, 4, 5)
+
+	values := VWAPValues([]candle.Candle{traded, sampled})
+	requireValues(t, []float64{2.5, 10.0 / 3}, values)
+}
@@ -0,0 +1,62 @@
+// Package dedupe drops the items already seen from overlapping windows of a time ordered feed, e.g. the last
+// hours of a market's trade history fetched every minute
+package dedupe
+
+import "time"
+
+// Item is an item of a window. Items sharing a timestamp are told apart by their Key, items with the same
+// timestamp and key are counted.
+type Item struct {
+	Time time.Time
+	Key  string
+}
+
+// Window is the state of a deduplicated feed: the latest timestamp and how many items of each key were seen at it.
+// The zero Window has seen nothing.
+type Window struct {
+	last time.Time
+	seen map[string]int
+}
+
+// Last returns the latest timestamp seen
+func (w Window) Last() time.Time {
+	return w.last
+}
+
+// Next returns the indexes of the items which are new since w, and the Window including them.
+// items must be ordered from the oldest. w isn't modified, so the new state can be discarded if the items
+// couldn't be processed.
+func (w Window) Next(items []Item) ([]int, Window) {
+	// known counts the items at w.last which can still be matched
+	known := make(map[string]int, len(w.seen))
+	next := Window{
+		last: w.last,
+		seen: make(map[string]int, len(w.seen)),
+	}
+	for k, n := range w.seen {
+		known[k] = n
+		next.seen[k] = n
+	}
+
+	var fresh []int
+	for i, it := range items {
+		if it.Time.Before(w.last) {
+			continue
+		}
+
+		if it.Time.Equal(w.last) && known[it.Key] > 0 {
+			known[it.Key]--
+			continue
+		}
+
+		if it.Time.After(next.last) {
+			next.last = it.Time
+			next.seen = make(map[string]int)
+		}
+
+		next.seen[it.Key]++
+		fresh = append(fresh, i)
+	}
+
+	return fresh, next
+}
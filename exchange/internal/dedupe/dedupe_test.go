@@ -0,0 +1,72 @@
+package dedupe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var start = time.Date(2018, 3, 20, 10, 0, 0, 0, time.UTC)
+
+func item(offset time.Duration, key string) Item {
+	return Item{Time: start.Add(offset), Key: key}
+}
+
+func TestNext(t *testing.T) {
+	var w Window
+
+	cases := []struct {
+		name  string
+		items []Item
+		fresh []int
+	}{
+		{
+			name:  "first window",
+			items: []Item{item(0, "a"), item(time.Second, "b"), item(time.Second, "b")},
+			fresh: []int{0, 1, 2},
+		},
+		{
+			name:  "same window",
+			items: []Item{item(0, "a"), item(time.Second, "b"), item(time.Second, "b")},
+		},
+		{
+			name:  "late item at the last timestamp",
+			items: []Item{item(time.Second, "b"), item(time.Second, "b"), item(time.Second, "b"), item(time.Second, "c")},
+			fresh: []int{2, 3},
+		},
+		{
+			// the identical items at a newer timestamp are not matched with the ones at the previous timestamp
+			name:  "window without the last items",
+			items: []Item{item(2*time.Second, "b"), item(2*time.Second, "b"), item(2*time.Second, "c")},
+			fresh: []int{0, 1, 2},
+		},
+		{
+			name:  "same items again",
+			items: []Item{item(2*time.Second, "b"), item(2*time.Second, "c"), item(2*time.Second, "b")},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var fresh []int
+			fresh, w = w.Next(tc.items)
+			require.Equal(t, tc.fresh, fresh)
+		})
+	}
+	require.Equal(t, start.Add(2*time.Second), w.Last())
+}
+
+func TestNext_Discarded(t *testing.T) {
+	var w Window
+	_, w = w.Next([]Item{item(0, "a")})
+
+	items := []Item{item(time.Second, "a"), item(time.Second, "a")}
+	fresh, _ := w.Next(items)
+	require.Equal(t, []int{0, 1}, fresh)
+
+	// w is unchanged when the new state is discarded
+	fresh, _ = w.Next(items)
+	require.Equal(t, []int{0, 1}, fresh)
+	require.Equal(t, start, w.Last())
+}
@@ -0,0 +1,83 @@
+// Package httpclient configures the HTTP clients and base URLs of the exchange API clients
+package httpclient
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// DialTimeout is the dial timeout of the default transport
+	DialTimeout = 60 * time.Second
+	// TLSHandshakeTimeout is the TLS handshake timeout of the default transport
+	TLSHandshakeTimeout = 60 * time.Second
+	// Timeout is the default request timeout
+	Timeout = 120 * time.Second
+)
+
+// Options configures the HTTP requests of an API client. Zero fields use the defaults.
+type Options struct {
+	// BaseURL is the root of the API endpoints, e.g. a proxy or a test server
+	BaseURL string
+	// HTTPClient sends the requests. If nil, a client is created with Transport and Timeout.
+	HTTPClient *http.Client
+	// Transport is the RoundTripper of the created HTTPClient, by default with 60s dial and TLS handshake timeouts
+	Transport http.RoundTripper
+	// Timeout is the request timeout of the created HTTPClient, 120s by default
+	Timeout   time.Duration
+	UserAgent string
+}
+
+// Client returns o.HTTPClient, or a new http.Client with o.Transport and o.Timeout
+func (o Options) Client() *http.Client {
+	if o.HTTPClient != nil {
+		return o.HTTPClient
+	}
+
+	transport := o.Transport
+	if transport == nil {
+		transport = &http.Transport{
+			Dial: (&net.Dialer{
+				Timeout: DialTimeout,
+			}).Dial,
+			TLSHandshakeTimeout: TLSHandshakeTimeout,
+		}
+	}
+
+	timeout := o.Timeout
+	if timeout == 0 {
+		timeout = Timeout
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+	}
+}
+
+// URL returns o.BaseURL parsed with ParseBaseURL, or a copy of def if o.BaseURL is empty
+func (o Options) URL(def url.URL) (*url.URL, error) {
+	if o.BaseURL == "" {
+		return &def, nil
+	}
+	return ParseBaseURL(o.BaseURL)
+}
+
+// ParseBaseURL parses an absolute URL, adding a trailing slash to its path so that endpoints can be appended
+func ParseBaseURL(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	if !u.IsAbs() || u.Host == "" {
+		return nil, fmt.Errorf("base URL %q is not absolute", s)
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	return u, nil
+}
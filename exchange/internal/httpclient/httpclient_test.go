@@ -0,0 +1,60 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient(t *testing.T) {
+	c := Options{}.Client()
+	require.Equal(t, Timeout, c.Timeout)
+	require.IsType(t, &http.Transport{}, c.Transport)
+
+	transport := &http.Transport{}
+	c = Options{Transport: transport, Timeout: time.Second}.Client()
+	require.Equal(t, time.Second, c.Timeout)
+	require.Equal(t, transport, c.Transport)
+
+	custom := &http.Client{}
+	require.Equal(t, custom, Options{HTTPClient: custom, Timeout: time.Second}.Client())
+}
+
+func TestURL(t *testing.T) {
+	def := url.URL{Scheme: "https", Host: "example.com", Path: "/api/"}
+	u, err := Options{}.URL(def)
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/api/", u.String())
+
+	// the default is copied
+	u.Path = "/"
+	require.Equal(t, "/api/", def.Path)
+
+	u, err = Options{BaseURL: "http://localhost:8080/api"}.URL(def)
+	require.NoError(t, err)
+	require.Equal(t, "http://localhost:8080/api/", u.String())
+}
+
+func TestParseBaseURL(t *testing.T) {
+	cases := []struct {
+		in  string
+		out string
+	}{
+		{"http://localhost:8080", "http://localhost:8080/"},
+		{"http://localhost:8080/v1", "http://localhost:8080/v1/"},
+		{"https://api.c2cx.com/v1/", "https://api.c2cx.com/v1/"},
+	}
+	for _, tc := range cases {
+		u, err := ParseBaseURL(tc.in)
+		require.NoError(t, err, tc.in)
+		require.Equal(t, tc.out, u.String())
+	}
+
+	for _, s := range []string{"", "/v1/", "api", "http://", ":"} {
+		_, err := ParseBaseURL(s)
+		require.Error(t, err, s)
+	}
+}
@@ -28,6 +28,20 @@ type MarketRecord struct {
 	Asks      []MarketOrder `json:"asks"`
 }
 
+// Copy returns a deep copy of the MarketRecord, which can be modified without affecting the original
+func (r MarketRecord) Copy() MarketRecord {
+	c := r
+	if r.Bids != nil {
+		c.Bids = make([]MarketOrder, len(r.Bids))
+		copy(c.Bids, r.Bids)
+	}
+	if r.Asks != nil {
+		c.Asks = make([]MarketOrder, len(r.Asks))
+		copy(c.Asks, r.Asks)
+	}
+	return c
+}
+
 // MarshalJSON implements json.Marshaler interface
 func (r MarketRecord) MarshalJSON() ([]byte, error) {
 	type rec struct {
@@ -101,11 +115,24 @@ func (marketOrders MarketOrders) Volume() decimal.Decimal {
 	return sum
 }
 
+// TotalCost returns the sum of a set of MarketOrders' total costs
+func (marketOrders MarketOrders) TotalCost() decimal.Decimal {
+	var sum = decimal.Zero
+
+	for _, order := range marketOrders {
+		sum = sum.Add(order.TotalCost())
+	}
+
+	return sum
+}
+
 var (
 	// ErrNegativeAmount SpendItAll error for when called with <0 currency
 	ErrNegativeAmount = errors.New("can't spend negative quantities of currency")
 	// ErrOrdersRanOut SpendItAll error for when the caller tries to purchase more coins than are available in the orderbook
 	ErrOrdersRanOut = errors.New("ran out of orders before we ran out of currency")
+	// ErrLimitPriceReached SellItAll and BuyVolume error for when the remaining orders are beyond the limit price
+	ErrLimitPriceReached = errors.New("reached the limit price before the volume was filled")
 )
 
 // SpendItAll determines the cheapest series of purchases necessary to spend the specified quantity of coins. It can fail if there aren't enough standing orders available to cover the purchase or if the user specifies a negative quantity of coins.
@@ -169,3 +196,114 @@ func (r *MarketRecord) CheapestAsk() *MarketOrder {
 
 	return &result
 }
+
+// FillPlan is a series of purchases or sales against an orderbook, one per price level, best price first
+type FillPlan struct {
+	Fills MarketOrders `json:"fills"`
+	// Volume is the quantity of coins bought or sold
+	Volume decimal.Decimal `json:"volume"`
+	// Cost is the quantity of currency spent or received
+	Cost decimal.Decimal `json:"cost"`
+	// AvgPrice is Cost divided by Volume
+	AvgPrice decimal.Decimal `json:"avg_price"`
+}
+
+func newFillPlan(fills MarketOrders) *FillPlan {
+	plan := &FillPlan{
+		Fills:  fills,
+		Volume: fills.Volume(),
+		Cost:   fills.TotalCost(),
+	}
+
+	if plan.Volume.GreaterThan(decimal.Zero) {
+		plan.AvgPrice = plan.Cost.Div(plan.Volume)
+	}
+
+	return plan
+}
+
+// SellItAll determines the best series of sales into the bids necessary to sell the specified quantity of coins.
+// If limit is not nil, bids priced below it are not used.
+// If the volume can't be sold, the partial plan is returned along with ErrOrdersRanOut or ErrLimitPriceReached.
+// The MarketRecord is not modified.
+func (r *MarketRecord) SellItAll(volume decimal.Decimal, limit *decimal.Decimal) (*FillPlan, error) {
+	bids := make(MarketOrders, len(r.Bids))
+	copy(bids, r.Bids)
+	sort.SliceStable(bids, func(first, second int) bool {
+		return bids[first].Price.GreaterThan(bids[second].Price)
+	})
+
+	return fillVolume(bids, volume, func(price decimal.Decimal) bool {
+		return limit != nil && price.LessThan(*limit)
+	})
+}
+
+// BuyVolume determines the cheapest series of purchases from the asks necessary to acquire exactly the specified quantity of coins.
+// If limit is not nil, asks priced above it are not used.
+// If the volume can't be bought, the partial plan is returned along with ErrOrdersRanOut or ErrLimitPriceReached.
+// The MarketRecord is not modified.
+func (r *MarketRecord) BuyVolume(volume decimal.Decimal, limit *decimal.Decimal) (*FillPlan, error) {
+	asks := make(MarketOrders, len(r.Asks))
+	copy(asks, r.Asks)
+	sort.SliceStable(asks, func(first, second int) bool {
+		return asks[first].Price.LessThan(asks[second].Price)
+	})
+
+	return fillVolume(asks, volume, func(price decimal.Decimal) bool {
+		return limit != nil && price.GreaterThan(*limit)
+	})
+}
+
+// fillVolume fills volume from orders, which are sorted best price first, until beyondLimit returns true for a price
+func fillVolume(orders MarketOrders, volume decimal.Decimal, beyondLimit func(decimal.Decimal) bool) (*FillPlan, error) {
+	if volume.LessThan(decimal.Zero) {
+		return nil, ErrNegativeAmount
+	}
+
+	var fills MarketOrders
+	remaining := volume
+
+	for _, order := range orders {
+		if !remaining.GreaterThan(decimal.Zero) {
+			break
+		}
+
+		if beyondLimit(order.Price) {
+			return newFillPlan(fills), ErrLimitPriceReached
+		}
+
+		fill := MarketOrder{
+			Price:  order.Price,
+			Volume: decimal.Min(order.Volume, remaining),
+		}
+
+		fills = append(fills, fill)
+		remaining = remaining.Sub(fill.Volume)
+	}
+
+	if remaining.GreaterThan(decimal.Zero) {
+		return newFillPlan(fills), ErrOrdersRanOut
+	}
+
+	return newFillPlan(fills), nil
+}
+
+// HighestBid returns the highest Bid order. If there are two bid orders with the same price, it returns the one with the larger volume.
+func (r *MarketRecord) HighestBid() *MarketOrder {
+	if len(r.Bids) == 0 {
+		return nil
+	}
+
+	result := r.Bids[0]
+	for _, order := range r.Bids[1:] {
+		switch {
+		case order.Price.GreaterThan(result.Price):
+			result = order
+		case order.Price.Equal(result.Price) && order.Volume.GreaterThan(result.Volume):
+			result = order
+		default:
+		}
+	}
+
+	return &result
+}
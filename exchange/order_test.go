@@ -118,3 +118,102 @@ func TestCheapestAsk_NoAsks(t *testing.T) {
 
 	require.Nil(t, order)
 }
+
+func TestSellItAll_Success(t *testing.T) {
+	marketRecord := newFakeMarketRecord()
+
+	plan, err := marketRecord.SellItAll(decimal.NewFromFloat(9.0), nil)
+	require.NoError(t, err)
+
+	// 7 SKY at 3 BTC per SKY = 21 BTC
+	// 2 SKY at 2 BTC per SKY =  4 BTC
+	require.Len(t, plan.Fills, 2)
+	require.True(t, plan.Fills[0].Price.Equal(decimal.NewFromFloat(3.0)))
+	require.True(t, plan.Fills[0].Volume.Equal(decimal.NewFromFloat(7.0)))
+	require.True(t, plan.Fills[1].Volume.Equal(decimal.NewFromFloat(2.0)))
+	require.True(t, plan.Volume.Equal(decimal.NewFromFloat(9.0)))
+	require.True(t, plan.Cost.Equal(decimal.NewFromFloat(25.0)))
+	require.True(t, plan.AvgPrice.Equal(decimal.NewFromFloat(25.0).Div(decimal.NewFromFloat(9.0))))
+
+	// the orderbook is not modified
+	require.True(t, marketRecord.Bids[0].Price.Equal(decimal.NewFromFloat(3.0)))
+}
+
+func TestSellItAll_Errors(t *testing.T) {
+	marketRecord := newFakeMarketRecord()
+
+	_, err := marketRecord.SellItAll(decimal.NewFromFloat(-1.0), nil)
+	require.Equal(t, ErrNegativeAmount, err)
+
+	plan, err := marketRecord.SellItAll(decimal.NewFromFloat(14.0), nil)
+	require.Equal(t, ErrOrdersRanOut, err)
+	require.True(t, plan.Volume.Equal(decimal.NewFromFloat(13.0)))
+
+	limit := decimal.NewFromFloat(2.5)
+	plan, err = marketRecord.SellItAll(decimal.NewFromFloat(9.0), &limit)
+	require.Equal(t, ErrLimitPriceReached, err)
+	require.Len(t, plan.Fills, 1)
+	require.True(t, plan.Volume.Equal(decimal.NewFromFloat(7.0)))
+
+	plan, err = marketRecord.SellItAll(decimal.NewFromFloat(5.0), &limit)
+	require.NoError(t, err)
+	require.True(t, plan.AvgPrice.Equal(decimal.NewFromFloat(3.0)))
+}
+
+func TestBuyVolume_Success(t *testing.T) {
+	marketRecord := newFakeMarketRecord()
+	asks := make([]MarketOrder, len(marketRecord.Asks))
+	copy(asks, marketRecord.Asks)
+
+	plan, err := marketRecord.BuyVolume(decimal.NewFromFloat(10.0), nil)
+	require.NoError(t, err)
+
+	// 8 SKY at 4 BTC per SKY = 32 BTC
+	// 2 SKY at 5 BTC per SKY = 10 BTC
+	require.Len(t, plan.Fills, 3)
+	require.True(t, plan.Volume.Equal(decimal.NewFromFloat(10.0)))
+	require.True(t, plan.Cost.Equal(decimal.NewFromFloat(42.0)))
+	require.True(t, plan.AvgPrice.Equal(decimal.NewFromFloat(4.2)))
+	require.True(t, plan.Fills[2].Price.Equal(decimal.NewFromFloat(5.0)))
+
+	// the orderbook is not modified
+	require.Equal(t, asks, marketRecord.Asks)
+}
+
+func TestBuyVolume_Errors(t *testing.T) {
+	marketRecord := newFakeMarketRecord()
+
+	_, err := marketRecord.BuyVolume(decimal.NewFromFloat(-1.0), nil)
+	require.Equal(t, ErrNegativeAmount, err)
+
+	plan, err := marketRecord.BuyVolume(decimal.Zero, nil)
+	require.NoError(t, err)
+	require.Empty(t, plan.Fills)
+	require.True(t, plan.AvgPrice.Equal(decimal.Zero))
+
+	_, err = marketRecord.BuyVolume(decimal.NewFromFloat(18.0), nil)
+	require.Equal(t, ErrOrdersRanOut, err)
+
+	limit := decimal.NewFromFloat(4.0)
+	plan, err = marketRecord.BuyVolume(decimal.NewFromFloat(10.0), &limit)
+	require.Equal(t, ErrLimitPriceReached, err)
+	require.True(t, plan.Volume.Equal(decimal.NewFromFloat(8.0)))
+	require.True(t, plan.Cost.Equal(decimal.NewFromFloat(32.0)))
+}
+
+func TestHighestBid_Success(t *testing.T) {
+	marketRecord := newFakeMarketRecord()
+
+	order := marketRecord.HighestBid()
+
+	require.NotNil(t, order)
+	require.True(t, order.Price.Equal(decimal.NewFromFloat(3.0)))
+}
+
+func TestHighestBid_NoBids(t *testing.T) {
+	marketRecord := newFakeMarketRecord()
+
+	marketRecord.Bids = []MarketOrder{}
+
+	require.Nil(t, marketRecord.HighestBid())
+}
@@ -0,0 +1,226 @@
+package orderbook
+
+import (
+	"math/rand"
+	"sync"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/skycoin/exchange-api/exchange"
+)
+
+var (
+	priorityMu  sync.Mutex
+	priorityGen = rand.New(rand.NewSource(1))
+)
+
+func nextPriority() uint32 {
+	priorityMu.Lock()
+	defer priorityMu.Unlock()
+	return priorityGen.Uint32()
+}
+
+// node is a price level of a persistent treap. Nodes are never modified once they are reachable from a Ladder.
+type node struct {
+	price    decimal.Decimal
+	volume   decimal.Decimal
+	priority uint32
+	left     *node
+	right    *node
+}
+
+// Ladder is an immutable set of price levels, ordered from the best price.
+// Updates return a new Ladder which shares all unchanged levels with the original,
+// so both remain valid and can be read concurrently. Lookups and updates are O(log n).
+type Ladder struct {
+	root *node
+	size int
+	// descending orders prices from the highest, as for bids
+	descending bool
+}
+
+// NewBidLadder creates an empty Ladder ordered from the highest price
+func NewBidLadder() Ladder {
+	return Ladder{descending: true}
+}
+
+// NewAskLadder creates an empty Ladder ordered from the lowest price
+func NewAskLadder() Ladder {
+	return Ladder{}
+}
+
+// cmp compares two prices in the Ladder's order, returning -1 if a comes before b
+func (l Ladder) cmp(a, b decimal.Decimal) int {
+	if l.descending {
+		return b.Cmp(a)
+	}
+	return a.Cmp(b)
+}
+
+// Len returns the number of price levels
+func (l Ladder) Len() int {
+	return l.size
+}
+
+// Get returns the volume at a price
+func (l Ladder) Get(price decimal.Decimal) (decimal.Decimal, bool) {
+	n := l.root
+	for n != nil {
+		switch c := l.cmp(price, n.price); {
+		case c < 0:
+			n = n.left
+		case c > 0:
+			n = n.right
+		default:
+			return n.volume, true
+		}
+	}
+	return decimal.Zero, false
+}
+
+// Best returns the level with the best price
+func (l Ladder) Best() (exchange.MarketOrder, bool) {
+	n := l.root
+	if n == nil {
+		return exchange.MarketOrder{}, false
+	}
+	for n.left != nil {
+		n = n.left
+	}
+	return exchange.MarketOrder{Price: n.price, Volume: n.volume}, true
+}
+
+// Set returns a Ladder with the volume at price replaced. A zero volume removes the level.
+func (l Ladder) Set(price, volume decimal.Decimal) Ladder {
+	_, exists := l.Get(price)
+
+	if volume.Equal(decimal.Zero) {
+		if exists {
+			l.root = l.remove(l.root, price)
+			l.size--
+		}
+		return l
+	}
+
+	l.root = l.insert(l.root, price, volume)
+	if !exists {
+		l.size++
+	}
+	return l
+}
+
+func (l Ladder) insert(n *node, price, volume decimal.Decimal) *node {
+	if n == nil {
+		return &node{
+			price:    price,
+			volume:   volume,
+			priority: nextPriority(),
+		}
+	}
+
+	c := *n
+	switch cmp := l.cmp(price, n.price); {
+	case cmp < 0:
+		c.left = l.insert(n.left, price, volume)
+		if c.left.priority > c.priority {
+			return rotateRight(&c)
+		}
+	case cmp > 0:
+		c.right = l.insert(n.right, price, volume)
+		if c.right.priority > c.priority {
+			return rotateLeft(&c)
+		}
+	default:
+		c.volume = volume
+	}
+
+	return &c
+}
+
+// rotateRight lifts n's left child, both n and its left child must be fresh copies
+func rotateRight(n *node) *node {
+	l := n.left
+	n.left = l.right
+	l.right = n
+	return l
+}
+
+// rotateLeft lifts n's right child, both n and its right child must be fresh copies
+func rotateLeft(n *node) *node {
+	r := n.right
+	n.right = r.left
+	r.left = n
+	return r
+}
+
+func (l Ladder) remove(n *node, price decimal.Decimal) *node {
+	if n == nil {
+		return nil
+	}
+
+	c := *n
+	switch cmp := l.cmp(price, n.price); {
+	case cmp < 0:
+		c.left = l.remove(n.left, price)
+	case cmp > 0:
+		c.right = l.remove(n.right, price)
+	default:
+		return merge(n.left, n.right)
+	}
+
+	return &c
+}
+
+// merge joins two treaps where all prices of a come before those of b
+func merge(a, b *node) *node {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	case a.priority > b.priority:
+		c := *a
+		c.right = merge(a.right, b)
+		return &c
+	default:
+		c := *b
+		c.left = merge(a, b.left)
+		return &c
+	}
+}
+
+// Walk calls f for each level from the best price, until f returns false
+func (l Ladder) Walk(f func(exchange.MarketOrder) bool) {
+	var stack []*node
+	n := l.root
+	for n != nil || len(stack) > 0 {
+		for n != nil {
+			stack = append(stack, n)
+			n = n.left
+		}
+
+		n = stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if !f(exchange.MarketOrder{Price: n.price, Volume: n.volume}) {
+			return
+		}
+
+		n = n.right
+	}
+}
+
+// Levels returns up to depth levels from the best price. A depth <= 0 returns all levels.
+func (l Ladder) Levels(depth int) exchange.MarketOrders {
+	if depth <= 0 || depth > l.size {
+		depth = l.size
+	}
+
+	levels := make(exchange.MarketOrders, 0, depth)
+	l.Walk(func(o exchange.MarketOrder) bool {
+		levels = append(levels, o)
+		return len(levels) < depth
+	})
+
+	return levels
+}
@@ -0,0 +1,138 @@
+package orderbook
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/exchange-api/exchange"
+)
+
+func prices(levels exchange.MarketOrders) []string {
+	result := make([]string, len(levels))
+	for i, o := range levels {
+		result[i] = o.Price.String()
+	}
+	return result
+}
+
+func TestLadderOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		ladder Ladder
+		want   []string
+	}{
+		{
+			name:   "bids",
+			ladder: NewBidLadder(),
+			want:   []string{"5", "4", "3", "2", "1"},
+		},
+		{
+			name:   "asks",
+			ladder: NewAskLadder(),
+			want:   []string{"1", "2", "3", "4", "5"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			l := tc.ladder
+			for _, p := range []int64{3, 1, 5, 2, 4} {
+				l = l.Set(decimal.New(p, 0), decimal.New(p*10, 0))
+			}
+
+			require.Equal(t, 5, l.Len())
+			require.Equal(t, tc.want, prices(l.Levels(0)))
+			require.Equal(t, tc.want[:2], prices(l.Levels(2)))
+
+			best, ok := l.Best()
+			require.True(t, ok)
+			require.Equal(t, tc.want[0], best.Price.String())
+		})
+	}
+}
+
+func TestLadderSet(t *testing.T) {
+	l := NewAskLadder()
+
+	_, ok := l.Best()
+	require.False(t, ok)
+
+	l = l.Set(decimal.New(2, 0), decimal.New(1, 0))
+	l = l.Set(decimal.New(1, 0), decimal.New(1, 0))
+
+	// update an existing level
+	l = l.Set(decimal.New(2, 0), decimal.New(7, 0))
+	require.Equal(t, 2, l.Len())
+	v, ok := l.Get(decimal.New(2, 0))
+	require.True(t, ok)
+	require.True(t, v.Equal(decimal.New(7, 0)))
+
+	// removing a missing level is a no-op
+	l = l.Set(decimal.New(3, 0), decimal.Zero)
+	require.Equal(t, 2, l.Len())
+
+	l = l.Set(decimal.New(1, 0), decimal.Zero)
+	require.Equal(t, 1, l.Len())
+	_, ok = l.Get(decimal.New(1, 0))
+	require.False(t, ok)
+
+	best, ok := l.Best()
+	require.True(t, ok)
+	require.True(t, best.Price.Equal(decimal.New(2, 0)))
+}
+
+func TestLadderImmutable(t *testing.T) {
+	a := NewAskLadder()
+	for i := int64(1); i <= 100; i++ {
+		a = a.Set(decimal.New(i, 0), decimal.New(i, 0))
+	}
+	before := a.Levels(0)
+
+	b := a.Set(decimal.New(1, 0), decimal.Zero)
+	b = b.Set(decimal.New(50, 0), decimal.New(1, 0))
+	b = b.Set(decimal.New(1000, 0), decimal.New(1, 0))
+
+	require.Equal(t, before, a.Levels(0))
+	require.Equal(t, 100, a.Len())
+	require.Equal(t, 100, b.Len())
+
+	v, _ := b.Get(decimal.New(50, 0))
+	require.True(t, v.Equal(decimal.New(1, 0)))
+	v, _ = a.Get(decimal.New(50, 0))
+	require.True(t, v.Equal(decimal.New(50, 0)))
+}
+
+func TestLadderRandom(t *testing.T) {
+	rnd := rand.New(rand.NewSource(42))
+	l := NewBidLadder()
+	expected := make(map[int64]int64)
+
+	for i := 0; i < 5000; i++ {
+		p := rnd.Int63n(500) + 1
+		v := rnd.Int63n(4)
+		l = l.Set(decimal.New(p, -2), decimal.New(v, 0))
+		if v == 0 {
+			delete(expected, p)
+		} else {
+			expected[p] = v
+		}
+	}
+
+	var keys []int64
+	for p := range expected {
+		keys = append(keys, p)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] > keys[j] })
+
+	levels := l.Levels(0)
+	require.Equal(t, len(keys), l.Len())
+	require.Len(t, levels, len(keys))
+	for i, p := range keys {
+		require.True(t, levels[i].Price.Equal(decimal.New(p, -2)))
+		require.True(t, levels[i].Volume.Equal(decimal.New(expected[p], 0)))
+	}
+}
@@ -0,0 +1,165 @@
+// Package orderbook provides a sorted orderbook for large books which is updated without copying its levels
+// and gives readers cheap immutable snapshots
+package orderbook
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/skycoin/exchange-api/exchange"
+)
+
+var (
+	// ErrInvalidPrice is returned when a level's price is not positive
+	ErrInvalidPrice = errors.New("price must be positive")
+	// ErrInvalidVolume is returned when a level's volume is negative
+	ErrInvalidVolume = errors.New("volume must not be negative")
+)
+
+// Snapshot is an immutable state of a Book. It is safe to use concurrently with updates of the Book.
+type Snapshot struct {
+	Timestamp time.Time
+	Symbol    string
+	Bids      Ladder
+	Asks      Ladder
+}
+
+// BestBid returns the highest bid
+func (s Snapshot) BestBid() (exchange.MarketOrder, bool) {
+	return s.Bids.Best()
+}
+
+// BestAsk returns the lowest ask
+func (s Snapshot) BestAsk() (exchange.MarketOrder, bool) {
+	return s.Asks.Best()
+}
+
+// MarketRecord converts the snapshot to a MarketRecord with bids from the highest and asks from the lowest price
+func (s Snapshot) MarketRecord() exchange.MarketRecord {
+	return exchange.MarketRecord{
+		Timestamp: s.Timestamp,
+		Symbol:    s.Symbol,
+		Bids:      s.Bids.Levels(0),
+		Asks:      s.Asks.Levels(0),
+	}
+}
+
+// Book is a sorted orderbook of one market. Updates replace its ladders with new ones sharing the unchanged levels,
+// so snapshots taken before an update are never modified.
+type Book struct {
+	mu       sync.RWMutex
+	snapshot Snapshot
+}
+
+// NewBook creates an empty Book
+func NewBook(symbol string) *Book {
+	return &Book{
+		snapshot: Snapshot{
+			Symbol: symbol,
+			Bids:   NewBidLadder(),
+			Asks:   NewAskLadder(),
+		},
+	}
+}
+
+// FromMarketRecord creates a Book from a MarketRecord. Levels with the same price are summed.
+func FromMarketRecord(r exchange.MarketRecord) (*Book, error) {
+	b := NewBook(r.Symbol)
+	if err := b.Replace(r); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Snapshot returns the current state of the Book in O(1)
+func (b *Book) Snapshot() Snapshot {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.snapshot
+}
+
+// Symbol returns the Book's market
+func (b *Book) Symbol() string {
+	return b.Snapshot().Symbol
+}
+
+// SetBid sets the volume of the bid level at price, a zero volume removes the level
+func (b *Book) SetBid(ts time.Time, price, volume decimal.Decimal) error {
+	return b.set(ts, exchange.ActionBuy, price, volume)
+}
+
+// SetAsk sets the volume of the ask level at price, a zero volume removes the level
+func (b *Book) SetAsk(ts time.Time, price, volume decimal.Decimal) error {
+	return b.set(ts, exchange.ActionSell, price, volume)
+}
+
+func (b *Book) set(ts time.Time, side exchange.Action, price, volume decimal.Decimal) error {
+	if err := validateLevel(price, volume); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if side == exchange.ActionBuy {
+		b.snapshot.Bids = b.snapshot.Bids.Set(price, volume)
+	} else {
+		b.snapshot.Asks = b.snapshot.Asks.Set(price, volume)
+	}
+	b.snapshot.Timestamp = ts
+
+	return nil
+}
+
+// Replace replaces the contents of the Book with a MarketRecord. Levels with the same price are summed.
+func (b *Book) Replace(r exchange.MarketRecord) error {
+	bids, err := buildLadder(NewBidLadder(), r.Bids)
+	if err != nil {
+		return err
+	}
+
+	asks, err := buildLadder(NewAskLadder(), r.Asks)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.snapshot = Snapshot{
+		Timestamp: r.Timestamp,
+		Symbol:    r.Symbol,
+		Bids:      bids,
+		Asks:      asks,
+	}
+
+	return nil
+}
+
+func buildLadder(l Ladder, levels []exchange.MarketOrder) (Ladder, error) {
+	for _, o := range levels {
+		if err := validateLevel(o.Price, o.Volume); err != nil {
+			return Ladder{}, err
+		}
+
+		if v, ok := l.Get(o.Price); ok {
+			l = l.Set(o.Price, v.Add(o.Volume))
+		} else {
+			l = l.Set(o.Price, o.Volume)
+		}
+	}
+	return l, nil
+}
+
+func validateLevel(price, volume decimal.Decimal) error {
+	if !price.GreaterThan(decimal.Zero) {
+		return ErrInvalidPrice
+	}
+	if volume.LessThan(decimal.Zero) {
+		return ErrInvalidVolume
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+package orderbook
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/exchange-api/exchange"
+)
+
+func newFakeMarketRecord() exchange.MarketRecord {
+	return exchange.MarketRecord{
+		Timestamp: time.Unix(1500000000, 0).UTC(),
+		Symbol:    "SKY/BTC",
+		Asks: []exchange.MarketOrder{
+			{Price: decimal.NewFromFloat(5.0), Volume: decimal.NewFromFloat(9.0)},
+			{Price: decimal.NewFromFloat(4.0), Volume: decimal.NewFromFloat(5.0)},
+			{Price: decimal.NewFromFloat(4.0), Volume: decimal.NewFromFloat(3.0)},
+		},
+		Bids: []exchange.MarketOrder{
+			{Price: decimal.NewFromFloat(3.0), Volume: decimal.NewFromFloat(7.0)},
+			{Price: decimal.NewFromFloat(2.0), Volume: decimal.NewFromFloat(6.0)},
+		},
+	}
+}
+
+func TestFromMarketRecord(t *testing.T) {
+	b, err := FromMarketRecord(newFakeMarketRecord())
+	require.NoError(t, err)
+	require.Equal(t, "SKY/BTC", b.Symbol())
+
+	s := b.Snapshot()
+	bid, ok := s.BestBid()
+	require.True(t, ok)
+	require.True(t, bid.Price.Equal(decimal.NewFromFloat(3.0)))
+
+	// the two asks at 4 are merged
+	ask, ok := s.BestAsk()
+	require.True(t, ok)
+	require.True(t, ask.Price.Equal(decimal.NewFromFloat(4.0)))
+	require.True(t, ask.Volume.Equal(decimal.NewFromFloat(8.0)))
+
+	r := s.MarketRecord()
+	require.Equal(t, "SKY/BTC", r.Symbol)
+	require.True(t, r.Timestamp.Equal(time.Unix(1500000000, 0)))
+	require.Equal(t, []string{"3", "2"}, prices(r.Bids))
+	require.Equal(t, []string{"4", "5"}, prices(r.Asks))
+}
+
+func TestFromMarketRecordInvalid(t *testing.T) {
+	r := newFakeMarketRecord()
+	r.Bids[1].Price = decimal.Zero
+	_, err := FromMarketRecord(r)
+	require.Equal(t, ErrInvalidPrice, err)
+
+	r = newFakeMarketRecord()
+	r.Asks[0].Volume = decimal.NewFromFloat(-1.0)
+	_, err = FromMarketRecord(r)
+	require.Equal(t, ErrInvalidVolume, err)
+}
+
+func TestBookSnapshotIsolation(t *testing.T) {
+	b, err := FromMarketRecord(newFakeMarketRecord())
+	require.NoError(t, err)
+
+	before := b.Snapshot()
+	ts := time.Unix(1500000001, 0).UTC()
+
+	require.NoError(t, b.SetAsk(ts, decimal.NewFromFloat(3.5), decimal.NewFromFloat(1.0)))
+	require.NoError(t, b.SetBid(ts, decimal.NewFromFloat(3.0), decimal.Zero))
+	require.Equal(t, ErrInvalidPrice, b.SetBid(ts, decimal.NewFromFloat(-1.0), decimal.NewFromFloat(1.0)))
+	require.Equal(t, ErrInvalidVolume, b.SetAsk(ts, decimal.NewFromFloat(1.0), decimal.NewFromFloat(-1.0)))
+
+	after := b.Snapshot()
+	require.True(t, after.Timestamp.Equal(ts))
+
+	ask, _ := after.BestAsk()
+	require.True(t, ask.Price.Equal(decimal.NewFromFloat(3.5)))
+	bid, _ := after.BestBid()
+	require.True(t, bid.Price.Equal(decimal.NewFromFloat(2.0)))
+
+	// the earlier snapshot is unchanged
+	require.Equal(t, newFakeMarketRecord().Timestamp, before.Timestamp)
+	ask, _ = before.BestAsk()
+	require.True(t, ask.Price.Equal(decimal.NewFromFloat(4.0)))
+	bid, _ = before.BestBid()
+	require.True(t, bid.Price.Equal(decimal.NewFromFloat(3.0)))
+}
+
+func TestBookConcurrentReaders(t *testing.T) {
+	b := NewBook("SKY/BTC")
+	ts := time.Now().UTC()
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+
+				s := b.Snapshot()
+				levels := s.Asks.Levels(0)
+				if !sort.SliceIsSorted(levels, func(i, j int) bool { return levels[i].Price.LessThan(levels[j].Price) }) {
+					t.Error("asks are not sorted")
+					return
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 2000; i++ {
+		require.NoError(t, b.SetAsk(ts, decimal.New(int64(rand.Intn(200)+1), -3), decimal.New(int64(rand.Intn(3)), 0)))
+	}
+
+	close(stop)
+	wg.Wait()
+}
+
+// benchmarkLevels is the number of levels on each side of the benchmarked books
+const benchmarkLevels = 5000
+
+func newBenchmarkMarketRecord() exchange.MarketRecord {
+	r := exchange.MarketRecord{
+		Symbol: "SKY/BTC",
+		Bids:   make([]exchange.MarketOrder, benchmarkLevels),
+		Asks:   make([]exchange.MarketOrder, benchmarkLevels),
+	}
+
+	for i := 0; i < benchmarkLevels; i++ {
+		r.Bids[i] = exchange.MarketOrder{Price: decimal.New(int64(benchmarkLevels-i), -8), Volume: decimal.New(10, 0)}
+		r.Asks[i] = exchange.MarketOrder{Price: decimal.New(int64(benchmarkLevels+1+i), -8), Volume: decimal.New(10, 0)}
+	}
+
+	return r
+}
+
+// updateMarketRecord sets an ask level of a copy of r, keeping the asks sorted, as readers of r must not observe the change
+func updateMarketRecord(r exchange.MarketRecord, price, volume decimal.Decimal) exchange.MarketRecord {
+	c := r.Copy()
+	i := sort.Search(len(c.Asks), func(i int) bool { return !c.Asks[i].Price.LessThan(price) })
+
+	switch {
+	case i < len(c.Asks) && c.Asks[i].Price.Equal(price) && volume.Equal(decimal.Zero):
+		c.Asks = append(c.Asks[:i], c.Asks[i+1:]...)
+	case i < len(c.Asks) && c.Asks[i].Price.Equal(price):
+		c.Asks[i].Volume = volume
+	case !volume.Equal(decimal.Zero):
+		c.Asks = append(c.Asks, exchange.MarketOrder{})
+		copy(c.Asks[i+1:], c.Asks[i:])
+		c.Asks[i] = exchange.MarketOrder{Price: price, Volume: volume}
+	}
+
+	return c
+}
+
+func benchmarkUpdates() []decimal.Decimal {
+	rnd := rand.New(rand.NewSource(1))
+	updates := make([]decimal.Decimal, 1024)
+	for i := range updates {
+		updates[i] = decimal.New(int64(benchmarkLevels+1+rnd.Intn(benchmarkLevels)), -8)
+	}
+	return updates
+}
+
+func BenchmarkUpdateMarketRecord(b *testing.B) {
+	r := newBenchmarkMarketRecord()
+	updates := benchmarkUpdates()
+	volume := decimal.New(3, 0)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r = updateMarketRecord(r, updates[i%len(updates)], volume)
+	}
+}
+
+func BenchmarkUpdateBook(b *testing.B) {
+	book, err := FromMarketRecord(newBenchmarkMarketRecord())
+	require.NoError(b, err)
+	updates := benchmarkUpdates()
+	volume := decimal.New(3, 0)
+	ts := time.Now()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		book.SetAsk(ts, updates[i%len(updates)], volume) // nolint: errcheck
+	}
+}
+
+func BenchmarkBestAskMarketRecord(b *testing.B) {
+	r := newBenchmarkMarketRecord()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.CheapestAsk()
+	}
+}
+
+func BenchmarkBestAskBook(b *testing.B) {
+	book, err := FromMarketRecord(newBenchmarkMarketRecord())
+	require.NoError(b, err)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		book.Snapshot().BestAsk()
+	}
+}
+
+func BenchmarkSnapshotMarketRecord(b *testing.B) {
+	r := newBenchmarkMarketRecord()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.Copy()
+	}
+}
+
+func BenchmarkSnapshotBook(b *testing.B) {
+	book, err := FromMarketRecord(newBenchmarkMarketRecord())
+	require.NoError(b, err)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		book.Snapshot()
+	}
+}
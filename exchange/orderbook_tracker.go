@@ -0,0 +1,167 @@
+package exchange
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// DefaultOrderbookRefreshInterval is the OrderbookTracker's polling interval if none is specified
+const DefaultOrderbookRefreshInterval = 5 * time.Second
+
+var (
+	// ErrOrderbookNotFound is returned when no orderbook has been fetched for a market yet
+	ErrOrderbookNotFound = errors.New("orderbook not found")
+	// ErrStaleOrderbook is returned when the cached orderbook is older than the accepted age
+	ErrStaleOrderbook = errors.New("orderbook is stale")
+)
+
+type cachedOrderbook struct {
+	record  MarketRecord
+	fetched time.Time
+	err     error
+}
+
+// OrderbookTracker keeps the orderbooks of several markets of an Exchange refreshed in the background.
+// Readers always receive copies, so they are free to modify them.
+// The age of an orderbook is measured from the moment it was fetched.
+type OrderbookTracker struct {
+	Exchange                 Exchange
+	Pairs                    []Pair
+	OrderbookRefreshInterval time.Duration
+	// Stop stops Run when closed
+	Stop chan struct{}
+
+	mu    sync.RWMutex
+	books map[Pair]cachedOrderbook
+	now   func() time.Time
+}
+
+// NewOrderbookTracker creates an OrderbookTracker for the given markets of an Exchange
+func NewOrderbookTracker(e Exchange, interval time.Duration, pairs ...Pair) *OrderbookTracker {
+	if interval <= 0 {
+		interval = DefaultOrderbookRefreshInterval
+	}
+
+	return &OrderbookTracker{
+		Exchange:                 e,
+		Pairs:                    pairs,
+		OrderbookRefreshInterval: interval,
+		Stop:                     make(chan struct{}),
+		books:                    make(map[Pair]cachedOrderbook),
+		now:                      time.Now,
+	}
+}
+
+// Run refreshes the orderbooks every OrderbookRefreshInterval until Stop is closed.
+// A failed refresh keeps the previous orderbook, which then ages.
+func (t *OrderbookTracker) Run() {
+	ticker := time.NewTicker(t.OrderbookRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		t.Update() // nolint: errcheck
+
+		select {
+		case <-t.Stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Update fetches the orderbooks of all markets once.
+// All markets are attempted, the last error encountered is returned.
+func (t *OrderbookTracker) Update() error {
+	var lastErr error
+	for _, pair := range t.Pairs {
+		if err := t.Refresh(pair); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// Refresh fetches the orderbook of a single market
+func (t *OrderbookTracker) Refresh(pair Pair) error {
+	record, err := t.Exchange.GetOrderbook(pair)
+	now := t.now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	book := t.books[pair]
+	book.err = err
+	if err == nil {
+		book.record = record.Copy()
+		book.fetched = now
+	}
+	t.books[pair] = book
+
+	return err
+}
+
+// Get returns a copy of the latest orderbook of a market, regardless of its age
+func (t *OrderbookTracker) Get(pair Pair) (*MarketRecord, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	book, ok := t.books[pair]
+	if !ok || book.fetched.IsZero() {
+		return nil, ErrOrderbookNotFound
+	}
+
+	record := book.record.Copy()
+	return &record, nil
+}
+
+// GetFresh returns a copy of the latest orderbook of a market if it is not older than maxAge.
+// Otherwise ErrStaleOrderbook is returned, so that callers don't trade on stale data.
+func (t *OrderbookTracker) GetFresh(pair Pair, maxAge time.Duration) (*MarketRecord, error) {
+	age, err := t.Age(pair)
+	if err != nil {
+		return nil, err
+	}
+
+	if age > maxAge {
+		return nil, ErrStaleOrderbook
+	}
+
+	return t.Get(pair)
+}
+
+// Age returns the time elapsed since the orderbook of a market was last fetched
+func (t *OrderbookTracker) Age(pair Pair) (time.Duration, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	book, ok := t.books[pair]
+	if !ok || book.fetched.IsZero() {
+		return 0, ErrOrderbookNotFound
+	}
+
+	return t.now().Sub(book.fetched), nil
+}
+
+// Ages returns the age of every fetched orderbook, by market
+func (t *OrderbookTracker) Ages() map[Pair]time.Duration {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	now := t.now()
+	ages := make(map[Pair]time.Duration, len(t.books))
+	for pair, book := range t.books {
+		if !book.fetched.IsZero() {
+			ages[pair] = now.Sub(book.fetched)
+		}
+	}
+
+	return ages
+}
+
+// Err returns the error of the last refresh of a market, if any
+func (t *OrderbookTracker) Err(pair Pair) error {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.books[pair].err
+}
@@ -0,0 +1,94 @@
+package exchange
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderbookTracker(t *testing.T) {
+	e := newFakeExchange()
+	e.orderbooks["SKY/BTC"] = newFakeMarketRecord()
+
+	now := time.Date(2018, 3, 20, 10, 0, 0, 0, time.UTC)
+	tracker := NewOrderbookTracker(e, time.Second, skyBtc, ltcBtc)
+	tracker.now = func() time.Time { return now }
+
+	_, err := tracker.Get(skyBtc)
+	require.Equal(t, ErrOrderbookNotFound, err)
+	_, err = tracker.Age(skyBtc)
+	require.Equal(t, ErrOrderbookNotFound, err)
+
+	// LTC/BTC is unknown to the exchange
+	require.Error(t, tracker.Update())
+	require.Error(t, tracker.Err(ltcBtc))
+	require.NoError(t, tracker.Err(skyBtc))
+
+	record, err := tracker.Get(skyBtc)
+	require.NoError(t, err)
+	require.Len(t, record.Asks, 3)
+
+	// Readers get copies
+	record.Asks[0].Price = decimal.Zero
+	record, err = tracker.Get(skyBtc)
+	require.NoError(t, err)
+	require.True(t, record.Asks[0].Price.Equal(decimal.New(5, 0)))
+
+	now = now.Add(time.Second * 3)
+	age, err := tracker.Age(skyBtc)
+	require.NoError(t, err)
+	require.Equal(t, time.Second*3, age)
+	require.Equal(t, map[Pair]time.Duration{skyBtc: time.Second * 3}, tracker.Ages())
+
+	_, err = tracker.GetFresh(skyBtc, time.Second*5)
+	require.NoError(t, err)
+	_, err = tracker.GetFresh(skyBtc, time.Second)
+	require.Equal(t, ErrStaleOrderbook, err)
+
+	// A failed refresh keeps the previous orderbook
+	e.err = errors.New("exchange unavailable")
+	require.Error(t, tracker.Refresh(skyBtc))
+	_, err = tracker.Get(skyBtc)
+	require.NoError(t, err)
+	age, err = tracker.Age(skyBtc)
+	require.NoError(t, err)
+	require.Equal(t, time.Second*3, age)
+
+	e.err = nil
+	require.NoError(t, tracker.Refresh(skyBtc))
+	age, err = tracker.Age(skyBtc)
+	require.NoError(t, err)
+	require.Equal(t, time.Duration(0), age)
+}
+
+func TestOrderbookTrackerRun(t *testing.T) {
+	e := newFakeExchange()
+	e.orderbooks["SKY/BTC"] = newFakeMarketRecord()
+	tracker := NewOrderbookTracker(e, time.Millisecond*10, skyBtc)
+
+	done := make(chan struct{})
+	go func() {
+		tracker.Run()
+		close(done)
+	}()
+
+	waitFor(t, func() bool {
+		_, err := tracker.Get(skyBtc)
+		return err == nil
+	})
+
+	close(tracker.Stop)
+	<-done
+}
+
+func TestMarketRecordCopy(t *testing.T) {
+	r := newFakeMarketRecord()
+	c := r.Copy()
+	c.Bids[0].Volume = decimal.Zero
+	c.Asks = c.Asks[:1]
+	require.True(t, r.Bids[0].Volume.Equal(decimal.New(7, 0)))
+	require.Len(t, r.Asks, 3)
+}
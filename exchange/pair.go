@@ -0,0 +1,135 @@
+package exchange
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrInvalidCurrency is returned when a currency symbol is empty or contains a separator
+	ErrInvalidCurrency = errors.New("invalid currency")
+	// ErrInvalidSymbol is returned when a symbol can't be decoded to a Pair
+	ErrInvalidSymbol = errors.New("invalid symbol")
+)
+
+// Currency is an upper case currency symbol, e.g. "BTC"
+type Currency string
+
+const (
+	// BTC is Bitcoin
+	BTC Currency = "BTC"
+	// ETH is Ethereum
+	ETH Currency = "ETH"
+	// SKY is Skycoin
+	SKY Currency = "SKY"
+	// USDT is Tether
+	USDT Currency = "USDT"
+)
+
+// ParseCurrency converts a currency symbol of any case to a Currency
+func ParseCurrency(s string) (Currency, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if s == "" || strings.ContainsAny(s, "/_- ") {
+		return "", ErrInvalidCurrency
+	}
+	return Currency(s), nil
+}
+
+// Pair is a market where the Base currency is traded and priced in the Quote currency,
+// e.g. SKY priced in BTC is Pair{Base: SKY, Quote: BTC}
+type Pair struct {
+	Base  Currency `json:"base"`
+	Quote Currency `json:"quote"`
+}
+
+// NewPair creates a Pair
+func NewPair(base, quote Currency) Pair {
+	return Pair{
+		Base:  base,
+		Quote: quote,
+	}
+}
+
+// ParsePair decodes the canonical "BASE/QUOTE" form of a Pair, in any case
+func ParsePair(s string) (Pair, error) {
+	return CanonicalCodec.DecodePair(s)
+}
+
+// String returns the canonical "BASE/QUOTE" form of the Pair
+func (p Pair) String() string {
+	return string(p.Base) + "/" + string(p.Quote)
+}
+
+// MarshalText implements encoding.TextMarshaler, using the canonical form
+func (p Pair) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, using the canonical form
+func (p *Pair) UnmarshalText(b []byte) error {
+	v, err := ParsePair(string(b))
+	if err != nil {
+		return err
+	}
+	*p = v
+	return nil
+}
+
+// PairCodec converts Pairs to and from an exchange's own symbols
+type PairCodec interface {
+	EncodePair(Pair) string
+	DecodePair(string) (Pair, error)
+}
+
+// CanonicalCodec encodes Pairs as "BASE/QUOTE", which is also Cryptopia's format
+var CanonicalCodec PairCodec = SeparatorCodec{Separator: "/"}
+
+// SeparatorCodec encodes Pairs as two currencies joined by Separator, with the quote currency first if QuoteFirst is set
+type SeparatorCodec struct {
+	Separator  string
+	QuoteFirst bool
+}
+
+// EncodePair encodes a Pair
+func (c SeparatorCodec) EncodePair(p Pair) string {
+	if c.QuoteFirst {
+		return string(p.Quote) + c.Separator + string(p.Base)
+	}
+	return string(p.Base) + c.Separator + string(p.Quote)
+}
+
+// DecodePair decodes a Pair
+func (c SeparatorCodec) DecodePair(s string) (Pair, error) {
+	parts := strings.Split(strings.TrimSpace(s), c.Separator)
+	if len(parts) != 2 {
+		return Pair{}, ErrInvalidSymbol
+	}
+
+	first, err := ParseCurrency(parts[0])
+	if err != nil {
+		return Pair{}, ErrInvalidSymbol
+	}
+
+	second, err := ParseCurrency(parts[1])
+	if err != nil {
+		return Pair{}, ErrInvalidSymbol
+	}
+
+	if c.QuoteFirst {
+		return NewPair(second, first), nil
+	}
+	return NewPair(first, second), nil
+}
+
+// codecOrCanonical returns c, or CanonicalCodec if c is nil
+func codecOrCanonical(c PairCodec) PairCodec {
+	if c == nil {
+		return CanonicalCodec
+	}
+	return c
+}
+
+// Pair decodes the record's symbol with the exchange's codec, CanonicalCodec if nil
+func (r *MarketRecord) Pair(codec PairCodec) (Pair, error) {
+	return codecOrCanonical(codec).DecodePair(r.Symbol)
+}
@@ -0,0 +1,95 @@
+package exchange
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCurrency(t *testing.T) {
+	c, err := ParseCurrency(" sky ")
+	require.NoError(t, err)
+	require.Equal(t, SKY, c)
+
+	for _, s := range []string{"", " ", "SKY/BTC", "BTC_SKY", "SKY-BTC"} {
+		_, err := ParseCurrency(s)
+		require.Equal(t, ErrInvalidCurrency, err, s)
+	}
+}
+
+func TestSeparatorCodec(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		codec   PairCodec
+		encoded string
+		decode  []string
+		invalid []string
+	}{
+		{
+			name:    "canonical",
+			codec:   CanonicalCodec,
+			encoded: "SKY/BTC",
+			decode:  []string{"SKY/BTC", " sky/btc"},
+			invalid: []string{"", "SKYBTC", "SKY/", "/BTC", "SKY/BTC/ETH", "SKY_BTC"},
+		},
+		{
+			name:    "quote first",
+			codec:   quoteFirstCodec,
+			encoded: "BTC_SKY",
+			decode:  []string{"BTC_SKY", "btc_sky"},
+			invalid: []string{"", "BTCSKY", "BTC_", "BTC/SKY"},
+		},
+		{
+			name:    "dash",
+			codec:   SeparatorCodec{Separator: "-"},
+			encoded: "SKY-BTC",
+			decode:  []string{"SKY-BTC"},
+			invalid: []string{"SKY/BTC"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.encoded, tc.codec.EncodePair(skyBtc))
+
+			for _, s := range tc.decode {
+				p, err := tc.codec.DecodePair(s)
+				require.NoError(t, err, s)
+				require.Equal(t, skyBtc, p, s)
+			}
+
+			for _, s := range tc.invalid {
+				_, err := tc.codec.DecodePair(s)
+				require.Equal(t, ErrInvalidSymbol, err, s)
+			}
+		})
+	}
+}
+
+func TestPairJSON(t *testing.T) {
+	p := NewPair(SKY, BTC)
+	require.Equal(t, "SKY/BTC", p.String())
+
+	b, err := json.Marshal(map[string]Pair{"pair": p})
+	require.NoError(t, err)
+	require.Equal(t, `{"pair":"SKY/BTC"}`, string(b))
+
+	var v map[string]Pair
+	require.NoError(t, json.Unmarshal(b, &v))
+	require.Equal(t, p, v["pair"])
+
+	require.Error(t, json.Unmarshal([]byte(`{"pair":"SKY"}`), &v))
+}
+
+func TestMarketRecordPair(t *testing.T) {
+	r := MarketRecord{Symbol: "BTC_SKY"}
+
+	p, err := r.Pair(quoteFirstCodec)
+	require.NoError(t, err)
+	require.Equal(t, NewPair(SKY, BTC), p)
+
+	_, err = r.Pair(nil)
+	require.Equal(t, ErrInvalidSymbol, err)
+}
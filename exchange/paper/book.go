@@ -0,0 +1,153 @@
+package paper
+
+import (
+	"sort"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/skycoin/exchange-api/exchange"
+)
+
+// order is an order on the paper exchange. External orders represent other market participants,
+// they don't affect the account's balances and don't match each other.
+type order struct {
+	exchange.Order
+	external bool
+	market   bool
+	// remaining is the volume left to fill, unused by market buys
+	remaining decimal.Decimal
+	// frozen is the part of the account's balance still reserved by the order
+	frozen decimal.Decimal
+}
+
+// level is a price level, orders are filled in time priority
+type level struct {
+	price  decimal.Decimal
+	orders []*order
+}
+
+func (l *level) volume() decimal.Decimal {
+	total := decimal.Zero
+	for _, o := range l.orders {
+		total = total.Add(o.remaining)
+	}
+	return total
+}
+
+// side is one side of a book, its levels are sorted from the best price
+type side struct {
+	levels     []*level
+	descending bool
+}
+
+// better returns true if price a is strictly better than price b on this side
+func (s *side) better(a, b decimal.Decimal) bool {
+	if s.descending {
+		return a.GreaterThan(b)
+	}
+	return a.LessThan(b)
+}
+
+// find returns the index of the level at price, or where it would be inserted
+func (s *side) find(price decimal.Decimal) (int, bool) {
+	i := sort.Search(len(s.levels), func(i int) bool {
+		return !s.better(s.levels[i].price, price)
+	})
+	return i, i < len(s.levels) && s.levels[i].price.Equal(price)
+}
+
+// add appends an order to the end of its price level's queue
+func (s *side) add(o *order) {
+	i, ok := s.find(o.Price)
+	if !ok {
+		s.levels = append(s.levels, nil)
+		copy(s.levels[i+1:], s.levels[i:])
+		s.levels[i] = &level{price: o.Price}
+	}
+	s.levels[i].orders = append(s.levels[i].orders, o)
+}
+
+// remove removes an order from the side, returning false if it isn't there
+func (s *side) remove(o *order) bool {
+	i, ok := s.find(o.Price)
+	if !ok {
+		return false
+	}
+
+	l := s.levels[i]
+	for j, v := range l.orders {
+		if v == o {
+			l.orders = append(l.orders[:j], l.orders[j+1:]...)
+			s.prune()
+			return true
+		}
+	}
+
+	return false
+}
+
+// removeIf removes all orders for which f returns true
+func (s *side) removeIf(f func(*order) bool) {
+	for _, l := range s.levels {
+		orders := l.orders[:0]
+		for _, o := range l.orders {
+			if !f(o) {
+				orders = append(orders, o)
+			}
+		}
+		l.orders = orders
+	}
+	s.prune()
+}
+
+// prune removes empty levels
+func (s *side) prune() {
+	levels := s.levels[:0]
+	for _, l := range s.levels {
+		if len(l.orders) > 0 {
+			levels = append(levels, l)
+		}
+	}
+	s.levels = levels
+}
+
+// marketOrders aggregates the levels' volumes, from the best price
+func (s *side) marketOrders() []exchange.MarketOrder {
+	result := make([]exchange.MarketOrder, len(s.levels))
+	for i, l := range s.levels {
+		result[i] = exchange.MarketOrder{
+			Price:  l.price,
+			Volume: l.volume(),
+		}
+	}
+	return result
+}
+
+// book is a price-time priority limit order book of one market
+type book struct {
+	bids side
+	asks side
+}
+
+func newBook() *book {
+	return &book{
+		bids: side{descending: true},
+		asks: side{},
+	}
+}
+
+// side returns the side resting orders of an action are placed on
+func (b *book) side(action exchange.Action) *side {
+	if action == exchange.ActionBuy {
+		return &b.bids
+	}
+	return &b.asks
+}
+
+// opposite returns the side orders of an action are matched against
+func (b *book) opposite(action exchange.Action) *side {
+	if action == exchange.ActionBuy {
+		return &b.asks
+	}
+	return &b.bids
+}
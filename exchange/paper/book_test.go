@@ -0,0 +1,61 @@
+package paper
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/exchange-api/exchange"
+)
+
+func newTestOrder(id int, price, volume float64) *order {
+	return &order{
+		Order: exchange.Order{
+			OrderID: exchange.OrderID(id),
+			Price:   decimal.NewFromFloat(price),
+		},
+		remaining: decimal.NewFromFloat(volume),
+	}
+}
+
+func TestSide(t *testing.T) {
+	b := newBook()
+
+	orders := []*order{
+		newTestOrder(1, 2, 1),
+		newTestOrder(2, 3, 2),
+		newTestOrder(3, 1, 3),
+		newTestOrder(4, 2, 4),
+	}
+	for _, o := range orders {
+		b.bids.add(o)
+		b.asks.add(o)
+	}
+
+	levels := func(s *side) []string {
+		var result []string
+		for _, o := range s.marketOrders() {
+			result = append(result, o.Price.String()+"x"+o.Volume.String())
+		}
+		return result
+	}
+
+	require.Equal(t, []string{"3x2", "2x5", "1x3"}, levels(&b.bids))
+	require.Equal(t, []string{"1x3", "2x5", "3x2"}, levels(&b.asks))
+
+	// orders at the same price keep their time priority
+	i, ok := b.asks.find(decimal.NewFromFloat(2.0))
+	require.True(t, ok)
+	require.Equal(t, []*order{orders[0], orders[3]}, b.asks.levels[i].orders)
+
+	require.True(t, b.asks.remove(orders[0]))
+	require.False(t, b.asks.remove(orders[0]))
+	require.True(t, b.asks.remove(orders[2]))
+	require.Equal(t, []string{"2x4", "3x2"}, levels(&b.asks))
+
+	b.bids.removeIf(func(o *order) bool { return o.OrderID != 2 })
+	require.Equal(t, []string{"3x2"}, levels(&b.bids))
+	require.Equal(t, &b.bids, b.side(exchange.ActionBuy))
+	require.Equal(t, &b.asks, b.opposite(exchange.ActionBuy))
+}
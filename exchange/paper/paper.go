@@ -0,0 +1,591 @@
+// Package paper provides a simulated exchange for paper trading. It implements exchange.Exchange with an
+// in-process price-time priority matching engine, virtual balances and fees, so strategies can be run
+// against it before being given real API keys.
+package paper
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/skycoin/exchange-api/exchange"
+)
+
+// DefaultVolumePrecision is the number of decimals of the volume bought by market buy orders if none is specified
+const DefaultVolumePrecision = 8
+
+var (
+	// ErrOrderNotOpen is returned when cancelling an order which was already completed or cancelled
+	ErrOrderNotOpen = errors.New("order is not open")
+
+	one = decimal.New(1, 0)
+)
+
+// Trade is a fill between two orders
+type Trade struct {
+	Timestamp time.Time       `json:"timestamp"`
+	Symbol    string          `json:"symbol"`
+	Price     decimal.Decimal `json:"price"`
+	Volume    decimal.Decimal `json:"volume"`
+	// Action is the side of the taker
+	Action       exchange.Action  `json:"action"`
+	TakerOrderID exchange.OrderID `json:"taker_order_id"`
+	MakerOrderID exchange.OrderID `json:"maker_order_id"`
+}
+
+type balance struct {
+	total  decimal.Decimal
+	frozen decimal.Decimal
+}
+
+// Exchange is a simulated exchange holding the balances of a single account.
+// Other market participants are represented by external orders, added with AddOrder or SetOrderbook,
+// which only match the account's orders.
+//
+// Fees are charged in the quote currency on every fill: buys pay price * volume * (1 + Fee) and sells
+// receive price * volume * (1 - Fee). Like C2CX, market buys take the quantity of the quote currency to spend,
+// fees included, and market sells the volume to sell. Market orders never rest on the book, the part
+// which can't be filled is cancelled.
+type Exchange struct {
+	// Fee is the fee rate, e.g. 0.002 for 0.2%
+	Fee decimal.Decimal
+	// VolumePrecision is the number of decimals of the volume bought by market buys
+	VolumePrecision int32
+	// Now returns the simulated time, the current time if nil. Backtests can replace it with a replay clock.
+	Now func() time.Time
+
+	name     string
+	codec    exchange.PairCodec
+	mu       sync.Mutex
+	books    map[string]*book
+	orders   map[exchange.OrderID]*order
+	balances map[exchange.Currency]*balance
+	trades   map[string][]Trade
+	nextID   exchange.OrderID
+}
+
+// New creates an empty Exchange. Pairs are encoded to symbols with codec, exchange.CanonicalCodec if nil.
+func New(name string, codec exchange.PairCodec, fee decimal.Decimal) *Exchange {
+	if codec == nil {
+		codec = exchange.CanonicalCodec
+	}
+
+	return &Exchange{
+		Fee:             fee,
+		VolumePrecision: DefaultVolumePrecision,
+		name:            name,
+		codec:           codec,
+		books:           make(map[string]*book),
+		orders:          make(map[exchange.OrderID]*order),
+		balances:        make(map[exchange.Currency]*balance),
+		trades:          make(map[string][]Trade),
+		nextID:          1,
+	}
+}
+
+func (e *Exchange) now() time.Time {
+	if e.Now != nil {
+		return e.Now()
+	}
+	return time.Now().UTC()
+}
+
+// Name returns the exchange's name
+func (e *Exchange) Name() string {
+	return e.name
+}
+
+// Deposit credits the account with amount of a currency
+func (e *Exchange) Deposit(currency exchange.Currency, amount decimal.Decimal) error {
+	if !amount.GreaterThan(decimal.Zero) {
+		return exchange.ErrNegativeAmount
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	b := e.balance(currency)
+	b.total = b.total.Add(amount)
+	return nil
+}
+
+func (e *Exchange) balance(currency exchange.Currency) *balance {
+	b, ok := e.balances[currency]
+	if !ok {
+		b = &balance{}
+		e.balances[currency] = b
+	}
+	return b
+}
+
+func (e *Exchange) book(symbol string) *book {
+	b, ok := e.books[symbol]
+	if !ok {
+		b = newBook()
+		e.books[symbol] = b
+	}
+	return b
+}
+
+// symbol encodes a Pair with the Exchange's codec, or returns exchange.ErrInvalidSymbol if it doesn't decode back to the Pair
+func (e *Exchange) symbol(pair exchange.Pair) (string, error) {
+	symbol := e.codec.EncodePair(pair)
+	if p, err := e.codec.DecodePair(symbol); err != nil || p != pair {
+		return "", exchange.ErrInvalidSymbol
+	}
+	return symbol, nil
+}
+
+// Codec returns the codec of the Exchange's symbols
+func (e *Exchange) Codec() exchange.PairCodec {
+	return e.codec
+}
+
+// GetOrderbook returns the aggregated levels of all resting orders of a market
+func (e *Exchange) GetOrderbook(pair exchange.Pair) (*exchange.MarketRecord, error) {
+	symbol, err := e.symbol(pair)
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	b := e.book(symbol)
+	return &exchange.MarketRecord{
+		Timestamp: e.now(),
+		Symbol:    symbol,
+		Bids:      b.bids.marketOrders(),
+		Asks:      b.asks.marketOrders(),
+	}, nil
+}
+
+// GetTicker returns the best quotes and the last price, high, low and volume of the trades of the last 24 hours
+func (e *Exchange) GetTicker(pair exchange.Pair) (*exchange.Ticker, error) {
+	symbol, err := e.symbol(pair)
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.now()
+	ticker := &exchange.Ticker{
+		Symbol:    symbol,
+		Timestamp: now,
+	}
+
+	b := e.book(symbol)
+	if len(b.bids.levels) > 0 {
+		ticker.Bid = b.bids.levels[0].price
+	}
+	if len(b.asks.levels) > 0 {
+		ticker.Ask = b.asks.levels[0].price
+	}
+
+	trades := e.trades[symbol]
+	if len(trades) > 0 {
+		ticker.Last = trades[len(trades)-1].Price
+	}
+
+	since := now.Add(-24 * time.Hour)
+	for i := len(trades) - 1; i >= 0 && trades[i].Timestamp.After(since); i-- {
+		t := trades[i]
+		if ticker.High.Equal(decimal.Zero) || t.Price.GreaterThan(ticker.High) {
+			ticker.High = t.Price
+		}
+		if ticker.Low.Equal(decimal.Zero) || t.Price.LessThan(ticker.Low) {
+			ticker.Low = t.Price
+		}
+		ticker.Volume = ticker.Volume.Add(t.Volume)
+	}
+
+	return ticker, nil
+}
+
+// GetBalances returns the account's balances of all currencies it has held
+func (e *Exchange) GetBalances() (exchange.Balances, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	result := make(exchange.Balances, len(e.balances))
+	for currency, b := range e.balances {
+		result[string(currency)] = exchange.Balance{
+			Currency:  string(currency),
+			Total:     b.total,
+			Available: b.total.Sub(b.frozen),
+			Frozen:    b.frozen,
+		}
+	}
+
+	return result, nil
+}
+
+// LimitBuy places a limit buy order, freezing price * volume plus fees of the quote currency
+func (e *Exchange) LimitBuy(pair exchange.Pair, price, volume decimal.Decimal) (exchange.OrderID, error) {
+	return e.place(pair, exchange.ActionBuy, price, volume, limitOrder)
+}
+
+// LimitSell places a limit sell order, freezing volume of the base currency
+func (e *Exchange) LimitSell(pair exchange.Pair, price, volume decimal.Decimal) (exchange.OrderID, error) {
+	return e.place(pair, exchange.ActionSell, price, volume, limitOrder)
+}
+
+// MarketBuy places a market buy order spending amount of the quote currency, fees included
+func (e *Exchange) MarketBuy(pair exchange.Pair, amount decimal.Decimal) (exchange.OrderID, error) {
+	return e.place(pair, exchange.ActionBuy, decimal.Zero, amount, marketOrder)
+}
+
+// MarketSell places a market sell order selling volume coins
+func (e *Exchange) MarketSell(pair exchange.Pair, volume decimal.Decimal) (exchange.OrderID, error) {
+	return e.place(pair, exchange.ActionSell, decimal.Zero, volume, marketOrder)
+}
+
+// AddOrder adds an external limit order, e.g. to simulate another trader. It only matches the account's orders.
+func (e *Exchange) AddOrder(pair exchange.Pair, action exchange.Action, price, volume decimal.Decimal) error {
+	_, err := e.place(pair, action, price, volume, externalOrder)
+	return err
+}
+
+// Cross fills the account's resting orders against an external immediate-or-cancel order,
+// e.g. to replay a historical trade. Nothing is added to the book.
+func (e *Exchange) Cross(pair exchange.Pair, action exchange.Action, price, volume decimal.Decimal) error {
+	_, err := e.place(pair, action, price, volume, externalCross)
+	return err
+}
+
+// SetOrderbook replaces the external orders of a market with one order per level of the record.
+// Levels crossing the account's resting orders fill them at the resting orders' prices.
+// The record is applied atomically: other calls never see a partly replaced book, and an invalid
+// record leaves the book unchanged.
+func (e *Exchange) SetOrderbook(r exchange.MarketRecord) error {
+	pair, err := e.codec.DecodePair(r.Symbol)
+	if err != nil {
+		return err
+	}
+
+	for _, levels := range [][]exchange.MarketOrder{r.Bids, r.Asks} {
+		for _, o := range levels {
+			if !o.Price.GreaterThan(decimal.Zero) || !o.Volume.GreaterThan(decimal.Zero) {
+				return exchange.ErrNegativeAmount
+			}
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	b := e.book(r.Symbol)
+	external := func(o *order) bool { return o.external }
+	b.bids.removeIf(external)
+	b.asks.removeIf(external)
+
+	for _, o := range r.Bids {
+		if _, err := e.placeLocked(pair, r.Symbol, exchange.ActionBuy, o.Price, o.Volume, externalOrder); err != nil {
+			return err
+		}
+	}
+
+	for _, o := range r.Asks {
+		if _, err := e.placeLocked(pair, r.Symbol, exchange.ActionSell, o.Price, o.Volume, externalOrder); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// orderKind is how an order is handled by place
+type orderKind int
+
+const (
+	// limitOrder is the account's limit order
+	limitOrder orderKind = iota
+	// marketOrder is the account's market order
+	marketOrder
+	// externalOrder is another trader's limit order
+	externalOrder
+	// externalCross is another trader's immediate-or-cancel limit order
+	externalCross
+)
+
+func (e *Exchange) place(pair exchange.Pair, action exchange.Action, price, volume decimal.Decimal, kind orderKind) (exchange.OrderID, error) {
+	if !volume.GreaterThan(decimal.Zero) || (kind != marketOrder && !price.GreaterThan(decimal.Zero)) {
+		return 0, exchange.ErrNegativeAmount
+	}
+
+	symbol, err := e.symbol(pair)
+	if err != nil {
+		return 0, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.placeLocked(pair, symbol, action, price, volume, kind)
+}
+
+// placeLocked places a validated order, e.mu must be held
+func (e *Exchange) placeLocked(pair exchange.Pair, symbol string, action exchange.Action, price, volume decimal.Decimal, kind orderKind) (exchange.OrderID, error) {
+	market := kind == marketOrder
+	external := kind == externalOrder || kind == externalCross
+
+	now := e.now()
+	o := &order{
+		Order: exchange.Order{
+			OrderID: e.nextID,
+			Symbol:  symbol,
+			Action:  action,
+			Status:  exchange.StatusOpened,
+			Price:   price,
+			Volume:  volume,
+			Created: now,
+		},
+		external:  external,
+		market:    market,
+		remaining: volume,
+	}
+
+	if !external {
+		currency, amount := pair.Base, volume
+		if action == exchange.ActionBuy {
+			currency = pair.Quote
+			if !market {
+				amount = price.Mul(volume).Mul(one.Add(e.Fee))
+			}
+		}
+
+		b := e.balance(currency)
+		if amount.GreaterThan(b.total.Sub(b.frozen)) {
+			return 0, exchange.ErrInsufficientBalance
+		}
+		b.frozen = b.frozen.Add(amount)
+		o.frozen = amount
+
+		e.orders[o.OrderID] = o
+	}
+	e.nextID++
+
+	b := e.book(symbol)
+	e.match(pair, b, o)
+
+	switch {
+	case market:
+		e.release(pair, o)
+		if action == exchange.ActionBuy {
+			o.Volume = o.Filled
+		}
+		o.Status = exchange.StatusCancelled
+		if o.Filled.GreaterThan(decimal.Zero) && (action == exchange.ActionBuy || o.remaining.Equal(decimal.Zero)) {
+			o.Status = exchange.StatusCompleted
+		}
+		o.Completed = now
+	case kind != externalCross && o.remaining.GreaterThan(decimal.Zero):
+		b.side(action).add(o)
+	}
+
+	return o.OrderID, nil
+}
+
+// match fills the taker against the opposite side of the book in price-time priority
+func (e *Exchange) match(pair exchange.Pair, b *book, taker *order) {
+	opposite := b.opposite(taker.Action)
+	marketBuy := taker.market && taker.Action == exchange.ActionBuy
+	done := false
+
+	for _, l := range opposite.levels {
+		if done || (!taker.market && opposite.better(taker.Price, l.price)) {
+			break
+		}
+
+		for _, maker := range l.orders {
+			if taker.external && maker.external {
+				continue
+			}
+
+			volume := maker.remaining
+			if marketBuy {
+				affordable := taker.frozen.Div(l.price.Mul(one.Add(e.Fee))).Truncate(e.VolumePrecision)
+				if !affordable.GreaterThan(decimal.Zero) {
+					done = true
+					break
+				}
+				volume = decimal.Min(volume, affordable)
+			} else {
+				volume = decimal.Min(volume, taker.remaining)
+			}
+
+			e.fill(pair, taker, maker, l.price, volume)
+
+			if !marketBuy && taker.remaining.Equal(decimal.Zero) {
+				done = true
+				break
+			}
+		}
+
+		orders := l.orders[:0]
+		for _, o := range l.orders {
+			if o.remaining.GreaterThan(decimal.Zero) {
+				orders = append(orders, o)
+			}
+		}
+		l.orders = orders
+	}
+
+	opposite.prune()
+}
+
+func (e *Exchange) fill(pair exchange.Pair, taker, maker *order, price, volume decimal.Decimal) {
+	now := e.now()
+
+	e.trades[taker.Symbol] = append(e.trades[taker.Symbol], Trade{
+		Timestamp:    now,
+		Symbol:       taker.Symbol,
+		Price:        price,
+		Volume:       volume,
+		Action:       taker.Action,
+		TakerOrderID: taker.OrderID,
+		MakerOrderID: maker.OrderID,
+	})
+
+	e.settle(pair, taker, price, volume, now)
+	e.settle(pair, maker, price, volume, now)
+}
+
+// settle updates an order and the account's balances after a fill
+func (e *Exchange) settle(pair exchange.Pair, o *order, price, volume decimal.Decimal, now time.Time) {
+	cost := price.Mul(volume)
+	fee := cost.Mul(e.Fee)
+
+	filled := o.Filled.Add(volume)
+	o.AvgPrice = o.AvgPrice.Mul(o.Filled).Add(cost).Div(filled)
+	o.Filled = filled
+	o.Fee = o.Fee.Add(fee)
+	o.remaining = o.remaining.Sub(volume)
+
+	if !o.market {
+		o.Status = exchange.StatusPartial
+		if o.remaining.Equal(decimal.Zero) {
+			o.Status = exchange.StatusCompleted
+			o.Completed = now
+		}
+	}
+
+	if o.external {
+		return
+	}
+
+	base := e.balance(pair.Base)
+	quote := e.balance(pair.Quote)
+
+	if o.Action == exchange.ActionBuy {
+		debit := cost.Add(fee)
+		reserved := debit
+		if !o.market {
+			reserved = o.Price.Mul(volume).Mul(one.Add(e.Fee))
+		}
+
+		quote.total = quote.total.Sub(debit)
+		quote.frozen = quote.frozen.Sub(reserved)
+		o.frozen = o.frozen.Sub(reserved)
+		base.total = base.total.Add(volume)
+	} else {
+		base.total = base.total.Sub(volume)
+		base.frozen = base.frozen.Sub(volume)
+		o.frozen = o.frozen.Sub(volume)
+		quote.total = quote.total.Add(cost.Sub(fee))
+	}
+}
+
+// release unfreezes the funds still reserved by an order
+func (e *Exchange) release(pair exchange.Pair, o *order) {
+	currency := pair.Base
+	if o.Action == exchange.ActionBuy {
+		currency = pair.Quote
+	}
+
+	b := e.balance(currency)
+	b.frozen = b.frozen.Sub(o.frozen)
+	o.frozen = decimal.Zero
+}
+
+// CancelOrder cancels an open order and unfreezes its funds
+func (e *Exchange) CancelOrder(pair exchange.Pair, orderID exchange.OrderID) error {
+	symbol, err := e.symbol(pair)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	o, ok := e.orders[orderID]
+	if !ok || o.Symbol != symbol {
+		return exchange.ErrOrderNotFound
+	}
+	if !o.Status.Open() {
+		return ErrOrderNotOpen
+	}
+
+	e.book(symbol).side(o.Action).remove(o)
+	e.release(pair, o)
+	o.Status = exchange.StatusCancelled
+	o.Completed = e.now()
+
+	return nil
+}
+
+// GetOpenOrders returns the account's resting orders of a market, ordered by ID
+func (e *Exchange) GetOpenOrders(pair exchange.Pair) ([]exchange.Order, error) {
+	symbol, err := e.symbol(pair)
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var result []exchange.Order
+	for _, o := range e.orders {
+		if o.Symbol == symbol && o.Status.Open() {
+			result = append(result, o.Order)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].OrderID < result[j].OrderID
+	})
+
+	return result, nil
+}
+
+// GetOrderStatus returns the latest state of one of the account's orders
+func (e *Exchange) GetOrderStatus(pair exchange.Pair, orderID exchange.OrderID) (*exchange.Order, error) {
+	symbol, err := e.symbol(pair)
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	o, ok := e.orders[orderID]
+	if !ok || o.Symbol != symbol {
+		return nil, exchange.ErrOrderNotFound
+	}
+
+	result := o.Order
+	return &result, nil
+}
+
+// Trades returns all trades of a market, including those between external orders and the account
+func (e *Exchange) Trades(pair exchange.Pair) []Trade {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return append([]Trade(nil), e.trades[e.codec.EncodePair(pair)]...)
+}
@@ -0,0 +1,373 @@
+package paper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/exchange-api/exchange"
+)
+
+const symbol = "SKY/BTC"
+
+var skyBtc = exchange.NewPair(exchange.SKY, exchange.BTC)
+
+func d(f float64) decimal.Decimal {
+	return decimal.NewFromFloat(f)
+}
+
+func newTestExchange(t *testing.T, fee float64) *Exchange {
+	e := New("paper", nil, d(fee))
+	now := time.Unix(1500000000, 0).UTC()
+	e.Now = func() time.Time { return now }
+	return e
+}
+
+func requireBalance(t *testing.T, e *Exchange, currency string, total, frozen float64) {
+	balances, err := e.GetBalances()
+	require.NoError(t, err)
+	b := balances[currency]
+	require.True(t, b.Total.Equal(d(total)), "%s total %s", currency, b.Total)
+	require.True(t, b.Frozen.Equal(d(frozen)), "%s frozen %s", currency, b.Frozen)
+	require.True(t, b.Available.Equal(d(total).Sub(d(frozen))), "%s available %s", currency, b.Available)
+}
+
+func requireLevels(t *testing.T, levels []exchange.MarketOrder, expected ...string) {
+	var result []string
+	for _, o := range levels {
+		result = append(result, o.Price.String()+"x"+o.Volume.String())
+	}
+	require.Equal(t, expected, result)
+}
+
+func TestExchangeImplementsExchange(t *testing.T) {
+	require.Implements(t, (*exchange.Exchange)(nil), New("paper", nil, decimal.Zero))
+}
+
+func TestLimitOrders(t *testing.T) {
+	e := newTestExchange(t, 0.01)
+	require.NoError(t, e.Deposit(exchange.BTC, d(100)))
+	require.NoError(t, e.AddOrder(skyBtc, exchange.ActionSell, d(9), d(5)))
+
+	// fills 5 at 9, the rest rests at 10
+	id, err := e.LimitBuy(skyBtc, d(10), d(8))
+	require.NoError(t, err)
+
+	o, err := e.GetOrderStatus(skyBtc, id)
+	require.NoError(t, err)
+	require.Equal(t, exchange.StatusPartial, o.Status)
+	require.True(t, o.Filled.Equal(d(5)))
+	require.True(t, o.AvgPrice.Equal(d(9)))
+	require.True(t, o.Fee.Equal(d(0.45)))
+
+	// frozen 3 * 10 * 1.01, spent 5 * 9 * 1.01
+	requireBalance(t, e, "BTC", 54.55, 30.3)
+	requireBalance(t, e, "SKY", 5, 0)
+
+	r, err := e.GetOrderbook(skyBtc)
+	require.NoError(t, err)
+	requireLevels(t, r.Bids, "10x3")
+	requireLevels(t, r.Asks)
+
+	// an external seller crossing the bid fills at the resting price
+	require.NoError(t, e.AddOrder(skyBtc, exchange.ActionSell, d(9.5), d(1)))
+	o, err = e.GetOrderStatus(skyBtc, id)
+	require.NoError(t, err)
+	require.True(t, o.Filled.Equal(d(6)))
+	require.Equal(t, "9.1666666666666667", o.AvgPrice.String())
+	requireBalance(t, e, "BTC", 44.45, 20.2)
+	requireBalance(t, e, "SKY", 6, 0)
+
+	open, err := e.GetOpenOrders(skyBtc)
+	require.NoError(t, err)
+	require.Len(t, open, 1)
+
+	require.NoError(t, e.CancelOrder(skyBtc, id))
+	require.Equal(t, ErrOrderNotOpen, e.CancelOrder(skyBtc, id))
+	requireBalance(t, e, "BTC", 44.45, 0)
+
+	o, err = e.GetOrderStatus(skyBtc, id)
+	require.NoError(t, err)
+	require.Equal(t, exchange.StatusCancelled, o.Status)
+
+	open, err = e.GetOpenOrders(skyBtc)
+	require.NoError(t, err)
+	require.Empty(t, open)
+
+	r, err = e.GetOrderbook(skyBtc)
+	require.NoError(t, err)
+	requireLevels(t, r.Bids)
+
+	// sell the coins back
+	require.NoError(t, e.AddOrder(skyBtc, exchange.ActionBuy, d(11), d(10)))
+	id, err = e.LimitSell(skyBtc, d(10), d(6))
+	require.NoError(t, err)
+	o, err = e.GetOrderStatus(skyBtc, id)
+	require.NoError(t, err)
+	require.Equal(t, exchange.StatusCompleted, o.Status)
+	require.True(t, o.AvgPrice.Equal(d(11)))
+	requireBalance(t, e, "SKY", 0, 0)
+	requireBalance(t, e, "BTC", 109.79, 0)
+}
+
+func TestPriceTimePriority(t *testing.T) {
+	e := newTestExchange(t, 0)
+	require.NoError(t, e.Deposit(exchange.BTC, d(100)))
+	require.NoError(t, e.Deposit(exchange.SKY, d(100)))
+
+	require.NoError(t, e.AddOrder(skyBtc, exchange.ActionSell, d(2), d(5)))
+	own, err := e.LimitSell(skyBtc, d(2), d(5))
+	require.NoError(t, err)
+	require.NoError(t, e.AddOrder(skyBtc, exchange.ActionSell, d(1.5), d(1)))
+
+	// external orders don't match each other
+	require.NoError(t, e.AddOrder(skyBtc, exchange.ActionBuy, d(1.8), d(1)))
+	r, err := e.GetOrderbook(skyBtc)
+	require.NoError(t, err)
+	requireLevels(t, r.Bids, "1.8x1")
+	requireLevels(t, r.Asks, "1.5x1", "2x10")
+
+	buy, err := e.LimitBuy(skyBtc, d(2), d(8))
+	require.NoError(t, err)
+
+	trades := e.Trades(skyBtc)
+	require.Len(t, trades, 3)
+	require.True(t, trades[0].Price.Equal(d(1.5)))
+	require.Equal(t, exchange.OrderID(3), trades[0].MakerOrderID)
+	require.Equal(t, exchange.OrderID(1), trades[1].MakerOrderID)
+	require.True(t, trades[1].Volume.Equal(d(5)))
+	require.Equal(t, own, trades[2].MakerOrderID)
+	require.True(t, trades[2].Volume.Equal(d(2)))
+	require.Equal(t, buy, trades[2].TakerOrderID)
+	require.Equal(t, exchange.ActionBuy, trades[2].Action)
+
+	o, err := e.GetOrderStatus(skyBtc, own)
+	require.NoError(t, err)
+	require.Equal(t, exchange.StatusPartial, o.Status)
+}
+
+func TestMarketBuy(t *testing.T) {
+	e := newTestExchange(t, 0)
+	require.NoError(t, e.Deposit(exchange.BTC, d(116)))
+	require.NoError(t, e.AddOrder(skyBtc, exchange.ActionSell, d(2), d(5)))
+	require.NoError(t, e.AddOrder(skyBtc, exchange.ActionSell, d(3), d(10)))
+
+	// 5 at 2 and 2 at 3
+	id, err := e.MarketBuy(skyBtc, d(16))
+	require.NoError(t, err)
+	o, err := e.GetOrderStatus(skyBtc, id)
+	require.NoError(t, err)
+	require.Equal(t, exchange.StatusCompleted, o.Status)
+	require.True(t, o.Volume.Equal(d(7)))
+	require.True(t, o.Filled.Equal(d(7)))
+	require.Equal(t, "2.2857142857142857", o.AvgPrice.String())
+	requireBalance(t, e, "BTC", 100, 0)
+	requireBalance(t, e, "SKY", 7, 0)
+
+	// the book runs out, the unspent amount is released
+	id, err = e.MarketBuy(skyBtc, d(100))
+	require.NoError(t, err)
+	o, err = e.GetOrderStatus(skyBtc, id)
+	require.NoError(t, err)
+	require.Equal(t, exchange.StatusCompleted, o.Status)
+	require.True(t, o.Filled.Equal(d(8)))
+	requireBalance(t, e, "BTC", 76, 0)
+	requireBalance(t, e, "SKY", 15, 0)
+
+	// nothing to buy
+	id, err = e.MarketBuy(skyBtc, d(10))
+	require.NoError(t, err)
+	o, err = e.GetOrderStatus(skyBtc, id)
+	require.NoError(t, err)
+	require.Equal(t, exchange.StatusCancelled, o.Status)
+	requireBalance(t, e, "BTC", 76, 0)
+
+	_, err = e.MarketBuy(skyBtc, d(77))
+	require.Equal(t, exchange.ErrInsufficientBalance, err)
+}
+
+func TestMarketBuyFee(t *testing.T) {
+	e := newTestExchange(t, 0.5)
+	e.VolumePrecision = 2
+	require.NoError(t, e.Deposit(exchange.BTC, d(10)))
+	require.NoError(t, e.AddOrder(skyBtc, exchange.ActionSell, d(3), d(10)))
+
+	// 10 / (3 * 1.5) = 2.22
+	id, err := e.MarketBuy(skyBtc, d(10))
+	require.NoError(t, err)
+	o, err := e.GetOrderStatus(skyBtc, id)
+	require.NoError(t, err)
+	require.True(t, o.Filled.Equal(d(2.22)))
+	require.True(t, o.Fee.Equal(d(3.33)))
+	requireBalance(t, e, "BTC", 0.01, 0)
+	requireBalance(t, e, "SKY", 2.22, 0)
+}
+
+func TestMarketSell(t *testing.T) {
+	e := newTestExchange(t, 0)
+	require.NoError(t, e.Deposit(exchange.SKY, d(5)))
+	require.NoError(t, e.AddOrder(skyBtc, exchange.ActionBuy, d(3), d(2)))
+	require.NoError(t, e.AddOrder(skyBtc, exchange.ActionBuy, d(2), d(2)))
+
+	id, err := e.MarketSell(skyBtc, d(5))
+	require.NoError(t, err)
+	o, err := e.GetOrderStatus(skyBtc, id)
+	require.NoError(t, err)
+	require.Equal(t, exchange.StatusCancelled, o.Status)
+	require.True(t, o.Filled.Equal(d(4)))
+	require.True(t, o.AvgPrice.Equal(d(2.5)))
+	requireBalance(t, e, "SKY", 1, 0)
+	requireBalance(t, e, "BTC", 10, 0)
+
+	_, err = e.MarketSell(skyBtc, d(2))
+	require.Equal(t, exchange.ErrInsufficientBalance, err)
+}
+
+func TestSetOrderbook(t *testing.T) {
+	e := newTestExchange(t, 0)
+	require.NoError(t, e.Deposit(exchange.BTC, d(100)))
+
+	id, err := e.LimitBuy(skyBtc, d(10), d(3))
+	require.NoError(t, err)
+
+	require.NoError(t, e.SetOrderbook(exchange.MarketRecord{
+		Symbol: symbol,
+		Bids:   []exchange.MarketOrder{{Price: d(8), Volume: d(2)}},
+		Asks: []exchange.MarketOrder{
+			{Price: d(9), Volume: d(1)},
+			{Price: d(11), Volume: d(5)},
+		},
+	}))
+
+	o, err := e.GetOrderStatus(skyBtc, id)
+	require.NoError(t, err)
+	require.True(t, o.Filled.Equal(d(1)))
+	require.True(t, o.AvgPrice.Equal(d(10)))
+
+	r, err := e.GetOrderbook(skyBtc)
+	require.NoError(t, err)
+	requireLevels(t, r.Bids, "10x2", "8x2")
+	requireLevels(t, r.Asks, "11x5")
+
+	require.NoError(t, e.SetOrderbook(exchange.MarketRecord{
+		Symbol: symbol,
+		Bids:   []exchange.MarketOrder{{Price: d(7), Volume: d(1)}},
+	}))
+
+	r, err = e.GetOrderbook(skyBtc)
+	require.NoError(t, err)
+	requireLevels(t, r.Bids, "10x2", "7x1")
+	requireLevels(t, r.Asks)
+
+	// an invalid level leaves the book unchanged
+	require.Equal(t, exchange.ErrNegativeAmount, e.SetOrderbook(exchange.MarketRecord{
+		Symbol: symbol,
+		Bids: []exchange.MarketOrder{
+			{Price: d(6), Volume: d(1)},
+			{Price: d(5), Volume: d(0)},
+		},
+	}))
+
+	r, err = e.GetOrderbook(skyBtc)
+	require.NoError(t, err)
+	requireLevels(t, r.Bids, "10x2", "7x1")
+
+	require.Equal(t, exchange.ErrInvalidSymbol, e.SetOrderbook(exchange.MarketRecord{Symbol: "SKYBTC"}))
+}
+
+func TestGetTicker(t *testing.T) {
+	e := newTestExchange(t, 0)
+	now := time.Unix(1500000000, 0).UTC()
+	e.Now = func() time.Time { return now }
+
+	require.NoError(t, e.Deposit(exchange.BTC, d(100)))
+	require.NoError(t, e.AddOrder(skyBtc, exchange.ActionSell, d(2), d(1)))
+	require.NoError(t, e.AddOrder(skyBtc, exchange.ActionSell, d(4), d(1)))
+	require.NoError(t, e.AddOrder(skyBtc, exchange.ActionSell, d(3), d(1)))
+	require.NoError(t, e.AddOrder(skyBtc, exchange.ActionSell, d(5), d(1)))
+	require.NoError(t, e.AddOrder(skyBtc, exchange.ActionBuy, d(1), d(1)))
+
+	// this trade is too old to count
+	_, err := e.LimitBuy(skyBtc, d(2), d(1))
+	require.NoError(t, err)
+
+	now = now.Add(25 * time.Hour)
+	_, err = e.LimitBuy(skyBtc, d(4), d(2))
+	require.NoError(t, err)
+
+	ticker, err := e.GetTicker(skyBtc)
+	require.NoError(t, err)
+	require.True(t, ticker.Timestamp.Equal(now))
+	require.True(t, ticker.Last.Equal(d(4)))
+	require.True(t, ticker.High.Equal(d(4)))
+	require.True(t, ticker.Low.Equal(d(3)))
+	require.True(t, ticker.Volume.Equal(d(2)))
+	require.True(t, ticker.Bid.Equal(d(1)))
+	require.True(t, ticker.Ask.Equal(d(5)))
+}
+
+func TestExchangeErrors(t *testing.T) {
+	e := newTestExchange(t, 0)
+	require.NoError(t, e.Deposit(exchange.BTC, d(10)))
+	require.Equal(t, exchange.ErrNegativeAmount, e.Deposit(exchange.BTC, decimal.Zero))
+
+	_, err := e.LimitBuy(skyBtc, d(1), d(11))
+	require.Equal(t, exchange.ErrInsufficientBalance, err)
+	_, err = e.LimitSell(skyBtc, d(1), d(1))
+	require.Equal(t, exchange.ErrInsufficientBalance, err)
+	_, err = e.LimitBuy(skyBtc, decimal.Zero, d(1))
+	require.Equal(t, exchange.ErrNegativeAmount, err)
+	_, err = e.LimitBuy(skyBtc, d(1), d(-1))
+	require.Equal(t, exchange.ErrNegativeAmount, err)
+	_, err = e.LimitBuy(exchange.Pair{Base: exchange.SKY}, d(1), d(1))
+	require.Equal(t, exchange.ErrInvalidSymbol, err)
+	_, err = e.GetOrderbook(exchange.NewPair("sky", exchange.BTC))
+	require.Equal(t, exchange.ErrInvalidSymbol, err)
+	_, err = e.GetTicker(exchange.Pair{})
+	require.Equal(t, exchange.ErrInvalidSymbol, err)
+
+	id, err := e.LimitBuy(skyBtc, d(1), d(1))
+	require.NoError(t, err)
+	_, err = e.GetOrderStatus(exchange.NewPair(exchange.SKY, exchange.ETH), id)
+	require.Equal(t, exchange.ErrOrderNotFound, err)
+	_, err = e.GetOrderStatus(skyBtc, id+1)
+	require.Equal(t, exchange.ErrOrderNotFound, err)
+	require.Equal(t, exchange.ErrOrderNotFound, e.CancelOrder(skyBtc, id+1))
+}
+
+func TestCodec(t *testing.T) {
+	e := New("c2cx", exchange.SeparatorCodec{Separator: "_", QuoteFirst: true}, decimal.Zero)
+	require.NoError(t, e.Deposit(exchange.BTC, d(10)))
+
+	id, err := e.LimitBuy(skyBtc, d(1), d(2))
+	require.NoError(t, err)
+	requireBalance(t, e, "BTC", 10, 2)
+
+	o, err := e.GetOrderStatus(skyBtc, id)
+	require.NoError(t, err)
+	require.Equal(t, "BTC_SKY", o.Symbol)
+}
+
+func TestCross(t *testing.T) {
+	e := newTestExchange(t, 0)
+	require.NoError(t, e.Deposit(exchange.BTC, d(100)))
+
+	id, err := e.LimitBuy(skyBtc, d(10), d(3))
+	require.NoError(t, err)
+	require.NoError(t, e.AddOrder(skyBtc, exchange.ActionBuy, d(9), d(5)))
+
+	// a trade below the bid fills it, the rest is not added to the book
+	require.NoError(t, e.Cross(skyBtc, exchange.ActionSell, d(8), d(4)))
+
+	o, err := e.GetOrderStatus(skyBtc, id)
+	require.NoError(t, err)
+	require.Equal(t, exchange.StatusCompleted, o.Status)
+	require.True(t, o.AvgPrice.Equal(d(10)))
+
+	r, err := e.GetOrderbook(skyBtc)
+	require.NoError(t, err)
+	requireLevels(t, r.Bids, "9x5")
+	requireLevels(t, r.Asks)
+}
@@ -0,0 +1,240 @@
+// Package ratelimit implements a token bucket rate limiter for API clients, with a bucket per endpoint,
+// a global bucket shared by all endpoints and priorities between waiting requests
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Priority orders the requests waiting for a token, higher first
+type Priority int
+
+const (
+	// PriorityLow is for requests which can wait, e.g. market data polling
+	PriorityLow Priority = -1
+	// PriorityNormal is the default priority
+	PriorityNormal Priority = 0
+	// PriorityHigh is for urgent requests, e.g. cancelling orders
+	PriorityHigh Priority = 1
+)
+
+// Rate allows Requests per Per, with bursts of up to Requests. The zero Rate is unlimited.
+type Rate struct {
+	Requests int
+	Per      time.Duration
+}
+
+// Unlimited returns true if the rate doesn't limit requests
+func (r Rate) Unlimited() bool {
+	return r.Requests <= 0 || r.Per <= 0
+}
+
+// bucket is a token bucket refilled continuously at its rate
+type bucket struct {
+	rate   Rate
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(rate Rate, now time.Time) *bucket {
+	return &bucket{
+		rate:   rate,
+		tokens: float64(rate.Requests),
+		last:   now,
+	}
+}
+
+func (b *bucket) refill(now time.Time) {
+	if b == nil || !now.After(b.last) {
+		return
+	}
+
+	b.tokens += float64(b.rate.Requests) * float64(now.Sub(b.last)) / float64(b.rate.Per)
+	if max := float64(b.rate.Requests); b.tokens > max {
+		b.tokens = max
+	}
+	b.last = now
+}
+
+// delay returns how long until the bucket has a token, zero for a nil bucket
+func (b *bucket) delay() time.Duration {
+	// tolerate the rounding errors of refill
+	if b == nil || b.tokens > 1-1e-9 {
+		return 0
+	}
+	return time.Duration(math.Ceil((1 - b.tokens) * float64(b.rate.Per) / float64(b.rate.Requests)))
+}
+
+func (b *bucket) take() {
+	if b != nil {
+		b.tokens--
+	}
+}
+
+type waiter struct {
+	endpoint string
+	priority Priority
+	seq      uint64
+	ready    chan struct{}
+}
+
+// Limiter limits the requests of one or more clients sharing an API key. A request takes a token from its
+// endpoint's bucket and from the global bucket. Waiting requests are served by priority, then in arrival order;
+// a request waiting for a global token holds back the lower priority ones.
+// Limiter is safe for concurrent use.
+type Limiter struct {
+	// Global is the rate of all the requests together
+	Global Rate
+	// Endpoint is the rate of each endpoint without an entry in Endpoints
+	Endpoint  Rate
+	Endpoints map[string]Rate
+	// Priorities are the priorities of endpoints, PriorityNormal if missing
+	Priorities map[string]Priority
+
+	mu      sync.Mutex
+	global  *bucket
+	buckets map[string]*bucket
+	waiters []*waiter
+	seq     uint64
+	timer   *time.Timer
+}
+
+// New creates a Limiter with a global rate and a rate for every endpoint
+func New(global, endpoint Rate) *Limiter {
+	return &Limiter{
+		Global:     global,
+		Endpoint:   endpoint,
+		Endpoints:  make(map[string]Rate),
+		Priorities: make(map[string]Priority),
+	}
+}
+
+// Wait blocks until a request to endpoint is allowed, or ctx is done
+func (l *Limiter) Wait(ctx context.Context, endpoint string) error {
+	l.mu.Lock()
+	l.seq++
+	w := &waiter{
+		endpoint: endpoint,
+		priority: l.Priorities[endpoint],
+		seq:      l.seq,
+		ready:    make(chan struct{}),
+	}
+	l.waiters = append(l.waiters, w)
+	l.dispatch()
+	l.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		// the token was taken anyway
+		return nil
+	default:
+	}
+
+	for i, x := range l.waiters {
+		if x == w {
+			l.waiters = append(l.waiters[:i], l.waiters[i+1:]...)
+			break
+		}
+	}
+	// the waiter may have held back others
+	l.dispatch()
+
+	return ctx.Err()
+}
+
+// bucket returns the bucket of an endpoint, nil if it is unlimited
+func (l *Limiter) bucket(endpoint string, now time.Time) *bucket {
+	rate, ok := l.Endpoints[endpoint]
+	if !ok {
+		rate = l.Endpoint
+	}
+	if rate.Unlimited() {
+		return nil
+	}
+
+	b, ok := l.buckets[endpoint]
+	if !ok || b.rate != rate {
+		if l.buckets == nil {
+			l.buckets = make(map[string]*bucket)
+		}
+		b = newBucket(rate, now)
+		l.buckets[endpoint] = b
+	}
+	return b
+}
+
+// dispatch wakes the waiters which can take tokens and schedules the next dispatch. l.mu must be held.
+func (l *Limiter) dispatch() {
+	now := time.Now()
+
+	if l.Global.Unlimited() {
+		l.global = nil
+	} else if l.global == nil || l.global.rate != l.Global {
+		l.global = newBucket(l.Global, now)
+	}
+	l.global.refill(now)
+
+	sort.SliceStable(l.waiters, func(i, j int) bool {
+		if l.waiters[i].priority != l.waiters[j].priority {
+			return l.waiters[i].priority > l.waiters[j].priority
+		}
+		return l.waiters[i].seq < l.waiters[j].seq
+	})
+
+	var (
+		next    time.Duration
+		waiting []*waiter
+	)
+	for i, w := range l.waiters {
+		b := l.bucket(w.endpoint, now)
+		b.refill(now)
+
+		if d := b.delay(); d > 0 {
+			// only this endpoint is exhausted, others can go ahead
+			waiting = append(waiting, w)
+			if next == 0 || d < next {
+				next = d
+			}
+			continue
+		}
+
+		if d := l.global.delay(); d > 0 {
+			// the remaining waiters wait for the global bucket, in priority order
+			waiting = append(waiting, l.waiters[i:]...)
+			if next == 0 || d < next {
+				next = d
+			}
+			break
+		}
+
+		b.take()
+		l.global.take()
+		close(w.ready)
+	}
+	l.waiters = waiting
+
+	if l.timer != nil {
+		l.timer.Stop()
+		l.timer = nil
+	}
+	if len(l.waiters) > 0 {
+		l.timer = time.AfterFunc(next, func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.dispatch()
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// elapsed returns how long f took
+func elapsed(f func()) time.Duration {
+	start := time.Now()
+	f()
+	return time.Since(start)
+}
+
+func TestRateUnlimited(t *testing.T) {
+	require.True(t, Rate{}.Unlimited())
+	require.True(t, Rate{Requests: 1}.Unlimited())
+	require.False(t, Rate{Requests: 1, Per: time.Second}.Unlimited())
+}
+
+func TestWait_Endpoint(t *testing.T) {
+	l := New(Rate{}, Rate{Requests: 2, Per: 200 * time.Millisecond})
+	ctx := context.Background()
+
+	// the burst is allowed right away
+	d := elapsed(func() {
+		require.NoError(t, l.Wait(ctx, "ticker"))
+		require.NoError(t, l.Wait(ctx, "ticker"))
+	})
+	require.True(t, d < 50*time.Millisecond, "%v", d)
+
+	// other endpoints have their own bucket
+	d = elapsed(func() {
+		require.NoError(t, l.Wait(ctx, "getorderbook"))
+	})
+	require.True(t, d < 50*time.Millisecond, "%v", d)
+
+	// then one token every 100ms
+	d = elapsed(func() {
+		require.NoError(t, l.Wait(ctx, "ticker"))
+	})
+	require.True(t, d >= 80*time.Millisecond, "%v", d)
+}
+
+func TestWait_EndpointOverride(t *testing.T) {
+	l := New(Rate{}, Rate{Requests: 1, Per: time.Hour})
+	l.Endpoints["cancelorder"] = Rate{}
+	ctx := context.Background()
+
+	d := elapsed(func() {
+		for i := 0; i < 10; i++ {
+			require.NoError(t, l.Wait(ctx, "cancelorder"))
+		}
+	})
+	require.True(t, d < 50*time.Millisecond, "%v", d)
+}
+
+func TestWait_Global(t *testing.T) {
+	l := New(Rate{Requests: 1, Per: 100 * time.Millisecond}, Rate{})
+	ctx := context.Background()
+
+	require.NoError(t, l.Wait(ctx, "ticker"))
+	d := elapsed(func() {
+		require.NoError(t, l.Wait(ctx, "getorderbook"))
+	})
+	require.True(t, d >= 80*time.Millisecond, "%v", d)
+}
+
+func TestWait_Priority(t *testing.T) {
+	l := New(Rate{Requests: 1, Per: 100 * time.Millisecond}, Rate{})
+	l.Priorities["cancelorder"] = PriorityHigh
+	l.Priorities["ticker"] = PriorityLow
+	ctx := context.Background()
+
+	require.NoError(t, l.Wait(ctx, "ticker"))
+
+	var (
+		mu    sync.Mutex
+		order []string
+		wg    sync.WaitGroup
+	)
+	wait := func(endpoint string) {
+		defer wg.Done()
+		require.NoError(t, l.Wait(ctx, endpoint))
+		mu.Lock()
+		order = append(order, endpoint)
+		mu.Unlock()
+	}
+
+	wg.Add(3)
+	go wait("ticker")
+	time.Sleep(10 * time.Millisecond)
+	go wait("getbalance")
+	time.Sleep(10 * time.Millisecond)
+	go wait("cancelorder")
+	wg.Wait()
+
+	require.Equal(t, []string{"cancelorder", "getbalance", "ticker"}, order)
+}
+
+func TestWait_EndpointDoesNotBlockOthers(t *testing.T) {
+	l := New(Rate{}, Rate{Requests: 1, Per: time.Hour})
+	l.Priorities["ticker"] = PriorityHigh
+
+	require.NoError(t, l.Wait(context.Background(), "ticker"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- l.Wait(ctx, "ticker")
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	// the waiting ticker request doesn't hold back another endpoint
+	d := elapsed(func() {
+		require.NoError(t, l.Wait(context.Background(), "getorderbook"))
+	})
+	require.True(t, d < 50*time.Millisecond, "%v", d)
+
+	cancel()
+	require.Equal(t, context.Canceled, <-done)
+
+	l.mu.Lock()
+	require.Empty(t, l.waiters)
+	l.mu.Unlock()
+}
+
+func TestWait_Concurrent(t *testing.T) {
+	l := New(Rate{Requests: 5, Per: 100 * time.Millisecond}, Rate{Requests: 3, Per: 100 * time.Millisecond})
+
+	var wg sync.WaitGroup
+	d := elapsed(func() {
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			endpoint := "a"
+			if i%2 == 1 {
+				endpoint = "b"
+			}
+			go func() {
+				defer wg.Done()
+				require.NoError(t, l.Wait(context.Background(), endpoint))
+			}()
+		}
+		wg.Wait()
+	})
+
+	// 5 requests at once, then 5 more at 20ms intervals of the global bucket, a and b both within 3 per 100ms
+	require.True(t, d >= 90*time.Millisecond, "%v", d)
+	require.True(t, d < time.Second, "%v", d)
+}
@@ -0,0 +1,133 @@
+package recorder
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Files returns the recording files of a prefix in a directory, oldest first
+func Files(dir, prefix string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, prefix+"-*"+fileExt))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// Reader reads Records from a sequence of recording files
+type Reader struct {
+	files []string
+	file  *os.File
+	lines *bufio.Reader
+}
+
+// NewReader creates a Reader of files, which are read in the given order
+func NewReader(files ...string) *Reader {
+	return &Reader{
+		files: files,
+	}
+}
+
+// Open creates a Reader of all the recording files of a prefix in a directory
+func Open(dir, prefix string) (*Reader, error) {
+	files, err := Files(dir, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewReader(files...), nil
+}
+
+// Next returns the next record, or io.EOF after the last one.
+// A file cut short, e.g. the file being written by a recorder that crashed, ends with io.ErrUnexpectedEOF.
+func (r *Reader) Next() (*Record, error) {
+	for {
+		if r.lines == nil {
+			if len(r.files) == 0 {
+				return nil, io.EOF
+			}
+			name := r.files[0]
+			r.files = r.files[1:]
+			switch err := r.open(name); err {
+			case nil:
+			case io.EOF:
+				// the recorder stopped before writing the file's first block
+				continue
+			default:
+				return nil, err
+			}
+		}
+
+		line, err := r.lines.ReadBytes('\n')
+		switch {
+		case err == io.EOF && len(line) == 0:
+			if err := r.Close(); err != nil {
+				return nil, err
+			}
+			continue
+		case err == io.EOF:
+			// the last record was torn before its newline was written
+			return nil, io.ErrUnexpectedEOF
+		case err != nil:
+			return nil, err
+		}
+
+		var rec Record
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return nil, err
+		}
+		return &rec, nil
+	}
+}
+
+// ReadAll reads the remaining records
+func (r *Reader) ReadAll() ([]Record, error) {
+	var result []Record
+	for {
+		rec, err := r.Next()
+		switch err {
+		case nil:
+			result = append(result, *rec)
+		case io.EOF:
+			return result, nil
+		default:
+			return result, err
+		}
+	}
+}
+
+func (r *Reader) open(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+
+	gz, err := gzip.NewReader(bufio.NewReader(f))
+	if err != nil {
+		f.Close() // nolint: errcheck
+		return err
+	}
+
+	r.file = f
+	r.lines = bufio.NewReader(gz)
+	return nil
+}
+
+// Close closes the file being read. Next continues with the following file.
+func (r *Reader) Close() error {
+	if r.file == nil {
+		return nil
+	}
+
+	err := r.file.Close()
+	r.file = nil
+	r.lines = nil
+	return err
+}
@@ -0,0 +1,55 @@
+// Package recorder polls exchanges for market data and stores it in rotating gzip-compressed JSON lines files
+package recorder
+
+import (
+	"errors"
+	"time"
+
+	"github.com/skycoin/exchange-api/exchange"
+	"github.com/skycoin/exchange-api/exchange/cryptopia"
+)
+
+// RecordType is the kind of data held by a Record
+type RecordType string
+
+const (
+	// TypeOrderbook is an orderbook snapshot
+	TypeOrderbook RecordType = "orderbook"
+	// TypeTicker is a ticker sample
+	TypeTicker RecordType = "ticker"
+	// TypeTrades is a batch of Cryptopia market history
+	TypeTrades RecordType = "trades"
+)
+
+// ErrInvalidRecord is returned when a Record's Type doesn't match the data it holds
+var ErrInvalidRecord = errors.New("record type doesn't match its data")
+
+// Record is one line of a recording. Exactly one of Orderbook, Ticker and Trades is set, depending on Type.
+// Orderbook uses MarketRecord's JSON form.
+type Record struct {
+	Time      time.Time                 `json:"time"`
+	Exchange  string                    `json:"exchange"`
+	Symbol    string                    `json:"symbol"`
+	Type      RecordType                `json:"type"`
+	Orderbook *exchange.MarketRecord    `json:"orderbook,omitempty"`
+	Ticker    *exchange.Ticker          `json:"ticker,omitempty"`
+	Trades    []cryptopia.MarketHistory `json:"trades,omitempty"`
+}
+
+// Validate checks that the record holds the data of its Type
+func (r Record) Validate() error {
+	var ok bool
+	switch r.Type {
+	case TypeOrderbook:
+		ok = r.Orderbook != nil && r.Ticker == nil && r.Trades == nil
+	case TypeTicker:
+		ok = r.Ticker != nil && r.Orderbook == nil && r.Trades == nil
+	case TypeTrades:
+		ok = r.Trades != nil && r.Orderbook == nil && r.Ticker == nil
+	}
+
+	if !ok {
+		return ErrInvalidRecord
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package recorder
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultPollInterval is the default interval between two polls of the sources
+const DefaultPollInterval = time.Minute
+
+// Recorder polls Sources on an interval and writes their records
+type Recorder struct {
+	Sources      []Source
+	Writer       *Writer
+	PollInterval time.Duration
+	// Stop stops Run when closed
+	Stop chan struct{}
+	// OnError, if set, is called with the error of every failed poll of a source
+	OnError func(error)
+
+	mu      sync.RWMutex
+	updated time.Time
+	records int
+	err     error
+}
+
+// NewRecorder creates a Recorder writing the records of sources to w
+func NewRecorder(w *Writer, interval time.Duration, sources ...Source) *Recorder {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+
+	return &Recorder{
+		Sources:      sources,
+		Writer:       w,
+		PollInterval: interval,
+		Stop:         make(chan struct{}),
+	}
+}
+
+// Run polls the sources every PollInterval until Stop is closed, then closes the Writer
+func (r *Recorder) Run() {
+	ticker := time.NewTicker(r.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		r.Poll() // nolint: errcheck
+
+		select {
+		case <-r.Stop:
+			r.Writer.Close() // nolint: errcheck
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll polls every source once, writes their records and flushes the Writer.
+// A failing source doesn't prevent the others from being recorded, the first error is returned.
+// Sources implementing Committer are committed once all their records are written and flushed.
+func (r *Recorder) Poll() error {
+	now := time.Now().UTC()
+
+	var (
+		firstErr  error
+		written   int
+		committed []Committer
+	)
+	fail := func(err error) {
+		if firstErr == nil {
+			firstErr = err
+		}
+		if r.OnError != nil {
+			r.OnError(err)
+		}
+	}
+
+	for _, s := range r.Sources {
+		records, err := s.Poll(now)
+		if err != nil {
+			fail(err)
+			continue
+		}
+
+		ok := true
+		for _, rec := range records {
+			if err := r.Writer.Write(rec); err != nil {
+				fail(err)
+				ok = false
+				continue
+			}
+			written++
+		}
+
+		if c, isCommitter := s.(Committer); isCommitter && ok {
+			committed = append(committed, c)
+		}
+	}
+
+	if err := r.Writer.Flush(); err != nil {
+		fail(err)
+	} else {
+		for _, c := range committed {
+			c.Commit()
+		}
+	}
+
+	r.mu.Lock()
+	r.err = firstErr
+	r.records += written
+	if firstErr == nil {
+		r.updated = now
+	}
+	r.mu.Unlock()
+
+	return firstErr
+}
+
+// Records returns the number of records written
+func (r *Recorder) Records() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.records
+}
+
+// LastUpdate returns the time of the last poll without errors
+func (r *Recorder) LastUpdate() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.updated
+}
+
+// Err returns the first error of the last poll
+func (r *Recorder) Err() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.err
+}
@@ -0,0 +1,86 @@
+package recorder
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorderPoll(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	errUnavailable := errors.New("unavailable")
+	var errs []error
+
+	r := NewRecorder(NewWriter(dir, "market"), 0,
+		SourceFunc(func(now time.Time) ([]Record, error) {
+			return nil, errUnavailable
+		}),
+		SourceFunc(func(now time.Time) ([]Record, error) {
+			return []Record{newOrderbookRecord(now)}, nil
+		}),
+	)
+	r.OnError = func(err error) {
+		errs = append(errs, err)
+	}
+	require.Equal(t, DefaultPollInterval, r.PollInterval)
+
+	require.Equal(t, errUnavailable, r.Poll())
+	require.Equal(t, errUnavailable, r.Err())
+	require.True(t, r.LastUpdate().IsZero())
+	require.Equal(t, []error{errUnavailable}, errs)
+	require.Equal(t, 1, r.Records())
+
+	r.Sources = r.Sources[1:]
+	require.NoError(t, r.Poll())
+	require.NoError(t, r.Err())
+	require.False(t, r.LastUpdate().IsZero())
+	require.Equal(t, 2, r.Records())
+
+	close(r.Stop)
+	r.Run()
+
+	reader, err := Open(dir, "market")
+	require.NoError(t, err)
+	records, err := reader.ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+}
+
+// committerSource returns its records and counts its commits
+type committerSource struct {
+	records []Record
+	commits int
+}
+
+func (s *committerSource) Poll(now time.Time) ([]Record, error) {
+	return s.records, nil
+}
+
+func (s *committerSource) Commit() {
+	s.commits++
+}
+
+func TestRecorderPoll_Commit(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	invalid := newOrderbookRecord(start)
+	invalid.Type = TypeTicker
+
+	s := &committerSource{records: []Record{newOrderbookRecord(start), invalid}}
+	r := NewRecorder(NewWriter(dir, "market"), 0, s)
+	defer r.Writer.Close() // nolint: errcheck
+
+	// a source is not committed if one of its records failed to be written
+	require.Equal(t, ErrInvalidRecord, r.Poll())
+	require.Equal(t, 0, s.commits)
+
+	s.records = s.records[:1]
+	require.NoError(t, r.Poll())
+	require.Equal(t, 1, s.commits)
+}
@@ -0,0 +1,152 @@
+package recorder
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/skycoin/exchange-api/exchange"
+	"github.com/skycoin/exchange-api/exchange/cryptopia"
+	"github.com/skycoin/exchange-api/exchange/internal/dedupe"
+)
+
+// Source polls one kind of market data of one market.
+// Poll returns the records to store, possibly none, stamped with now.
+type Source interface {
+	Poll(now time.Time) ([]Record, error)
+}
+
+// SourceFunc adapts a function to the Source interface
+type SourceFunc func(now time.Time) ([]Record, error)
+
+// Poll calls f
+func (f SourceFunc) Poll(now time.Time) ([]Record, error) {
+	return f(now)
+}
+
+// OrderbookSource records the orderbook of a market, e.g. c2cx GetOrderbook or cryptopia GetMarketOrders.
+// Records are stamped with the market's symbol in the exchange's own format.
+func OrderbookSource(e exchange.Exchange, pair exchange.Pair) Source {
+	symbol := e.Codec().EncodePair(pair)
+	return SourceFunc(func(now time.Time) ([]Record, error) {
+		orderbook, err := e.GetOrderbook(pair)
+		if err != nil {
+			return nil, err
+		}
+
+		return []Record{{
+			Time:      now,
+			Exchange:  e.Name(),
+			Symbol:    symbol,
+			Type:      TypeOrderbook,
+			Orderbook: orderbook,
+		}}, nil
+	})
+}
+
+// TickerSource records the ticker of a market, stamped with its symbol in the exchange's own format
+func TickerSource(e exchange.Exchange, pair exchange.Pair) Source {
+	symbol := e.Codec().EncodePair(pair)
+	return SourceFunc(func(now time.Time) ([]Record, error) {
+		ticker, err := e.GetTicker(pair)
+		if err != nil {
+			return nil, err
+		}
+
+		return []Record{{
+			Time:     now,
+			Exchange: e.Name(),
+			Symbol:   symbol,
+			Type:     TypeTicker,
+			Ticker:   ticker,
+		}}, nil
+	})
+}
+
+// Committer is implemented by Sources which must only advance past their records once they are stored.
+// Recorder calls Commit after the records of a Poll were written and flushed.
+type Committer interface {
+	Commit()
+}
+
+// HistorySource records the trades of a Cryptopia market.
+// Every poll fetches the last hours of history, only the trades not returned by previous polls are recorded.
+// Trades sharing the latest timestamp are told apart by their type, price and amount.
+// The trades returned by Poll are only considered recorded after Commit, a failed write records them again.
+type HistorySource struct {
+	Fetch  func(market string, hours int) ([]cryptopia.MarketHistory, error)
+	Market string
+	Hours  int
+
+	window  dedupe.Window
+	pending *dedupe.Window
+}
+
+// NewHistorySource creates a HistorySource polling c's GetMarketHistory
+func NewHistorySource(c *cryptopia.Client, market string, hours int) *HistorySource {
+	return &HistorySource{
+		Fetch:  c.GetMarketHistory,
+		Market: market,
+		Hours:  hours,
+	}
+}
+
+// historyKey identifies a trade within a timestamp. Decimals can't be compared with ==.
+func historyKey(h cryptopia.MarketHistory) string {
+	return fmt.Sprintf("%s %s %s", h.Type, h.Price, h.Amount)
+}
+
+// Poll fetches the market history and returns a TypeTrades record of the new trades, oldest first,
+// or no record if there are none
+func (s *HistorySource) Poll(now time.Time) ([]Record, error) {
+	s.pending = nil
+
+	history, err := s.Fetch(s.Market, s.Hours)
+	if err != nil {
+		return nil, err
+	}
+
+	// history is ordered from the newest trade
+	sorted := make([]cryptopia.MarketHistory, 0, len(history))
+	for i := len(history) - 1; i >= 0; i-- {
+		sorted = append(sorted, history[i])
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp < sorted[j].Timestamp
+	})
+
+	items := make([]dedupe.Item, len(sorted))
+	for i, h := range sorted {
+		items[i] = dedupe.Item{
+			Time: time.Unix(int64(h.Timestamp), 0),
+			Key:  historyKey(h),
+		}
+	}
+
+	fresh, next := s.window.Next(items)
+	if len(fresh) == 0 {
+		return nil, nil
+	}
+	s.pending = &next
+
+	trades := make([]cryptopia.MarketHistory, len(fresh))
+	for i, j := range fresh {
+		trades[i] = sorted[j]
+	}
+
+	return []Record{{
+		Time:     now,
+		Exchange: "cryptopia",
+		Symbol:   s.Market,
+		Type:     TypeTrades,
+		Trades:   trades,
+	}}, nil
+}
+
+// Commit marks the trades returned by the last Poll as recorded
+func (s *HistorySource) Commit() {
+	if s.pending != nil {
+		s.window = *s.pending
+		s.pending = nil
+	}
+}
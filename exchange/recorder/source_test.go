@@ -0,0 +1,137 @@
+package recorder
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/exchange-api/exchange"
+	"github.com/skycoin/exchange-api/exchange/cryptopia"
+	"github.com/skycoin/exchange-api/exchange/paper"
+)
+
+var skyBtc = exchange.NewPair(exchange.SKY, exchange.BTC)
+
+func TestOrderbookAndTickerSource(t *testing.T) {
+	book := *newOrderbookRecord(start).Orderbook
+	book.Symbol = "SKY/BTC"
+
+	e := paper.New("paper", exchange.CanonicalCodec, decimal.Zero)
+	require.NoError(t, e.SetOrderbook(book))
+
+	records, err := OrderbookSource(e, skyBtc).Poll(start)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Equal(t, TypeOrderbook, records[0].Type)
+	require.Equal(t, "paper", records[0].Exchange)
+	require.Equal(t, "SKY/BTC", records[0].Symbol)
+	require.Equal(t, start, records[0].Time)
+	require.NoError(t, records[0].Validate())
+	require.True(t, records[0].Orderbook.CheapestAsk().Price.Equal(decimal.New(4, 0)))
+
+	records, err = TickerSource(e, skyBtc).Poll(start)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Equal(t, TypeTicker, records[0].Type)
+	require.NoError(t, records[0].Validate())
+	require.True(t, records[0].Ticker.Bid.Equal(decimal.New(3, 0)))
+
+	_, err = OrderbookSource(e, exchange.Pair{Base: exchange.SKY}).Poll(start)
+	require.Error(t, err)
+}
+
+func trade(offset time.Duration, price int64) cryptopia.MarketHistory {
+	return cryptopia.MarketHistory{
+		Label:     "SKY/BTC",
+		Type:      cryptopia.Buy,
+		Price:     decimal.New(price, 0),
+		Amount:    decimal.New(1, 0),
+		Timestamp: int(start.Add(offset).Unix()),
+	}
+}
+
+func TestHistorySource(t *testing.T) {
+	var (
+		history []cryptopia.MarketHistory
+		err     error
+	)
+	s := &HistorySource{
+		Market: "SKY/BTC",
+		Hours:  1,
+		Fetch: func(market string, hours int) ([]cryptopia.MarketHistory, error) {
+			require.Equal(t, "SKY/BTC", market)
+			require.Equal(t, 1, hours)
+			return history, err
+		},
+	}
+
+	prices := func(records []Record) []int64 {
+		require.Len(t, records, 1)
+		require.NoError(t, records[0].Validate())
+		var result []int64
+		for _, h := range records[0].Trades {
+			result = append(result, h.Price.IntPart())
+		}
+		return result
+	}
+
+	// newest first, with two identical trades
+	history = []cryptopia.MarketHistory{trade(time.Minute, 3), trade(time.Minute, 2), trade(time.Minute, 2), trade(0, 1)}
+	records, err := s.Poll(start)
+	require.NoError(t, err)
+	require.Equal(t, []int64{1, 2, 2, 3}, prices(records))
+	s.Commit()
+
+	// nothing new in a fresh copy of the same window
+	history = []cryptopia.MarketHistory{trade(time.Minute, 3), trade(time.Minute, 2), trade(time.Minute, 2), trade(0, 1)}
+	records, err = s.Poll(start)
+	require.NoError(t, err)
+	require.Empty(t, records)
+
+	// a late trade within the last second, another identical one and a newer one
+	history = []cryptopia.MarketHistory{trade(2*time.Minute, 5), trade(time.Minute, 4), trade(time.Minute, 3),
+		trade(time.Minute, 2), trade(time.Minute, 2), trade(time.Minute, 2), trade(0, 1)}
+	records, err = s.Poll(start)
+	require.NoError(t, err)
+	require.Equal(t, []int64{2, 4, 5}, prices(records))
+	s.Commit()
+
+	err = errors.New("unavailable")
+	_, err2 := s.Poll(start)
+	require.Equal(t, err, err2)
+}
+
+func TestHistorySource_NewTimestamp(t *testing.T) {
+	var history []cryptopia.MarketHistory
+	s := &HistorySource{
+		Market: "SKY/BTC",
+		Fetch: func(market string, hours int) ([]cryptopia.MarketHistory, error) {
+			return history, nil
+		},
+	}
+
+	history = []cryptopia.MarketHistory{trade(0, 1)}
+	records, err := s.Poll(start)
+	require.NoError(t, err)
+	require.Len(t, records[0].Trades, 1)
+	s.Commit()
+
+	// a window without the earlier trade, with two trades identical to it at a newer timestamp
+	history = []cryptopia.MarketHistory{trade(10*time.Second, 1), trade(10*time.Second, 1)}
+	records, err = s.Poll(start)
+	require.NoError(t, err)
+	require.Len(t, records[0].Trades, 2)
+
+	// the trades are returned again until they are committed
+	records, err = s.Poll(start)
+	require.NoError(t, err)
+	require.Len(t, records[0].Trades, 2)
+	s.Commit()
+
+	records, err = s.Poll(start)
+	require.NoError(t, err)
+	require.Empty(t, records)
+}
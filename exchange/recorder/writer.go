@@ -0,0 +1,157 @@
+package recorder
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultMaxSize is the default uncompressed size after which a Writer starts a new file
+	DefaultMaxSize = 64 << 20
+	// DefaultMaxAge is the default age after which a Writer starts a new file
+	DefaultMaxAge = time.Hour
+
+	fileExt    = ".jsonl.gz"
+	timeLayout = "20060102T150405Z"
+)
+
+// Writer appends Records to gzip-compressed JSON lines files in a directory.
+// A new file is started when the current one reaches MaxSize uncompressed bytes or MaxAge.
+// Files are named <Prefix>-<UTC start time>.jsonl.gz, so they sort in time order.
+// Writer is safe for concurrent use.
+type Writer struct {
+	Dir     string
+	Prefix  string
+	MaxSize int64
+	MaxAge  time.Duration
+	// Now returns the current time, time.Now if nil
+	Now func() time.Time
+
+	mu      sync.Mutex
+	file    *os.File
+	gz      *gzip.Writer
+	buf     *bufio.Writer
+	size    int64
+	started time.Time
+}
+
+// NewWriter creates a Writer with the default limits
+func NewWriter(dir, prefix string) *Writer {
+	return &Writer{
+		Dir:     dir,
+		Prefix:  prefix,
+		MaxSize: DefaultMaxSize,
+		MaxAge:  DefaultMaxAge,
+	}
+}
+
+func (w *Writer) now() time.Time {
+	if w.Now != nil {
+		return w.Now()
+	}
+	return time.Now()
+}
+
+// Write appends a record, rotating the file first if needed
+func (w *Writer) Write(r Record) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := w.now()
+	if w.file != nil && w.full(now) {
+		if err := w.close(); err != nil {
+			return err
+		}
+	}
+
+	if w.file == nil {
+		if err := w.open(now); err != nil {
+			return err
+		}
+	}
+
+	n, err := w.buf.Write(b)
+	w.size += int64(n)
+	return err
+}
+
+func (w *Writer) full(now time.Time) bool {
+	return (w.MaxSize > 0 && w.size >= w.MaxSize) || (w.MaxAge > 0 && now.Sub(w.started) >= w.MaxAge)
+}
+
+func (w *Writer) open(now time.Time) error {
+	if err := os.MkdirAll(w.Dir, 0750); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s%s", w.Prefix, now.UTC().Format(timeLayout), fileExt)
+	f, err := os.OpenFile(filepath.Join(w.Dir, name), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
+	if err != nil {
+		return err
+	}
+
+	w.file = f
+	w.gz = gzip.NewWriter(f)
+	w.buf = bufio.NewWriter(w.gz)
+	w.size = 0
+	w.started = now
+	return nil
+}
+
+// Flush writes the buffered records to the current file, so that they survive a crash.
+// Each flush ends a gzip block, flushing after every record hurts the compression.
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+
+	if err := w.buf.Flush(); err != nil {
+		return err
+	}
+	return w.gz.Flush()
+}
+
+// Close closes the current file. The next Write starts a new one.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	return w.close()
+}
+
+func (w *Writer) close() error {
+	err := w.buf.Flush()
+	if e := w.gz.Close(); err == nil {
+		err = e
+	}
+	if e := w.file.Close(); err == nil {
+		err = e
+	}
+
+	w.file = nil
+	w.gz = nil
+	w.buf = nil
+	return err
+}
@@ -0,0 +1,178 @@
+package recorder
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/exchange-api/exchange"
+)
+
+var start = time.Date(2018, 3, 20, 10, 0, 0, 0, time.UTC)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "recorder")
+	require.NoError(t, err)
+	return dir
+}
+
+func newOrderbookRecord(ts time.Time) Record {
+	return Record{
+		Time:     ts,
+		Exchange: "c2cx",
+		Symbol:   "BTC_SKY",
+		Type:     TypeOrderbook,
+		Orderbook: &exchange.MarketRecord{
+			Timestamp: ts,
+			Symbol:    "BTC_SKY",
+			Bids:      []exchange.MarketOrder{{Price: decimal.New(3, 0), Volume: decimal.New(7, 0)}},
+			Asks:      []exchange.MarketOrder{{Price: decimal.New(4, 0), Volume: decimal.New(5, 0)}},
+		},
+	}
+}
+
+func TestWriterReader(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	now := start
+	w := NewWriter(dir, "market")
+	w.MaxAge = time.Minute
+	w.Now = func() time.Time { return now }
+
+	var written []Record
+	for i := 0; i < 5; i++ {
+		rec := newOrderbookRecord(now)
+		require.NoError(t, w.Write(rec))
+		written = append(written, rec)
+		now = now.Add(30 * time.Second)
+	}
+	require.NoError(t, w.Close())
+
+	files, err := Files(dir, "market")
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+	require.Equal(t, "market-20180320T100000Z.jsonl.gz", filepath.Base(files[0]))
+
+	r, err := Open(dir, "market")
+	require.NoError(t, err)
+	records, err := r.ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, len(written))
+
+	for i, rec := range records {
+		require.True(t, written[i].Time.Equal(rec.Time))
+		require.Equal(t, TypeOrderbook, rec.Type)
+		require.Equal(t, written[i].Orderbook.Timestamp.Unix(), rec.Orderbook.Timestamp.Unix())
+		require.True(t, rec.Orderbook.Bids[0].Price.Equal(decimal.New(3, 0)))
+		require.NoError(t, rec.Validate())
+	}
+}
+
+func TestWriter_MaxSize(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	now := start
+	w := NewWriter(dir, "market")
+	w.MaxSize = 1
+	w.Now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, w.Write(newOrderbookRecord(now)))
+		now = now.Add(time.Second)
+	}
+	require.NoError(t, w.Close())
+
+	files, err := Files(dir, "market")
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+}
+
+func TestWriter_InvalidRecord(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	rec := newOrderbookRecord(start)
+	rec.Type = TypeTicker
+
+	w := NewWriter(dir, "market")
+	require.Equal(t, ErrInvalidRecord, w.Write(rec))
+	require.NoError(t, w.Close())
+
+	files, err := Files(dir, "market")
+	require.NoError(t, err)
+	require.Empty(t, files)
+}
+
+func TestReader_Flushed(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	w := NewWriter(dir, "market")
+	require.NoError(t, w.Write(newOrderbookRecord(start)))
+	require.NoError(t, w.Flush())
+
+	// the file being written can be read up to the last flush
+	r, err := Open(dir, "market")
+	require.NoError(t, err)
+
+	rec, err := r.Next()
+	require.NoError(t, err)
+	require.Equal(t, "BTC_SKY", rec.Symbol)
+
+	_, err = r.Next()
+	require.Equal(t, io.ErrUnexpectedEOF, err)
+	require.NoError(t, r.Close())
+	require.NoError(t, w.Close())
+}
+
+func TestReader_EmptyFile(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "market-20180320T100000Z.jsonl.gz"), nil, 0640))
+
+	r, err := Open(dir, "market")
+	require.NoError(t, err)
+
+	_, err = r.Next()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestReader_TornRecord(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b, err := json.Marshal(newOrderbookRecord(start))
+	require.NoError(t, err)
+
+	f, err := os.Create(filepath.Join(dir, "market-20180320T100000Z.jsonl.gz"))
+	require.NoError(t, err)
+	gz := gzip.NewWriter(f)
+	_, err = gz.Write(append(b, '\n'))
+	require.NoError(t, err)
+	_, err = gz.Write(b[:len(b)/2])
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	require.NoError(t, f.Close())
+
+	r, err := Open(dir, "market")
+	require.NoError(t, err)
+
+	rec, err := r.Next()
+	require.NoError(t, err)
+	require.Equal(t, "BTC_SKY", rec.Symbol)
+
+	_, err = r.Next()
+	require.Equal(t, io.ErrUnexpectedEOF, err)
+	require.NoError(t, r.Close())
+}
@@ -0,0 +1,139 @@
+// Package retry retries failed API calls with jittered exponential backoff
+package retry
+
+import (
+	"context"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+)
+
+// Class is the kind of failure of a call, deciding whether it can be retried
+type Class int
+
+const (
+	// Permanent failures are not retried
+	Permanent Class = iota
+	// Transient failures, e.g. timeouts and server errors, may have happened after the call took effect.
+	// They are only retried for idempotent calls.
+	Transient
+	// RateLimited failures were rejected before taking effect, all calls can be retried
+	RateLimited
+)
+
+func (c Class) String() string {
+	switch c {
+	case Transient:
+		return "transient"
+	case RateLimited:
+		return "rate limited"
+	default:
+		return "permanent"
+	}
+}
+
+// Retryable returns true if a call failing with this class can be retried, depending on whether it is idempotent
+func (c Class) Retryable(idempotent bool) bool {
+	return c == RateLimited || (c == Transient && idempotent)
+}
+
+// NetClass classifies net.Error timeouts and temporary errors as Transient, other errors as Permanent
+func NetClass(err error) Class {
+	if e, ok := err.(net.Error); ok && (e.Timeout() || e.Temporary()) {
+		return Transient
+	}
+	return Permanent
+}
+
+// Default Policy parameters
+const (
+	DefaultMaxAttempts    = 4
+	DefaultBaseDelay      = 250 * time.Millisecond
+	DefaultMaxDelay       = 10 * time.Second
+	DefaultRateLimitDelay = 2 * time.Second
+)
+
+// Policy decides how many times and after which delay a failed call is retried.
+// The delay before retry n (from 1) is drawn uniformly between half and all of BaseDelay * 2^(n-1),
+// capped at MaxDelay; after a RateLimited failure it is at least RateLimitDelay.
+// Policy is safe for concurrent use.
+type Policy struct {
+	// MaxAttempts is the number of calls including the first one, 1 disables retries
+	MaxAttempts    int
+	BaseDelay      time.Duration
+	MaxDelay       time.Duration
+	RateLimitDelay time.Duration
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// NewPolicy creates a Policy with the default parameters
+func NewPolicy() *Policy {
+	return &Policy{
+		MaxAttempts:    DefaultMaxAttempts,
+		BaseDelay:      DefaultBaseDelay,
+		MaxDelay:       DefaultMaxDelay,
+		RateLimitDelay: DefaultRateLimitDelay,
+	}
+}
+
+// Delay returns the delay before retry number n, counted from 1, after a failure of class c
+func (p *Policy) Delay(n int, c Class) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < n && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+
+	if half := int64(d / 2); half > 0 {
+		p.mu.Lock()
+		if p.rand == nil {
+			p.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+		}
+		d = time.Duration(half + p.rand.Int63n(half+1))
+		p.mu.Unlock()
+	}
+
+	if c == RateLimited && d < p.RateLimitDelay {
+		d = p.RateLimitDelay
+	}
+	return d
+}
+
+// Do calls f until it succeeds, fails with an error which classify and idempotent don't allow retrying,
+// MaxAttempts calls were made or ctx is done. It returns the last error of f, or ctx's error.
+// A nil Policy calls f once.
+func (p *Policy) Do(ctx context.Context, idempotent bool, classify func(error) Class, f func() error) error {
+	for n := 1; ; n++ {
+		err := f()
+		if err == nil || p == nil || n >= p.MaxAttempts {
+			return err
+		}
+
+		c := classify(err)
+		if !c.Retryable(idempotent) {
+			return err
+		}
+
+		if err := Sleep(ctx, p.Delay(n, c)); err != nil {
+			return err
+		}
+	}
+}
+
+// Sleep waits for d or until ctx is done, in which case it returns ctx's error
+func Sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
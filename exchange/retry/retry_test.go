@@ -0,0 +1,132 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	errTransient   = errors.New("transient")
+	errRateLimited = errors.New("rate limited")
+	errPermanent   = errors.New("permanent")
+)
+
+func classify(err error) Class {
+	switch err {
+	case errTransient:
+		return Transient
+	case errRateLimited:
+		return RateLimited
+	default:
+		return Permanent
+	}
+}
+
+func newTestPolicy() *Policy {
+	p := NewPolicy()
+	p.BaseDelay = time.Millisecond
+	p.RateLimitDelay = time.Millisecond
+	return p
+}
+
+func TestRetryable(t *testing.T) {
+	require.False(t, Permanent.Retryable(true))
+	require.True(t, Transient.Retryable(true))
+	require.False(t, Transient.Retryable(false))
+	require.True(t, RateLimited.Retryable(false))
+}
+
+func TestNetClass(t *testing.T) {
+	require.Equal(t, Transient, NetClass(&net.DNSError{IsTimeout: true}))
+	require.Equal(t, Transient, NetClass(&net.DNSError{IsTemporary: true}))
+	require.Equal(t, Permanent, NetClass(&net.DNSError{}))
+	require.Equal(t, Permanent, NetClass(errors.New("foo")))
+}
+
+func TestDelay(t *testing.T) {
+	p := NewPolicy()
+	p.BaseDelay = 100 * time.Millisecond
+	p.MaxDelay = time.Second
+	p.RateLimitDelay = 2 * time.Second
+
+	cases := []struct {
+		n        int
+		class    Class
+		min, max time.Duration
+	}{
+		{1, Transient, 50 * time.Millisecond, 100 * time.Millisecond},
+		{2, Transient, 100 * time.Millisecond, 200 * time.Millisecond},
+		{4, Transient, 400 * time.Millisecond, 800 * time.Millisecond},
+		{5, Transient, 500 * time.Millisecond, time.Second},
+		{100, Transient, 500 * time.Millisecond, time.Second},
+		{1, RateLimited, 2 * time.Second, 2 * time.Second},
+	}
+
+	for _, tc := range cases {
+		for i := 0; i < 100; i++ {
+			d := p.Delay(tc.n, tc.class)
+			require.True(t, d >= tc.min && d <= tc.max, "n=%d %s: %v", tc.n, tc.class, d)
+		}
+	}
+}
+
+func TestDo(t *testing.T) {
+	cases := []struct {
+		name       string
+		errs       []error
+		idempotent bool
+		calls      int
+		err        error
+	}{
+		{"success", []error{nil}, true, 1, nil},
+		{"transient", []error{errTransient, errTransient, nil}, true, 3, nil},
+		{"transient not idempotent", []error{errTransient, nil}, false, 1, errTransient},
+		{"rate limited not idempotent", []error{errRateLimited, nil}, false, 2, nil},
+		{"permanent", []error{errPermanent, nil}, true, 1, errPermanent},
+		{"max attempts", []error{errTransient, errTransient, errTransient, errTransient, nil}, true, 4, errTransient},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			err := newTestPolicy().Do(context.Background(), tc.idempotent, classify, func() error {
+				calls++
+				return tc.errs[calls-1]
+			})
+			require.Equal(t, tc.err, err)
+			require.Equal(t, tc.calls, calls)
+		})
+	}
+}
+
+func TestDo_NilPolicy(t *testing.T) {
+	var p *Policy
+	calls := 0
+	err := p.Do(context.Background(), true, classify, func() error {
+		calls++
+		return errTransient
+	})
+	require.Equal(t, errTransient, err)
+	require.Equal(t, 1, calls)
+}
+
+func TestDo_Canceled(t *testing.T) {
+	p := NewPolicy()
+	p.BaseDelay = time.Hour
+	p.MaxDelay = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := p.Do(ctx, true, classify, func() error {
+		calls++
+		cancel()
+		return errTransient
+	})
+	require.Equal(t, context.Canceled, err)
+	require.Equal(t, 1, calls)
+}
@@ -0,0 +1,311 @@
+package exchange
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// DefaultVolumePrecision is the number of decimal places of child order volumes if none is specified
+const DefaultVolumePrecision = 8
+
+var (
+	// ErrInsufficientFunds is returned when the budget or the venues' balances can't pay for the whole volume
+	ErrInsufficientFunds = errors.New("insufficient funds to buy the whole volume")
+	// ErrUnknownVenue is returned when a route refers to a venue the Router doesn't have
+	ErrUnknownVenue = errors.New("unknown venue")
+)
+
+// Venue is a market on an exchange that the Router can trade on
+type Venue struct {
+	Exchange Exchange
+	Pair     Pair
+	// Fee is the exchange's taker fee rate, e.g. 0.002 for 0.2%
+	Fee decimal.Decimal
+}
+
+// ChildOrder is the part of a routed trade allocated to one venue. It is placed as a limit order
+// priced at the worst level it is expected to fill against.
+type ChildOrder struct {
+	Venue  string          `json:"venue"`
+	Pair   Pair            `json:"pair"`
+	Price  decimal.Decimal `json:"price"`
+	Volume decimal.Decimal `json:"volume"`
+	// Cost is the expected quote currency spent on the levels, excluding Fee
+	Cost decimal.Decimal `json:"cost"`
+	Fee  decimal.Decimal `json:"fee"`
+}
+
+// Route is an allocation of a buy across venues
+type Route struct {
+	Pair Pair `json:"pair"`
+	// Volume is the quantity of coins requested
+	Volume   decimal.Decimal `json:"volume"`
+	Budget   decimal.Decimal `json:"budget"`
+	Children []ChildOrder    `json:"children"`
+}
+
+// Allocated returns the volume of all child orders
+func (r *Route) Allocated() decimal.Decimal {
+	total := decimal.Zero
+	for _, c := range r.Children {
+		total = total.Add(c.Volume)
+	}
+	return total
+}
+
+// Cost returns the expected quote currency spent by all child orders, including fees
+func (r *Route) Cost() decimal.Decimal {
+	total := decimal.Zero
+	for _, c := range r.Children {
+		total = total.Add(c.Cost).Add(c.Fee)
+	}
+	return total
+}
+
+// Router splits a trade across venues trading the same market to get the best executable price
+type Router struct {
+	Venues []Venue
+	// VolumePrecision is the number of decimal places of child order volumes
+	VolumePrecision int32
+}
+
+// NewRouter creates a Router for the given venues
+func NewRouter(venues ...Venue) *Router {
+	return &Router{
+		Venues:          venues,
+		VolumePrecision: DefaultVolumePrecision,
+	}
+}
+
+func (r *Router) venue(name string) (Venue, bool) {
+	for _, v := range r.Venues {
+		if v.Exchange.Name() == name {
+			return v, true
+		}
+	}
+	return Venue{}, false
+}
+
+// PlanBuy allocates buying volume coins, spending at most budget of the quote currency including fees.
+// It fetches each venue's orderbook and balances and takes the cheapest levels after fees first, limiting
+// each venue to its available quote balance. If the whole volume can't be bought, the partial route is
+// returned along with ErrOrdersRanOut or ErrInsufficientFunds.
+func (r *Router) PlanBuy(volume, budget decimal.Decimal) (*Route, error) {
+	if !volume.GreaterThan(decimal.Zero) || !budget.GreaterThan(decimal.Zero) {
+		return nil, ErrNegativeAmount
+	}
+
+	books := make([]VenueBook, len(r.Venues))
+	funds := make(map[string]decimal.Decimal, len(r.Venues))
+	for i, v := range r.Venues {
+		name := v.Exchange.Name()
+
+		record, err := v.Exchange.GetOrderbook(v.Pair)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+
+		balances, err := v.Exchange.GetBalances()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+
+		books[i] = VenueBook{
+			Venue:  name,
+			Record: record,
+			Fee:    v.Fee,
+			Codec:  v.Exchange.Codec(),
+		}
+
+		funds[name] = balances[string(v.Pair.Quote)].Available
+	}
+
+	book, err := Consolidate(books...)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.allocate(book, volume, budget, funds)
+}
+
+// allocate walks the consolidated asks from the lowest effective price, funds maps a venue to its spendable quote balance
+func (r *Router) allocate(book *ConsolidatedBook, volume, budget decimal.Decimal, funds map[string]decimal.Decimal) (*Route, error) {
+	route := &Route{
+		Pair:   book.Pair,
+		Volume: volume,
+		Budget: budget,
+	}
+
+	children := make(map[string]*ChildOrder)
+	var order []string
+
+	remaining := volume
+	short := false
+	for _, level := range book.Asks {
+		if remaining.Equal(decimal.Zero) {
+			break
+		}
+
+		v, ok := r.venue(level.Venue)
+		if !ok {
+			return nil, ErrUnknownVenue
+		}
+
+		spendable := decimal.Min(budget, funds[level.Venue])
+		take := decimal.Min(level.Volume, remaining, spendable.Div(level.EffectivePrice).Truncate(r.VolumePrecision))
+		if take.LessThan(level.Volume) && take.LessThan(remaining) {
+			short = true
+		}
+		if !take.GreaterThan(decimal.Zero) {
+			continue
+		}
+
+		cost := take.Mul(level.Price)
+		fee := cost.Mul(v.Fee)
+		budget = budget.Sub(cost).Sub(fee)
+		funds[level.Venue] = funds[level.Venue].Sub(cost).Sub(fee)
+		remaining = remaining.Sub(take)
+
+		c, ok := children[level.Venue]
+		if !ok {
+			c = &ChildOrder{Venue: level.Venue, Pair: v.Pair}
+			children[level.Venue] = c
+			order = append(order, level.Venue)
+		}
+		c.Price = level.Price
+		c.Volume = c.Volume.Add(take)
+		c.Cost = c.Cost.Add(cost)
+		c.Fee = c.Fee.Add(fee)
+	}
+
+	for _, name := range order {
+		route.Children = append(route.Children, *children[name])
+	}
+
+	switch {
+	case remaining.Equal(decimal.Zero):
+		return route, nil
+	case short:
+		return route, ErrInsufficientFunds
+	default:
+		return route, ErrOrdersRanOut
+	}
+}
+
+// ChildResult is the outcome of placing a ChildOrder
+type ChildResult struct {
+	ChildOrder
+	// OrderID is zero if the order was not placed or was filled instantly without an ID
+	OrderID OrderID `json:"order_id"`
+	// Order is the latest state of the placed order, nil if it could not be queried.
+	// Orders filled instantly are completed at the planned cost and fee.
+	Order *Order `json:"order,omitempty"`
+	Err   error  `json:"-"`
+}
+
+// RouteFill is the aggregate result of an executed Route
+type RouteFill struct {
+	Children []ChildResult   `json:"children"`
+	Filled   decimal.Decimal `json:"filled"`
+	// Cost is the quote currency spent by the filled volume, excluding fees
+	Cost     decimal.Decimal `json:"cost"`
+	AvgPrice decimal.Decimal `json:"avg_price"`
+	Fee      decimal.Decimal `json:"fee"`
+}
+
+// Err returns the first error of the child orders, if any
+func (f *RouteFill) Err() error {
+	for _, c := range f.Children {
+		if c.Err != nil {
+			return fmt.Errorf("%s: %v", c.Venue, c.Err)
+		}
+	}
+	return nil
+}
+
+// Execute places the route's child orders as limit buys and reports their aggregate fill.
+// A failing child order doesn't prevent the others from being placed, its error is recorded in its ChildResult.
+func (r *Router) Execute(route *Route) (*RouteFill, error) {
+	fill := &RouteFill{}
+
+	for _, c := range route.Children {
+		v, ok := r.venue(c.Venue)
+		if !ok {
+			return nil, ErrUnknownVenue
+		}
+
+		result := ChildResult{ChildOrder: c}
+		result.OrderID, result.Err = v.Exchange.LimitBuy(c.Pair, c.Price, c.Volume)
+		if result.Err == ErrFilledInstantly {
+			result.Err = nil
+			result.Order = instantOrder(v.Exchange.Codec(), c)
+		}
+		fill.Children = append(fill.Children, result)
+	}
+
+	return r.Refresh(fill)
+}
+
+// instantOrder is the Order of a child order filled instantly, whose state can't be queried
+func instantOrder(codec PairCodec, c ChildOrder) *Order {
+	avg := c.Price
+	if c.Volume.GreaterThan(decimal.Zero) {
+		avg = c.Cost.Div(c.Volume)
+	}
+
+	return &Order{
+		Symbol:   codec.EncodePair(c.Pair),
+		Action:   ActionBuy,
+		Status:   StatusCompleted,
+		Price:    c.Price,
+		Volume:   c.Volume,
+		Filled:   c.Volume,
+		AvgPrice: avg,
+		Fee:      c.Fee,
+	}
+}
+
+// Refresh queries the latest state of a RouteFill's placed child orders and updates the aggregate fill.
+// Child orders without an OrderID are not queried.
+func (r *Router) Refresh(fill *RouteFill) (*RouteFill, error) {
+	result := &RouteFill{
+		Children: make([]ChildResult, len(fill.Children)),
+	}
+
+	for i, c := range fill.Children {
+		if c.OrderID > 0 {
+			v, ok := r.venue(c.Venue)
+			if !ok {
+				return nil, ErrUnknownVenue
+			}
+
+			o, err := v.Exchange.GetOrderStatus(c.Pair, c.OrderID)
+			if err != nil {
+				c.Err = err
+			} else {
+				c.Order = o
+				c.Err = nil
+			}
+		}
+
+		if c.Order != nil {
+			avg := c.Order.AvgPrice
+			if avg.Equal(decimal.Zero) {
+				avg = c.Order.Price
+			}
+			result.Filled = result.Filled.Add(c.Order.Filled)
+			result.Cost = result.Cost.Add(c.Order.Filled.Mul(avg))
+			result.Fee = result.Fee.Add(c.Order.Fee)
+		}
+
+		result.Children[i] = c
+	}
+
+	if result.Filled.GreaterThan(decimal.Zero) {
+		result.AvgPrice = result.Cost.Div(result.Filled)
+	}
+
+	return result, nil
+}
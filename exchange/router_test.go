@@ -0,0 +1,204 @@
+package exchange
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func newFakeRouter(c2cxBTC, cryptopiaBTC float64) (*Router, *fakeExchange, *fakeExchange) {
+	books := newVenueBooks()
+
+	cryptopia := newFakeExchange()
+	cryptopia.name = "cryptopia"
+	cryptopia.orderbooks["SKY/BTC"] = *books[0].Record
+	cryptopia.balances["BTC"] = Balance{Currency: "BTC", Available: decimal.NewFromFloat(cryptopiaBTC)}
+
+	c2cx := newFakeExchange()
+	c2cx.name = "c2cx"
+	c2cx.codec = quoteFirstCodec
+	c2cx.orderbooks["BTC_SKY"] = *books[1].Record
+	c2cx.balances["BTC"] = Balance{Currency: "BTC", Available: decimal.NewFromFloat(c2cxBTC)}
+
+	router := NewRouter(
+		Venue{Exchange: cryptopia, Pair: skyBtc, Fee: decimal.NewFromFloat(0.002)},
+		Venue{Exchange: c2cx, Pair: skyBtc},
+	)
+
+	return router, c2cx, cryptopia
+}
+
+type child struct {
+	venue, price, volume, cost, fee string
+}
+
+func children(route *Route) []child {
+	var result []child
+	for _, c := range route.Children {
+		result = append(result, child{c.Venue, c.Price.String(), c.Volume.String(), c.Cost.String(), c.Fee.String()})
+	}
+	return result
+}
+
+func TestRouterPlanBuy(t *testing.T) {
+	tests := []struct {
+		name      string
+		c2cxBTC   float64
+		volume    float64
+		budget    float64
+		children  []child
+		allocated string
+		err       error
+	}{
+		{
+			name:    "cheapest after fees",
+			c2cxBTC: 100,
+			volume:  6,
+			budget:  100,
+			children: []child{
+				{"c2cx", "4.5", "3", "13", "0"},
+				{"cryptopia", "4", "3", "12", "0.024"},
+			},
+			allocated: "6",
+		},
+		{
+			name:    "limited by budget",
+			c2cxBTC: 100,
+			volume:  6,
+			budget:  10,
+			children: []child{
+				{"c2cx", "4", "1", "4", "0"},
+				{"cryptopia", "4", "1.49700598", "5.98802392", "0.01197604784"},
+			},
+			allocated: "2.49700598",
+			err:       ErrInsufficientFunds,
+		},
+		{
+			name:    "limited by venue balance",
+			c2cxBTC: 2,
+			volume:  6,
+			budget:  100,
+			children: []child{
+				{"c2cx", "4", "0.5", "2", "0"},
+				{"cryptopia", "5", "5.5", "24.5", "0.049"},
+			},
+			allocated: "6",
+		},
+		{
+			name:    "orders ran out",
+			c2cxBTC: 100,
+			volume:  100,
+			budget:  1000,
+			children: []child{
+				{"c2cx", "4.5", "5", "22", "0"},
+				{"cryptopia", "5", "12", "57", "0.114"},
+			},
+			allocated: "17",
+			err:       ErrOrdersRanOut,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router, _, _ := newFakeRouter(tc.c2cxBTC, 100)
+
+			route, err := router.PlanBuy(decimal.NewFromFloat(tc.volume), decimal.NewFromFloat(tc.budget))
+			require.Equal(t, tc.err, err)
+			require.Equal(t, skyBtc, route.Pair)
+			require.Equal(t, tc.children, children(route))
+			require.Equal(t, tc.allocated, route.Allocated().String())
+			require.True(t, route.Cost().LessThanOrEqual(decimal.NewFromFloat(tc.budget)))
+		})
+	}
+}
+
+func TestRouterPlanBuyErrors(t *testing.T) {
+	router, c2cx, _ := newFakeRouter(100, 100)
+
+	_, err := router.PlanBuy(decimal.Zero, decimal.NewFromFloat(1.0))
+	require.Equal(t, ErrNegativeAmount, err)
+	_, err = router.PlanBuy(decimal.NewFromFloat(1.0), decimal.Zero)
+	require.Equal(t, ErrNegativeAmount, err)
+
+	c2cx.err = errors.New("exchange unavailable")
+	_, err = router.PlanBuy(decimal.NewFromFloat(1.0), decimal.NewFromFloat(10.0))
+	require.EqualError(t, err, "c2cx: exchange unavailable")
+}
+
+func TestRouterExecute(t *testing.T) {
+	router, c2cx, cryptopia := newFakeRouter(100, 100)
+
+	route, err := router.PlanBuy(decimal.NewFromFloat(6.0), decimal.NewFromFloat(100.0))
+	require.NoError(t, err)
+
+	fill, err := router.Execute(route)
+	require.NoError(t, err)
+	require.NoError(t, fill.Err())
+	require.Len(t, fill.Children, 2)
+	require.True(t, fill.Filled.Equal(decimal.Zero))
+
+	c2cxID := fill.Children[0].OrderID
+	o, err := c2cx.GetOrderStatus(skyBtc, c2cxID)
+	require.NoError(t, err)
+	require.Equal(t, ActionBuy, o.Action)
+	require.True(t, o.Price.Equal(decimal.NewFromFloat(4.5)))
+	require.True(t, o.Volume.Equal(decimal.NewFromFloat(3.0)))
+
+	cryptopiaID := fill.Children[1].OrderID
+	c2cx.fill(c2cxID, decimal.NewFromFloat(3.0), decimal.Zero)
+	cryptopia.fill(cryptopiaID, decimal.NewFromFloat(1.0), decimal.NewFromFloat(0.008))
+
+	fill, err = router.Refresh(fill)
+	require.NoError(t, err)
+	require.Equal(t, StatusCompleted, fill.Children[0].Order.Status)
+	require.Equal(t, StatusPartial, fill.Children[1].Order.Status)
+	require.True(t, fill.Filled.Equal(decimal.NewFromFloat(4.0)))
+	require.True(t, fill.Cost.Equal(decimal.NewFromFloat(17.5)))
+	require.True(t, fill.AvgPrice.Equal(decimal.NewFromFloat(4.375)))
+	require.True(t, fill.Fee.Equal(decimal.NewFromFloat(0.008)))
+}
+
+func TestRouterExecuteFilledInstantly(t *testing.T) {
+	router, _, cryptopia := newFakeRouter(100, 100)
+
+	route, err := router.PlanBuy(decimal.NewFromFloat(6.0), decimal.NewFromFloat(100.0))
+	require.NoError(t, err)
+	instant := route.Children[1]
+
+	cryptopia.err = ErrFilledInstantly
+	fill, err := router.Execute(route)
+	require.NoError(t, err)
+	require.NoError(t, fill.Err())
+	require.Zero(t, fill.Children[1].OrderID)
+	require.Equal(t, StatusCompleted, fill.Children[1].Order.Status)
+	require.True(t, fill.Filled.Equal(instant.Volume))
+	require.True(t, fill.Cost.Equal(instant.Cost))
+	require.True(t, fill.Fee.Equal(instant.Fee))
+
+	// the instant fill is still counted without being queried
+	fill, err = router.Refresh(fill)
+	require.NoError(t, err)
+	require.NoError(t, fill.Err())
+	require.True(t, fill.Filled.Equal(instant.Volume))
+}
+
+func TestRouterExecuteChildError(t *testing.T) {
+	router, _, cryptopia := newFakeRouter(100, 100)
+
+	route, err := router.PlanBuy(decimal.NewFromFloat(6.0), decimal.NewFromFloat(100.0))
+	require.NoError(t, err)
+
+	cryptopia.err = errors.New("exchange unavailable")
+	fill, err := router.Execute(route)
+	require.NoError(t, err)
+	require.Len(t, fill.Children, 2)
+	require.NotZero(t, fill.Children[0].OrderID)
+	require.NotNil(t, fill.Children[0].Order)
+	require.Zero(t, fill.Children[1].OrderID)
+	require.EqualError(t, fill.Err(), "cryptopia: exchange unavailable")
+
+	_, err = router.Execute(&Route{Children: []ChildOrder{{Venue: "bitfinex"}}})
+	require.Equal(t, ErrUnknownVenue, err)
+}
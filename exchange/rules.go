@@ -0,0 +1,326 @@
+package exchange
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// ErrRulesNotFound is returned when a market has no trading rules
+	ErrRulesNotFound = errors.New("no trading rules for market")
+	// ErrVolumeTooSmall is returned when an order's volume is below the market's minimum
+	ErrVolumeTooSmall = errors.New("volume too small")
+	// ErrVolumeTooLarge is returned when an order's volume is above the market's maximum
+	ErrVolumeTooLarge = errors.New("volume too large")
+	// ErrCostTooSmall is returned when an order's total in the quote currency is below the market's minimum
+	ErrCostTooSmall = errors.New("total too small")
+	// ErrCostTooLarge is returned when an order's total in the quote currency is above the market's maximum
+	ErrCostTooLarge = errors.New("total too large")
+	// ErrPriceTooLow is returned when an order's price is below the market's minimum
+	ErrPriceTooLow = errors.New("price too low")
+	// ErrPriceTooHigh is returned when an order's price is above the market's maximum
+	ErrPriceTooHigh = errors.New("price too high")
+	// ErrInsufficientBalance is returned when the available balance can't pay for an order
+	ErrInsufficientBalance = errors.New("insufficient balance")
+)
+
+// RuleError is returned by Rules.Preflight when an order breaks a rule
+type RuleError struct {
+	// Err is one of ErrVolumeTooSmall, ErrVolumeTooLarge, ErrCostTooSmall, ErrCostTooLarge,
+	// ErrPriceTooLow, ErrPriceTooHigh or ErrInsufficientBalance
+	Err error
+	// Value is the offending value, after rounding
+	Value decimal.Decimal
+	// Limit is the bound that Value breaks
+	Limit decimal.Decimal
+}
+
+func (e RuleError) Error() string {
+	return fmt.Sprintf("%v: %s, limit %s", e.Err, e.Value, e.Limit)
+}
+
+// Rules are a market's trading constraints. Zero bounds and nil precisions are not enforced.
+type Rules struct {
+	// PricePrecision and VolumePrecision are the maximum number of decimals, see Precision
+	PricePrecision  *int32 `json:"price_precision,omitempty"`
+	VolumePrecision *int32 `json:"volume_precision,omitempty"`
+	// MinVolume and MaxVolume bound the quantity of the base currency
+	MinVolume decimal.Decimal `json:"min_volume"`
+	MaxVolume decimal.Decimal `json:"max_volume"`
+	// MinCost and MaxCost bound the order's total in the quote currency
+	MinCost  decimal.Decimal `json:"min_cost"`
+	MaxCost  decimal.Decimal `json:"max_cost"`
+	MinPrice decimal.Decimal `json:"min_price"`
+	MaxPrice decimal.Decimal `json:"max_price"`
+	// Fee is the trading fee rate charged in the quote currency on buys, e.g. 0.002 for 0.2%
+	Fee decimal.Decimal `json:"fee"`
+}
+
+// Precision returns a pointer to places, for setting Rules.PricePrecision and Rules.VolumePrecision
+func Precision(places int32) *int32 {
+	return &places
+}
+
+// Round rounds a limit order's price and volume to the market's precision. The price is rounded in the
+// trader's favour, down for buys and up for sells, and the volume is rounded down.
+// Values with a nil precision are not rounded.
+func (r Rules) Round(action Action, price, volume decimal.Decimal) (decimal.Decimal, decimal.Decimal) {
+	if action == ActionBuy {
+		price = truncate(price, r.PricePrecision)
+	} else {
+		price = roundUp(price, r.PricePrecision)
+	}
+	return price, truncate(volume, r.VolumePrecision)
+}
+
+func truncate(d decimal.Decimal, places *int32) decimal.Decimal {
+	if places == nil {
+		return d
+	}
+	return d.Truncate(*places)
+}
+
+func roundUp(d decimal.Decimal, places *int32) decimal.Decimal {
+	if places == nil {
+		return d
+	}
+	t := d.Truncate(*places)
+	if t.LessThan(d) {
+		t = t.Add(decimal.New(1, -*places))
+	}
+	return t
+}
+
+// unit is the smallest positive value with the given precision, zero if places is nil
+func unit(places *int32) decimal.Decimal {
+	if places == nil {
+		return decimal.Zero
+	}
+	return decimal.New(1, -*places)
+}
+
+// Preflight rounds a limit order with Round and checks it against the rules. If balances is not nil,
+// it also checks that the available balance of the quote currency, for buys, or base currency, for sells,
+// pays for the order. It returns the rounded price and volume, or a RuleError.
+func (r Rules) Preflight(pair Pair, action Action, price, volume decimal.Decimal, balances Balances) (decimal.Decimal, decimal.Decimal, error) {
+	price, volume = r.Round(action, price, volume)
+	if !volume.GreaterThan(decimal.Zero) {
+		return price, volume, RuleError{Err: ErrVolumeTooSmall, Value: volume, Limit: unit(r.VolumePrecision)}
+	}
+	if !price.GreaterThan(decimal.Zero) {
+		return price, volume, RuleError{Err: ErrPriceTooLow, Value: price, Limit: unit(r.PricePrecision)}
+	}
+
+	cost := price.Mul(volume)
+
+	checks := []struct {
+		err   error
+		value decimal.Decimal
+		limit decimal.Decimal
+		fails func(value, limit decimal.Decimal) bool
+	}{
+		{ErrVolumeTooSmall, volume, r.MinVolume, decimal.Decimal.LessThan},
+		{ErrVolumeTooLarge, volume, r.MaxVolume, decimal.Decimal.GreaterThan},
+		{ErrPriceTooLow, price, r.MinPrice, decimal.Decimal.LessThan},
+		{ErrPriceTooHigh, price, r.MaxPrice, decimal.Decimal.GreaterThan},
+		{ErrCostTooSmall, cost, r.MinCost, decimal.Decimal.LessThan},
+		{ErrCostTooLarge, cost, r.MaxCost, decimal.Decimal.GreaterThan},
+	}
+
+	for _, c := range checks {
+		if c.limit.GreaterThan(decimal.Zero) && c.fails(c.value, c.limit) {
+			return price, volume, RuleError{Err: c.err, Value: c.value, Limit: c.limit}
+		}
+	}
+
+	if balances == nil {
+		return price, volume, nil
+	}
+
+	if action == ActionBuy {
+		total := cost.Add(cost.Mul(r.Fee))
+		available := balances[string(pair.Quote)].Available
+		if total.GreaterThan(available) {
+			return price, volume, RuleError{Err: ErrInsufficientBalance, Value: total, Limit: available}
+		}
+	} else {
+		available := balances[string(pair.Base)].Available
+		if volume.GreaterThan(available) {
+			return price, volume, RuleError{Err: ErrInsufficientBalance, Value: volume, Limit: available}
+		}
+	}
+
+	return price, volume, nil
+}
+
+// PreflightMarket rounds a market order down and checks it against the rules. For buys, amount is the total
+// to spend in the quote currency, rounded to PricePrecision and checked against MinCost and MaxCost.
+// For sells, amount is the volume of the base currency, rounded to VolumePrecision and checked against
+// MinVolume and MaxVolume. If balances is not nil, it also checks that the available balance pays for
+// the order, including the fee on buys. It returns the rounded amount, or a RuleError.
+func (r Rules) PreflightMarket(pair Pair, action Action, amount decimal.Decimal, balances Balances) (decimal.Decimal, error) {
+	precision, min, max := r.VolumePrecision, r.MinVolume, r.MaxVolume
+	minErr, maxErr, currency := ErrVolumeTooSmall, ErrVolumeTooLarge, pair.Base
+	if action == ActionBuy {
+		precision, min, max = r.PricePrecision, r.MinCost, r.MaxCost
+		minErr, maxErr, currency = ErrCostTooSmall, ErrCostTooLarge, pair.Quote
+	}
+
+	amount = truncate(amount, precision)
+	if !amount.GreaterThan(decimal.Zero) {
+		return amount, RuleError{Err: minErr, Value: amount, Limit: unit(precision)}
+	}
+	if min.GreaterThan(decimal.Zero) && amount.LessThan(min) {
+		return amount, RuleError{Err: minErr, Value: amount, Limit: min}
+	}
+	if max.GreaterThan(decimal.Zero) && amount.GreaterThan(max) {
+		return amount, RuleError{Err: maxErr, Value: amount, Limit: max}
+	}
+
+	if balances == nil {
+		return amount, nil
+	}
+
+	total := amount
+	if action == ActionBuy {
+		total = amount.Add(amount.Mul(r.Fee))
+	}
+	available := balances[string(currency)].Available
+	if total.GreaterThan(available) {
+		return amount, RuleError{Err: ErrInsufficientBalance, Value: total, Limit: available}
+	}
+
+	return amount, nil
+}
+
+// RulesRegistry holds the trading rules of markets on several exchanges. It is safe for concurrent use.
+type RulesRegistry struct {
+	mu    sync.RWMutex
+	rules map[string]map[Pair]Rules
+}
+
+// NewRulesRegistry creates an empty RulesRegistry
+func NewRulesRegistry() *RulesRegistry {
+	return &RulesRegistry{
+		rules: make(map[string]map[Pair]Rules),
+	}
+}
+
+// Set sets the rules of a market, replacing any previous rules
+func (r *RulesRegistry) Set(exchange string, pair Pair, rules Rules) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.rules[exchange] == nil {
+		r.rules[exchange] = make(map[Pair]Rules)
+	}
+	r.rules[exchange][pair] = rules
+}
+
+// SetAll sets the rules of several markets of an exchange, e.g. loaded from its metadata
+func (r *RulesRegistry) SetAll(exchange string, rules map[Pair]Rules) {
+	for pair, v := range rules {
+		r.Set(exchange, pair, v)
+	}
+}
+
+// Get returns the rules of a market
+func (r *RulesRegistry) Get(exchange string, pair Pair) (Rules, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rules, ok := r.rules[exchange][pair]
+	return rules, ok
+}
+
+// Preflight checks a limit order against the rules of its market, see Rules.Preflight
+func (r *RulesRegistry) Preflight(exchange string, pair Pair, action Action, price, volume decimal.Decimal, balances Balances) (decimal.Decimal, decimal.Decimal, error) {
+	rules, ok := r.Get(exchange, pair)
+	if !ok {
+		return price, volume, ErrRulesNotFound
+	}
+	return rules.Preflight(pair, action, price, volume, balances)
+}
+
+// ValidatingExchange wraps an Exchange, rounding limit and market orders and checking them against the
+// RulesRegistry and the available balances before they are placed. Other methods are passed through.
+type ValidatingExchange struct {
+	Exchange
+	Rules *RulesRegistry
+}
+
+// NewValidatingExchange creates a ValidatingExchange
+func NewValidatingExchange(e Exchange, rules *RulesRegistry) *ValidatingExchange {
+	return &ValidatingExchange{
+		Exchange: e,
+		Rules:    rules,
+	}
+}
+
+func (v *ValidatingExchange) rules(pair Pair) (Rules, Balances, error) {
+	rules, ok := v.Rules.Get(v.Name(), pair)
+	if !ok {
+		return rules, nil, ErrRulesNotFound
+	}
+
+	balances, err := v.GetBalances()
+	if err != nil {
+		return rules, nil, err
+	}
+
+	return rules, balances, nil
+}
+
+func (v *ValidatingExchange) preflight(pair Pair, action Action, price, volume decimal.Decimal) (decimal.Decimal, decimal.Decimal, error) {
+	rules, balances, err := v.rules(pair)
+	if err != nil {
+		return price, volume, err
+	}
+	return rules.Preflight(pair, action, price, volume, balances)
+}
+
+func (v *ValidatingExchange) preflightMarket(pair Pair, action Action, amount decimal.Decimal) (decimal.Decimal, error) {
+	rules, balances, err := v.rules(pair)
+	if err != nil {
+		return amount, err
+	}
+	return rules.PreflightMarket(pair, action, amount, balances)
+}
+
+// LimitBuy checks and places a limit buy order
+func (v *ValidatingExchange) LimitBuy(pair Pair, price, volume decimal.Decimal) (OrderID, error) {
+	price, volume, err := v.preflight(pair, ActionBuy, price, volume)
+	if err != nil {
+		return 0, err
+	}
+	return v.Exchange.LimitBuy(pair, price, volume)
+}
+
+// LimitSell checks and places a limit sell order
+func (v *ValidatingExchange) LimitSell(pair Pair, price, volume decimal.Decimal) (OrderID, error) {
+	price, volume, err := v.preflight(pair, ActionSell, price, volume)
+	if err != nil {
+		return 0, err
+	}
+	return v.Exchange.LimitSell(pair, price, volume)
+}
+
+// MarketBuy checks and places a market buy order
+func (v *ValidatingExchange) MarketBuy(pair Pair, amount decimal.Decimal) (OrderID, error) {
+	amount, err := v.preflightMarket(pair, ActionBuy, amount)
+	if err != nil {
+		return 0, err
+	}
+	return v.Exchange.MarketBuy(pair, amount)
+}
+
+// MarketSell checks and places a market sell order
+func (v *ValidatingExchange) MarketSell(pair Pair, volume decimal.Decimal) (OrderID, error) {
+	volume, err := v.preflightMarket(pair, ActionSell, volume)
+	if err != nil {
+		return 0, err
+	}
+	return v.Exchange.MarketSell(pair, volume)
+}
@@ -0,0 +1,349 @@
+package exchange
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func newFakeRules() Rules {
+	return Rules{
+		PricePrecision:  Precision(5),
+		VolumePrecision: Precision(2),
+		MinVolume:       decimal.NewFromFloat(1.0),
+		MaxVolume:       decimal.NewFromFloat(1000.0),
+		MinCost:         decimal.NewFromFloat(0.001),
+		MinPrice:        decimal.NewFromFloat(0.0001),
+		MaxPrice:        decimal.NewFromFloat(0.01),
+		Fee:             decimal.NewFromFloat(0.002),
+	}
+}
+
+func newFakeBalances() Balances {
+	return Balances{
+		"BTC": {Currency: "BTC", Available: decimal.NewFromFloat(0.1)},
+		"SKY": {Currency: "SKY", Available: decimal.NewFromFloat(50.0)},
+	}
+}
+
+func TestRulesRound(t *testing.T) {
+	rules := newFakeRules()
+
+	price, volume := rules.Round(ActionBuy, decimal.NewFromFloat(0.000678), decimal.NewFromFloat(12.345))
+	require.Equal(t, "0.00067", price.String())
+	require.Equal(t, "12.34", volume.String())
+
+	price, volume = rules.Round(ActionSell, decimal.NewFromFloat(0.000671), decimal.NewFromFloat(12.349))
+	require.Equal(t, "0.00068", price.String())
+	require.Equal(t, "12.34", volume.String())
+
+	price, _ = rules.Round(ActionSell, decimal.NewFromFloat(0.00067), decimal.NewFromFloat(1.0))
+	require.Equal(t, "0.00067", price.String())
+
+	// unset precisions don't round
+	price, volume = Rules{}.Round(ActionSell, decimal.NewFromFloat(0.000671), decimal.NewFromFloat(12.349))
+	require.Equal(t, "0.000671", price.String())
+	require.Equal(t, "12.349", volume.String())
+
+	// zero precision rounds to whole numbers
+	price, volume = Rules{PricePrecision: Precision(0), VolumePrecision: Precision(0)}.Round(ActionSell, decimal.NewFromFloat(1.5), decimal.NewFromFloat(2.5))
+	require.Equal(t, "2", price.String())
+	require.Equal(t, "2", volume.String())
+}
+
+func TestRulesPreflight(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		action   Action
+		price    float64
+		volume   float64
+		balances Balances
+		err      error
+		value    string
+		limit    string
+	}{
+		{
+			name:     "valid buy",
+			action:   ActionBuy,
+			price:    0.000678,
+			volume:   12.345,
+			balances: newFakeBalances(),
+		},
+		{
+			name:   "valid sell without balances",
+			action: ActionSell,
+			price:  0.000671,
+			volume: 500,
+		},
+		{
+			name:   "volume rounds to zero",
+			action: ActionBuy,
+			price:  0.001,
+			volume: 0.001,
+			err:    ErrVolumeTooSmall,
+			value:  "0",
+			limit:  "0.01",
+		},
+		{
+			name:   "volume too small",
+			action: ActionBuy,
+			price:  0.001,
+			volume: 0.999,
+			err:    ErrVolumeTooSmall,
+			value:  "0.99",
+			limit:  "1",
+		},
+		{
+			name:   "volume too large",
+			action: ActionSell,
+			price:  0.001,
+			volume: 1000.01,
+			err:    ErrVolumeTooLarge,
+			value:  "1000.01",
+			limit:  "1000",
+		},
+		{
+			name:   "price too low",
+			action: ActionBuy,
+			price:  0.000099,
+			volume: 20,
+			err:    ErrPriceTooLow,
+			value:  "0.00009",
+			limit:  "0.0001",
+		},
+		{
+			name:   "price too high",
+			action: ActionSell,
+			price:  0.010001,
+			volume: 1,
+			err:    ErrPriceTooHigh,
+			value:  "0.01001",
+			limit:  "0.01",
+		},
+		{
+			name:   "total too small",
+			action: ActionBuy,
+			price:  0.0001,
+			volume: 9,
+			err:    ErrCostTooSmall,
+			value:  "0.0009",
+			limit:  "0.001",
+		},
+		{
+			name:     "buy unaffordable with fee",
+			action:   ActionBuy,
+			price:    0.001,
+			volume:   100,
+			balances: newFakeBalances(),
+			err:      ErrInsufficientBalance,
+			value:    "0.1002",
+			limit:    "0.1",
+		},
+		{
+			name:     "sell unaffordable",
+			action:   ActionSell,
+			price:    0.001,
+			volume:   50.01,
+			balances: newFakeBalances(),
+			err:      ErrInsufficientBalance,
+			value:    "50.01",
+			limit:    "50",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rules := newFakeRules()
+			price, volume, err := rules.Preflight(skyBtc, tc.action, decimal.NewFromFloat(tc.price), decimal.NewFromFloat(tc.volume), tc.balances)
+			if tc.err == nil {
+				require.NoError(t, err)
+				wantPrice, wantVolume := rules.Round(tc.action, decimal.NewFromFloat(tc.price), decimal.NewFromFloat(tc.volume))
+				require.True(t, price.Equal(wantPrice))
+				require.True(t, volume.Equal(wantVolume))
+				return
+			}
+
+			require.IsType(t, RuleError{}, err)
+			ruleErr := err.(RuleError)
+			require.Equal(t, tc.err, ruleErr.Err)
+			require.Equal(t, tc.value, ruleErr.Value.String())
+			require.Equal(t, tc.limit, ruleErr.Limit.String())
+		})
+	}
+
+	err := RuleError{Err: ErrVolumeTooSmall, Value: decimal.NewFromFloat(0.5), Limit: decimal.NewFromFloat(1.0)}
+	require.Equal(t, "volume too small: 0.5, limit 1", err.Error())
+}
+
+func TestRulesPreflightMarket(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		action   Action
+		amount   float64
+		balances Balances
+		rounded  string
+		err      error
+		value    string
+		limit    string
+	}{
+		{
+			name:     "valid buy",
+			action:   ActionBuy,
+			amount:   0.0123456,
+			balances: newFakeBalances(),
+			rounded:  "0.01234",
+		},
+		{
+			name:    "valid sell without balances",
+			action:  ActionSell,
+			amount:  500.009,
+			rounded: "500",
+		},
+		{
+			name:   "buy rounds to zero",
+			action: ActionBuy,
+			amount: 0.000001,
+			err:    ErrCostTooSmall,
+			value:  "0",
+			limit:  "0.00001",
+		},
+		{
+			name:   "buy too small",
+			action: ActionBuy,
+			amount: 0.0009,
+			err:    ErrCostTooSmall,
+			value:  "0.0009",
+			limit:  "0.001",
+		},
+		{
+			name:   "sell too small",
+			action: ActionSell,
+			amount: 0.999,
+			err:    ErrVolumeTooSmall,
+			value:  "0.99",
+			limit:  "1",
+		},
+		{
+			name:   "sell too large",
+			action: ActionSell,
+			amount: 1000.01,
+			err:    ErrVolumeTooLarge,
+			value:  "1000.01",
+			limit:  "1000",
+		},
+		{
+			name:     "buy unaffordable with fee",
+			action:   ActionBuy,
+			amount:   0.1,
+			balances: newFakeBalances(),
+			err:      ErrInsufficientBalance,
+			value:    "0.1002",
+			limit:    "0.1",
+		},
+		{
+			name:     "sell unaffordable",
+			action:   ActionSell,
+			amount:   50.01,
+			balances: newFakeBalances(),
+			err:      ErrInsufficientBalance,
+			value:    "50.01",
+			limit:    "50",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			amount, err := newFakeRules().PreflightMarket(skyBtc, tc.action, decimal.NewFromFloat(tc.amount), tc.balances)
+			if tc.err == nil {
+				require.NoError(t, err)
+				require.Equal(t, tc.rounded, amount.String())
+				return
+			}
+
+			require.IsType(t, RuleError{}, err)
+			ruleErr := err.(RuleError)
+			require.Equal(t, tc.err, ruleErr.Err)
+			require.Equal(t, tc.value, ruleErr.Value.String())
+			require.Equal(t, tc.limit, ruleErr.Limit.String())
+		})
+	}
+}
+
+func TestRulesRegistry(t *testing.T) {
+	registry := NewRulesRegistry()
+
+	_, ok := registry.Get("c2cx", skyBtc)
+	require.False(t, ok)
+	_, _, err := registry.Preflight("c2cx", skyBtc, ActionBuy, decimal.NewFromFloat(0.001), decimal.NewFromFloat(1.0), nil)
+	require.Equal(t, ErrRulesNotFound, err)
+
+	registry.SetAll("c2cx", map[Pair]Rules{skyBtc: newFakeRules()})
+	rules, ok := registry.Get("c2cx", skyBtc)
+	require.True(t, ok)
+	require.Equal(t, newFakeRules(), rules)
+
+	_, ok = registry.Get("cryptopia", skyBtc)
+	require.False(t, ok)
+
+	price, _, err := registry.Preflight("c2cx", skyBtc, ActionBuy, decimal.NewFromFloat(0.0012345), decimal.NewFromFloat(1.0), nil)
+	require.NoError(t, err)
+	require.Equal(t, "0.00123", price.String())
+}
+
+func TestValidatingExchange(t *testing.T) {
+	e := newFakeExchange()
+	e.name = "c2cx"
+	e.codec = quoteFirstCodec
+	e.balances = newFakeBalances()
+
+	registry := NewRulesRegistry()
+	registry.Set("c2cx", skyBtc, newFakeRules())
+	v := NewValidatingExchange(e, registry)
+	require.Implements(t, (*Exchange)(nil), v)
+
+	id, err := v.LimitBuy(skyBtc, decimal.NewFromFloat(0.0012345), decimal.NewFromFloat(10.005))
+	require.NoError(t, err)
+	o, err := e.GetOrderStatus(skyBtc, id)
+	require.NoError(t, err)
+	require.Equal(t, "BTC_SKY", o.Symbol)
+	require.Equal(t, "0.00123", o.Price.String())
+	require.Equal(t, "10", o.Volume.String())
+
+	_, err = v.LimitSell(skyBtc, decimal.NewFromFloat(0.001), decimal.NewFromFloat(60.0))
+	require.IsType(t, RuleError{}, err)
+	require.Equal(t, ErrInsufficientBalance, err.(RuleError).Err)
+
+	_, err = v.LimitSell(NewPair(ETH, BTC), decimal.NewFromFloat(0.001), decimal.NewFromFloat(1.0))
+	require.Equal(t, ErrRulesNotFound, err)
+
+	e.err = errors.New("exchange unavailable")
+	_, err = v.LimitBuy(skyBtc, decimal.NewFromFloat(0.001), decimal.NewFromFloat(1.0))
+	require.EqualError(t, err, "exchange unavailable")
+
+	e.err = nil
+	id, err = v.MarketSell(skyBtc, decimal.NewFromFloat(10.005))
+	require.NoError(t, err)
+	o, err = e.GetOrderStatus(skyBtc, id)
+	require.NoError(t, err)
+	require.Equal(t, "10", o.Volume.String())
+
+	_, err = v.MarketSell(skyBtc, decimal.NewFromFloat(0.5))
+	require.IsType(t, RuleError{}, err)
+	require.Equal(t, ErrVolumeTooSmall, err.(RuleError).Err)
+
+	_, err = v.MarketBuy(skyBtc, decimal.NewFromFloat(0.1))
+	require.IsType(t, RuleError{}, err)
+	require.Equal(t, ErrInsufficientBalance, err.(RuleError).Err)
+
+	_, err = v.MarketBuy(NewPair(ETH, BTC), decimal.NewFromFloat(0.01))
+	require.Equal(t, ErrRulesNotFound, err)
+
+	// only the valid orders were placed
+	open, err := e.GetOpenOrders(skyBtc)
+	require.NoError(t, err)
+	require.Len(t, open, 2)
+}
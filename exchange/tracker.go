@@ -0,0 +1,223 @@
+package exchange
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultOrdersRefreshInterval is the Tracker's polling interval if none is specified
+const DefaultOrdersRefreshInterval = 5 * time.Second
+
+// Tracker polls an Exchange in the background and keeps the latest state of its orders in memory.
+// Open orders of the tracked markets are discovered automatically. Orders which disappear from the
+// open orders are queried once more to record their final status, fill amount and fee.
+type Tracker struct {
+	Exchange              Exchange
+	Pairs                 []Pair
+	OrdersRefreshInterval time.Duration
+	// Stop stops Run when closed
+	Stop chan struct{}
+	// OnChange, if set, is called with the new state of an order whenever it is added or changes,
+	// e.g. to persist it. It is called from the goroutine which updated the order.
+	OnChange func(Order)
+
+	mu      sync.RWMutex
+	orders  map[OrderID]Order
+	updated time.Time
+	err     error
+}
+
+// NewTracker creates a Tracker for the given markets of an Exchange
+func NewTracker(e Exchange, interval time.Duration, pairs ...Pair) *Tracker {
+	if interval <= 0 {
+		interval = DefaultOrdersRefreshInterval
+	}
+
+	return &Tracker{
+		Exchange:              e,
+		Pairs:                 pairs,
+		OrdersRefreshInterval: interval,
+		Stop:                  make(chan struct{}),
+		orders:                make(map[OrderID]Order),
+	}
+}
+
+// Run refreshes the orders every OrdersRefreshInterval until Stop is closed.
+// Errors do not stop the Tracker, the latest one is available from Err.
+func (t *Tracker) Run() {
+	ticker := time.NewTicker(t.OrdersRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		t.Update() // nolint: errcheck
+
+		select {
+		case <-t.Stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Update polls the exchange once and refreshes the state of all tracked orders
+func (t *Tracker) Update() error {
+	err := t.update()
+
+	t.mu.Lock()
+	t.err = err
+	if err == nil {
+		t.updated = time.Now().UTC()
+	}
+	t.mu.Unlock()
+
+	return err
+}
+
+func (t *Tracker) update() error {
+	codec := t.Exchange.Codec()
+	for _, pair := range t.Pairs {
+		open, err := t.Exchange.GetOpenOrders(pair)
+		if err != nil {
+			return err
+		}
+
+		seen := make(map[OrderID]struct{}, len(open))
+		for _, o := range open {
+			seen[o.OrderID] = struct{}{}
+			t.set(o)
+		}
+
+		// Orders that are no longer open were filled or cancelled since the last update
+		for _, o := range t.Open() {
+			if p, err := codec.DecodePair(o.Symbol); err != nil || p != pair {
+				continue
+			}
+			if _, ok := seen[o.OrderID]; ok {
+				continue
+			}
+
+			order, err := t.Exchange.GetOrderStatus(pair, o.OrderID)
+			switch err {
+			case nil:
+				t.set(*order)
+			case ErrOrderNotFound:
+				o.Status = StatusUnknown
+				t.set(o)
+			default:
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (t *Tracker) set(o Order) {
+	t.mu.Lock()
+	prev, ok := t.orders[o.OrderID]
+	t.orders[o.OrderID] = o
+	t.mu.Unlock()
+
+	if t.OnChange != nil && (!ok || orderChanged(prev, o)) {
+		t.OnChange(o)
+	}
+}
+
+func orderChanged(a, b Order) bool {
+	return a.Status != b.Status || !a.Filled.Equal(b.Filled) || !a.Fee.Equal(b.Fee) ||
+		!a.AvgPrice.Equal(b.AvgPrice) || !a.Completed.Equal(b.Completed)
+}
+
+// Add starts tracking an order, e.g. one that was just placed.
+// If the order's market is not one of the Tracker's Pairs, its state is refreshed only by calls to Refresh.
+func (t *Tracker) Add(o Order) {
+	t.set(o)
+}
+
+// Refresh queries the exchange for the latest state of an order and records it
+func (t *Tracker) Refresh(pair Pair, orderID OrderID) (*Order, error) {
+	order, err := t.Exchange.GetOrderStatus(pair, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	t.set(*order)
+	return order, nil
+}
+
+// Remove stops tracking an order
+func (t *Tracker) Remove(orderID OrderID) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.orders, orderID)
+}
+
+// Get returns the latest known state of an order
+func (t *Tracker) Get(orderID OrderID) (Order, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	o, ok := t.orders[orderID]
+	return o, ok
+}
+
+// Status returns the latest known status of an order
+func (t *Tracker) Status(orderID OrderID) (OrderStatus, error) {
+	o, ok := t.Get(orderID)
+	if !ok {
+		return StatusUnknown, ErrOrderNotFound
+	}
+	return o.Status, nil
+}
+
+// Orders returns all tracked orders, ordered by ID
+func (t *Tracker) Orders() []Order {
+	return t.filter(func(Order) bool { return true })
+}
+
+// Open returns the tracked orders which can still be filled
+func (t *Tracker) Open() []Order {
+	return t.filter(func(o Order) bool { return o.Status.Open() })
+}
+
+// Completed returns the tracked orders which were completely filled
+func (t *Tracker) Completed() []Order {
+	return t.filter(func(o Order) bool { return o.Status == StatusCompleted })
+}
+
+// Cancelled returns the tracked orders which were cancelled
+func (t *Tracker) Cancelled() []Order {
+	return t.filter(func(o Order) bool { return o.Status == StatusCancelled })
+}
+
+// LastUpdate returns the time of the last successful update
+func (t *Tracker) LastUpdate() time.Time {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.updated
+}
+
+// Err returns the error of the last update, if any
+func (t *Tracker) Err() error {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.err
+}
+
+func (t *Tracker) filter(f func(Order) bool) []Order {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var result []Order
+	for _, o := range t.orders {
+		if f(o) {
+			result = append(result, o)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].OrderID < result[j].OrderID
+	})
+
+	return result
+}
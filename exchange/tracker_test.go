@@ -0,0 +1,170 @@
+package exchange
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackerUpdate(t *testing.T) {
+	e := newFakeExchange()
+	// orders are matched to the tracked pairs by decoding their symbols
+	e.codec = quoteFirstCodec
+	tracker := NewTracker(e, time.Second, skyBtc)
+	var changes []Order
+	tracker.OnChange = func(o Order) {
+		changes = append(changes, o)
+	}
+
+	buyID, err := e.LimitBuy(skyBtc, decimal.New(1, -3), decimal.New(10, 0))
+	require.NoError(t, err)
+	sellID, err := e.LimitSell(skyBtc, decimal.New(2, -3), decimal.New(5, 0))
+	require.NoError(t, err)
+	otherID, err := e.LimitSell(ltcBtc, decimal.New(2, -3), decimal.New(5, 0))
+	require.NoError(t, err)
+
+	require.NoError(t, tracker.Update())
+	require.Len(t, tracker.Orders(), 2)
+	require.Len(t, tracker.Open(), 2)
+	_, ok := tracker.Get(otherID)
+	require.False(t, ok)
+	require.False(t, tracker.LastUpdate().IsZero())
+	require.Len(t, changes, 2)
+
+	// Unchanged orders are not reported again
+	require.NoError(t, tracker.Update())
+	require.Len(t, changes, 2)
+
+	// A partial fill is picked up from the open orders
+	e.fill(buyID, decimal.New(4, 0), decimal.New(1, -5))
+	require.NoError(t, tracker.Update())
+	o, ok := tracker.Get(buyID)
+	require.True(t, ok)
+	require.Equal(t, StatusPartial, o.Status)
+	require.True(t, o.Filled.Equal(decimal.New(4, 0)))
+	require.Len(t, changes, 3)
+	require.Equal(t, buyID, changes[2].OrderID)
+
+	// Completed and cancelled orders are no longer open, their final state is queried
+	e.fill(buyID, decimal.New(6, 0), decimal.New(1, -5))
+	require.NoError(t, e.CancelOrder(skyBtc, sellID))
+	require.NoError(t, tracker.Update())
+
+	require.Empty(t, tracker.Open())
+	completed := tracker.Completed()
+	require.Len(t, completed, 1)
+	require.Equal(t, buyID, completed[0].OrderID)
+	require.True(t, completed[0].Fee.Equal(decimal.New(2, -5)))
+
+	status, err := tracker.Status(sellID)
+	require.NoError(t, err)
+	require.Equal(t, StatusCancelled, status)
+	require.Len(t, tracker.Cancelled(), 1)
+
+	_, err = tracker.Status(otherID)
+	require.Equal(t, ErrOrderNotFound, err)
+}
+
+func TestTrackerDisappearsAfterFill(t *testing.T) {
+	e := newFakeExchange()
+	tracker := NewTracker(e, time.Second, skyBtc)
+	var changes []Order
+	tracker.OnChange = func(o Order) {
+		changes = append(changes, o)
+	}
+
+	filledID, err := e.LimitBuy(skyBtc, decimal.New(1, -3), decimal.New(10, 0))
+	require.NoError(t, err)
+	lostID, err := e.LimitBuy(skyBtc, decimal.New(1, -3), decimal.New(10, 0))
+	require.NoError(t, err)
+
+	e.fill(filledID, decimal.New(4, 0), decimal.New(1, -5))
+	e.fill(lostID, decimal.New(4, 0), decimal.New(1, -5))
+	require.NoError(t, tracker.Update())
+	require.Len(t, changes, 2)
+
+	// filledID is filled between two updates, lostID disappears without a record on the exchange
+	e.fill(filledID, decimal.New(6, 0), decimal.New(2, -5))
+	e.Lock()
+	delete(e.orders, lostID)
+	e.Unlock()
+	require.NoError(t, tracker.Update())
+	require.Empty(t, tracker.Open())
+	require.Len(t, changes, 4)
+
+	// the final fill is reported
+	final := changes[2]
+	require.Equal(t, filledID, final.OrderID)
+	require.Equal(t, StatusCompleted, final.Status)
+	require.True(t, final.Filled.Equal(decimal.New(10, 0)))
+	require.True(t, final.Fee.Equal(decimal.New(3, -5)))
+
+	// the last known fill is kept
+	lost := changes[3]
+	require.Equal(t, lostID, lost.OrderID)
+	require.Equal(t, StatusUnknown, lost.Status)
+	require.True(t, lost.Filled.Equal(decimal.New(4, 0)))
+}
+
+func TestTrackerAddAndRefresh(t *testing.T) {
+	e := newFakeExchange()
+	tracker := NewTracker(e, time.Second)
+
+	id, err := e.LimitBuy(ltcBtc, decimal.New(1, -3), decimal.New(10, 0))
+	require.NoError(t, err)
+
+	tracker.Add(Order{OrderID: id, Symbol: "LTC/BTC", Status: StatusPending})
+	status, err := tracker.Status(id)
+	require.NoError(t, err)
+	require.Equal(t, StatusPending, status)
+
+	o, err := tracker.Refresh(ltcBtc, id)
+	require.NoError(t, err)
+	require.Equal(t, StatusOpened, o.Status)
+
+	tracker.Remove(id)
+	require.Empty(t, tracker.Orders())
+}
+
+func TestTrackerRun(t *testing.T) {
+	e := newFakeExchange()
+	tracker := NewTracker(e, time.Millisecond*10, skyBtc)
+
+	_, err := e.LimitBuy(skyBtc, decimal.New(1, -3), decimal.New(10, 0))
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		tracker.Run()
+		close(done)
+	}()
+
+	waitFor(t, func() bool {
+		return len(tracker.Open()) == 1
+	})
+
+	e.Lock()
+	e.err = errors.New("exchange unavailable")
+	e.Unlock()
+
+	waitFor(t, func() bool {
+		return tracker.Err() != nil
+	})
+
+	close(tracker.Stop)
+	<-done
+}
+
+// waitFor polls cond until it is true, failing the test after a second
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before deadline")
+		}
+		time.Sleep(time.Millisecond * 5)
+	}
+}
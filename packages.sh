@@ -1,7 +1,7 @@
 #!/bin/sh
 
 # every source file ending in .go
-find ./exchange -path '.*\.go' | \
+find ./exchange ./db ./server -path '.*\.go' | \
 
 while read src_path
 do
@@ -0,0 +1,397 @@
+// Package server implements an HTTP/JSON API exposing exchange.Exchange implementations.
+//
+// Endpoints, where {exchange} is the name of a configured exchange:
+//
+//	GET    /api/v1/exchanges
+//	GET    /api/v1/{exchange}/orderbook?symbol=
+//	GET    /api/v1/{exchange}/ticker?symbol=
+//	GET    /api/v1/{exchange}/balances
+//	GET    /api/v1/{exchange}/orders?symbol=        open orders
+//	POST   /api/v1/{exchange}/orders                places an order, see OrderRequest
+//	GET    /api/v1/{exchange}/orders/{id}?symbol=   order status
+//	DELETE /api/v1/{exchange}/orders/{id}?symbol=   cancels an order
+//
+// Placing and cancelling orders requires the header "Authorization: Bearer <token>" with the Server's Token.
+// Errors are returned as {"error": "message"} with a 4xx or 5xx status code.
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/skycoin/exchange-api/exchange"
+)
+
+const apiPrefix = "/api/v1/"
+
+// Order types accepted by OrderRequest
+const (
+	OrderTypeLimit  = "limit"
+	OrderTypeMarket = "market"
+)
+
+// OrderRequest is the body of an order placement request.
+// Limit orders require Price and Volume. Market buy orders require Amount, the quantity of the
+// quote currency to spend. Market sell orders require Volume.
+type OrderRequest struct {
+	Symbol string           `json:"symbol"`
+	Action exchange.Action  `json:"action"`
+	Type   string           `json:"type"`
+	Price  *decimal.Decimal `json:"price,omitempty"`
+	Volume *decimal.Decimal `json:"volume,omitempty"`
+	Amount *decimal.Decimal `json:"amount,omitempty"`
+}
+
+// OrderResponse is the response to an order placement request
+type OrderResponse struct {
+	OrderID exchange.OrderID `json:"order_id"`
+	// FilledInstantly is true if the order was completely filled as it was placed and has no OrderID
+	FilledInstantly bool `json:"filled_instantly,omitempty"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Server serves the API for a set of exchanges
+type Server struct {
+	exchanges map[string]exchange.Exchange
+	// Token is the bearer token required to place and cancel orders. If empty, orders can't be placed or cancelled.
+	Token string
+	// Logger receives an audit line for every request, and one with the parameters and result of every
+	// order placement or cancellation. Nil disables logging.
+	Logger *log.Logger
+}
+
+// New creates a Server for the given exchanges, addressed by their Name
+func New(exchanges ...exchange.Exchange) *Server {
+	s := &Server{
+		exchanges: make(map[string]exchange.Exchange, len(exchanges)),
+	}
+	for _, e := range exchanges {
+		s.exchanges[e.Name()] = e
+	}
+	return s
+}
+
+type httpError struct {
+	status int
+	err    error
+}
+
+func (e httpError) Error() string {
+	return e.err.Error()
+}
+
+func badRequest(format string, args ...interface{}) error {
+	return httpError{http.StatusBadRequest, fmt.Errorf(format, args...)}
+}
+
+func notFound(err error) error {
+	return httpError{http.StatusNotFound, err}
+}
+
+var errUnauthorized = httpError{http.StatusUnauthorized, errors.New("a valid bearer token is required")}
+
+// authorize checks the bearer token of a request which places or cancels an order
+func (s *Server) authorize(r *http.Request) error {
+	const prefix = "Bearer "
+	header := r.Header.Get("Authorization")
+	if s.Token == "" || !strings.HasPrefix(header, prefix) {
+		return errUnauthorized
+	}
+	if subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(header, prefix)), []byte(s.Token)) != 1 {
+		return errUnauthorized
+	}
+	return nil
+}
+
+// audit logs the parameters and result of a request changing orders
+func (s *Server) audit(r *http.Request, format string, args ...interface{}) {
+	if s.Logger != nil {
+		s.Logger.Printf("%s %s", r.RemoteAddr, fmt.Sprintf(format, args...))
+	}
+}
+
+func auditResult(err error) string {
+	if err == exchange.ErrFilledInstantly {
+		return "filled_instantly"
+	}
+	if err != nil {
+		return fmt.Sprintf("error=%q", err)
+	}
+	return "ok"
+}
+
+func auditDecimal(d *decimal.Decimal) string {
+	if d == nil {
+		return "-"
+	}
+	return d.String()
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	result, err := s.route(r)
+
+	status := http.StatusOK
+	if err != nil {
+		status = http.StatusBadGateway
+		switch e := err.(type) {
+		case httpError:
+			status = e.status
+		default:
+			if err == exchange.ErrOrderNotFound {
+				status = http.StatusNotFound
+			}
+		}
+		result = errorResponse{Error: err.Error()}
+	}
+
+	if s.Logger != nil {
+		if err != nil {
+			s.Logger.Printf("%s %s %s status=%d error=%q", r.RemoteAddr, r.Method, r.URL.RequestURI(), status, err)
+		} else {
+			s.Logger.Printf("%s %s %s status=%d", r.RemoteAddr, r.Method, r.URL.RequestURI(), status)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(result) // nolint: errcheck
+}
+
+func (s *Server) route(r *http.Request) (interface{}, error) {
+	if !strings.HasPrefix(r.URL.Path, apiPrefix) {
+		return nil, notFound(errors.New("not found"))
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, apiPrefix), "/"), "/")
+
+	if len(parts) == 1 && parts[0] == "exchanges" {
+		if r.Method != http.MethodGet {
+			return nil, methodNotAllowed(r)
+		}
+		return s.exchangeNames(), nil
+	}
+
+	e, ok := s.exchanges[parts[0]]
+	if !ok {
+		return nil, notFound(fmt.Errorf("unknown exchange %q", parts[0]))
+	}
+
+	switch {
+	case len(parts) == 2 && parts[1] == "orderbook" && r.Method == http.MethodGet:
+		return getOrderbook(e, r)
+	case len(parts) == 2 && parts[1] == "ticker" && r.Method == http.MethodGet:
+		return getTicker(e, r)
+	case len(parts) == 2 && parts[1] == "balances" && r.Method == http.MethodGet:
+		return e.GetBalances()
+	case len(parts) == 2 && parts[1] == "orders" && r.Method == http.MethodGet:
+		return getOpenOrders(e, r)
+	case len(parts) == 2 && parts[1] == "orders" && r.Method == http.MethodPost:
+		if err := s.authorize(r); err != nil {
+			return nil, err
+		}
+		return s.placeOrder(parts[0], e, r)
+	case len(parts) == 3 && parts[1] == "orders" && r.Method == http.MethodGet:
+		return orderStatus(e, r, parts[2])
+	case len(parts) == 3 && parts[1] == "orders" && r.Method == http.MethodDelete:
+		if err := s.authorize(r); err != nil {
+			return nil, err
+		}
+		return s.cancelOrder(parts[0], e, r, parts[2])
+	case len(parts) == 2 || len(parts) == 3:
+		return nil, methodNotAllowed(r)
+	default:
+		return nil, notFound(errors.New("not found"))
+	}
+}
+
+func methodNotAllowed(r *http.Request) error {
+	return httpError{http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method)}
+}
+
+func (s *Server) exchangeNames() []string {
+	names := make([]string, 0, len(s.exchanges))
+	for name := range s.exchanges {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func symbolParam(r *http.Request) (string, error) {
+	symbol := r.URL.Query().Get("symbol")
+	if symbol == "" {
+		return "", badRequest("symbol is required")
+	}
+	return symbol, nil
+}
+
+// decodeSymbol decodes a symbol in the exchange's own format
+func decodeSymbol(e exchange.Exchange, symbol string) (exchange.Pair, error) {
+	pair, err := e.Codec().DecodePair(symbol)
+	if err != nil {
+		return pair, badRequest("invalid symbol %q", symbol)
+	}
+	return pair, nil
+}
+
+// pairParam returns the decoded symbol parameter
+func pairParam(e exchange.Exchange, r *http.Request) (exchange.Pair, error) {
+	symbol, err := symbolParam(r)
+	if err != nil {
+		return exchange.Pair{}, err
+	}
+	return decodeSymbol(e, symbol)
+}
+
+func orderIDParam(s string) (exchange.OrderID, error) {
+	id, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, badRequest("invalid order id %q", s)
+	}
+	return exchange.OrderID(id), nil
+}
+
+func getOrderbook(e exchange.Exchange, r *http.Request) (interface{}, error) {
+	pair, err := pairParam(e, r)
+	if err != nil {
+		return nil, err
+	}
+	return e.GetOrderbook(pair)
+}
+
+func getTicker(e exchange.Exchange, r *http.Request) (interface{}, error) {
+	pair, err := pairParam(e, r)
+	if err != nil {
+		return nil, err
+	}
+	return e.GetTicker(pair)
+}
+
+func getOpenOrders(e exchange.Exchange, r *http.Request) (interface{}, error) {
+	pair, err := pairParam(e, r)
+	if err != nil {
+		return nil, err
+	}
+
+	orders, err := e.GetOpenOrders(pair)
+	if orders == nil {
+		orders = []exchange.Order{}
+	}
+	return orders, err
+}
+
+func (s *Server) placeOrder(name string, e exchange.Exchange, r *http.Request) (interface{}, error) {
+	var req OrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, badRequest("invalid request body: %v", err)
+	}
+
+	orderID, err := place(e, req)
+	s.audit(r, "place exchange=%s symbol=%s action=%s type=%s price=%s volume=%s amount=%s order_id=%d %s", name, req.Symbol,
+		req.Action, req.Type, auditDecimal(req.Price), auditDecimal(req.Volume), auditDecimal(req.Amount), orderID, auditResult(err))
+	if err == exchange.ErrFilledInstantly {
+		return OrderResponse{FilledInstantly: true}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return OrderResponse{OrderID: orderID}, nil
+}
+
+func place(e exchange.Exchange, req OrderRequest) (exchange.OrderID, error) {
+	if req.Symbol == "" {
+		return 0, badRequest("symbol is required")
+	}
+
+	pair, err := decodeSymbol(e, req.Symbol)
+	if err != nil {
+		return 0, err
+	}
+
+	var orderID exchange.OrderID
+
+	switch req.Type {
+	case OrderTypeLimit:
+		if req.Price == nil || req.Volume == nil {
+			return 0, badRequest("limit orders require price and volume")
+		}
+		switch req.Action {
+		case exchange.ActionBuy:
+			orderID, err = e.LimitBuy(pair, *req.Price, *req.Volume)
+		case exchange.ActionSell:
+			orderID, err = e.LimitSell(pair, *req.Price, *req.Volume)
+		default:
+			return 0, badRequest("invalid action %q", req.Action)
+		}
+	case OrderTypeMarket:
+		switch req.Action {
+		case exchange.ActionBuy:
+			if req.Amount == nil {
+				return 0, badRequest("market buy orders require amount")
+			}
+			orderID, err = e.MarketBuy(pair, *req.Amount)
+		case exchange.ActionSell:
+			if req.Volume == nil {
+				return 0, badRequest("market sell orders require volume")
+			}
+			orderID, err = e.MarketSell(pair, *req.Volume)
+		default:
+			return 0, badRequest("invalid action %q", req.Action)
+		}
+	default:
+		return 0, badRequest("invalid order type %q", req.Type)
+	}
+
+	return orderID, err
+}
+
+func orderStatus(e exchange.Exchange, r *http.Request, id string) (interface{}, error) {
+	pair, err := pairParam(e, r)
+	if err != nil {
+		return nil, err
+	}
+
+	orderID, err := orderIDParam(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.GetOrderStatus(pair, orderID)
+}
+
+func (s *Server) cancelOrder(name string, e exchange.Exchange, r *http.Request, id string) (interface{}, error) {
+	symbol, err := symbolParam(r)
+	if err != nil {
+		return nil, err
+	}
+
+	orderID, err := orderIDParam(id)
+	if err != nil {
+		return nil, err
+	}
+
+	pair, err := decodeSymbol(e, symbol)
+	if err == nil {
+		err = e.CancelOrder(pair, orderID)
+	}
+	s.audit(r, "cancel exchange=%s symbol=%s order_id=%d %s", name, symbol, orderID, auditResult(err))
+	if err != nil {
+		return nil, err
+	}
+
+	return OrderResponse{OrderID: orderID}, nil
+}
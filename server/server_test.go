@@ -0,0 +1,259 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/exchange-api/exchange"
+)
+
+type fakeExchange struct {
+	placed    []exchange.Order
+	cancelled []exchange.OrderID
+	err       error
+}
+
+func (e *fakeExchange) Name() string {
+	return "fake"
+}
+
+func (e *fakeExchange) Codec() exchange.PairCodec {
+	return exchange.CanonicalCodec
+}
+
+func (e *fakeExchange) GetOrderbook(pair exchange.Pair) (*exchange.MarketRecord, error) {
+	if pair != exchange.NewPair(exchange.SKY, exchange.BTC) {
+		return nil, errors.New("unknown market")
+	}
+	return &exchange.MarketRecord{
+		Symbol: pair.String(),
+		Bids:   []exchange.MarketOrder{{Price: decimal.New(1, -3), Volume: decimal.New(10, 0)}},
+		Asks:   []exchange.MarketOrder{{Price: decimal.New(2, -3), Volume: decimal.New(5, 0)}},
+	}, nil
+}
+
+func (e *fakeExchange) GetTicker(pair exchange.Pair) (*exchange.Ticker, error) {
+	return &exchange.Ticker{Symbol: pair.String(), Last: decimal.New(15, -4)}, nil
+}
+
+func (e *fakeExchange) GetBalances() (exchange.Balances, error) {
+	return exchange.Balances{"SKY": {Currency: "SKY", Total: decimal.New(3, 0)}}, nil
+}
+
+func (e *fakeExchange) place(pair exchange.Pair, action exchange.Action, price, volume decimal.Decimal) (exchange.OrderID, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+	e.placed = append(e.placed, exchange.Order{
+		OrderID: exchange.OrderID(len(e.placed) + 1),
+		Symbol:  pair.String(),
+		Action:  action,
+		Price:   price,
+		Volume:  volume,
+	})
+	return exchange.OrderID(len(e.placed)), nil
+}
+
+func (e *fakeExchange) LimitBuy(pair exchange.Pair, price, volume decimal.Decimal) (exchange.OrderID, error) {
+	return e.place(pair, exchange.ActionBuy, price, volume)
+}
+
+func (e *fakeExchange) LimitSell(pair exchange.Pair, price, volume decimal.Decimal) (exchange.OrderID, error) {
+	return e.place(pair, exchange.ActionSell, price, volume)
+}
+
+func (e *fakeExchange) MarketBuy(pair exchange.Pair, amount decimal.Decimal) (exchange.OrderID, error) {
+	return e.place(pair, exchange.ActionBuy, decimal.Zero, amount)
+}
+
+func (e *fakeExchange) MarketSell(pair exchange.Pair, volume decimal.Decimal) (exchange.OrderID, error) {
+	return e.place(pair, exchange.ActionSell, decimal.Zero, volume)
+}
+
+func (e *fakeExchange) CancelOrder(pair exchange.Pair, orderID exchange.OrderID) error {
+	e.cancelled = append(e.cancelled, orderID)
+	return nil
+}
+
+func (e *fakeExchange) GetOpenOrders(pair exchange.Pair) ([]exchange.Order, error) {
+	return nil, nil
+}
+
+func (e *fakeExchange) GetOrderStatus(pair exchange.Pair, orderID exchange.OrderID) (*exchange.Order, error) {
+	for _, o := range e.placed {
+		if o.OrderID == orderID {
+			return &o, nil
+		}
+	}
+	return nil, exchange.ErrOrderNotFound
+}
+
+const testToken = "token"
+
+func do(t *testing.T, s *Server, method, target, body string) (int, string) {
+	return doWithToken(t, s, method, target, body, testToken)
+}
+
+func doWithToken(t *testing.T, s *Server, method, target, body, token string) (int, string) {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	return w.Code, strings.TrimSpace(w.Body.String())
+}
+
+func TestServerMarketData(t *testing.T) {
+	s := New(&fakeExchange{})
+
+	code, body := do(t, s, http.MethodGet, "/api/v1/exchanges", "")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, `["fake"]`, body)
+
+	code, body = do(t, s, http.MethodGet, "/api/v1/fake/orderbook?symbol=SKY/BTC", "")
+	require.Equal(t, http.StatusOK, code)
+	var record exchange.MarketRecord
+	require.NoError(t, json.Unmarshal([]byte(body), &record))
+	require.Equal(t, "SKY/BTC", record.Symbol)
+	require.Len(t, record.Asks, 1)
+
+	code, body = do(t, s, http.MethodGet, "/api/v1/fake/orderbook?symbol=LTC/BTC", "")
+	require.Equal(t, http.StatusBadGateway, code)
+	require.Equal(t, `{"error":"unknown market"}`, body)
+
+	code, _ = do(t, s, http.MethodGet, "/api/v1/fake/orderbook", "")
+	require.Equal(t, http.StatusBadRequest, code)
+
+	code, body = do(t, s, http.MethodGet, "/api/v1/fake/orderbook?symbol=SKYBTC", "")
+	require.Equal(t, http.StatusBadRequest, code)
+	require.Equal(t, `{"error":"invalid symbol \"SKYBTC\""}`, body)
+
+	code, body = do(t, s, http.MethodGet, "/api/v1/fake/ticker?symbol=SKY/BTC", "")
+	require.Equal(t, http.StatusOK, code)
+	require.Contains(t, body, `"last":"0.0015"`)
+
+	code, body = do(t, s, http.MethodGet, "/api/v1/fake/balances", "")
+	require.Equal(t, http.StatusOK, code)
+	require.Contains(t, body, `"SKY":{"currency":"SKY","total":"3"`)
+
+	code, body = do(t, s, http.MethodGet, "/api/v1/fake/orders?symbol=SKY/BTC", "")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, `[]`, body)
+
+	code, _ = do(t, s, http.MethodGet, "/api/v1/other/balances", "")
+	require.Equal(t, http.StatusNotFound, code)
+
+	code, _ = do(t, s, http.MethodPut, "/api/v1/fake/balances", "")
+	require.Equal(t, http.StatusMethodNotAllowed, code)
+
+	code, _ = do(t, s, http.MethodGet, "/foo", "")
+	require.Equal(t, http.StatusNotFound, code)
+}
+
+func TestServerOrders(t *testing.T) {
+	e := &fakeExchange{}
+	s := New(e)
+	s.Token = testToken
+
+	tt := []struct {
+		name string
+		body string
+		code int
+	}{
+		{"limit buy", `{"symbol":"SKY/BTC","action":"buy","type":"limit","price":"0.001","volume":"10"}`, http.StatusOK},
+		{"limit sell", `{"symbol":"SKY/BTC","action":"sell","type":"limit","price":"0.002","volume":"5"}`, http.StatusOK},
+		{"market buy", `{"symbol":"SKY/BTC","action":"buy","type":"market","amount":"0.01"}`, http.StatusOK},
+		{"market sell", `{"symbol":"SKY/BTC","action":"sell","type":"market","volume":"2"}`, http.StatusOK},
+		{"limit without price", `{"symbol":"SKY/BTC","action":"buy","type":"limit","volume":"10"}`, http.StatusBadRequest},
+		{"market buy without amount", `{"symbol":"SKY/BTC","action":"buy","type":"market","volume":"10"}`, http.StatusBadRequest},
+		{"invalid action", `{"symbol":"SKY/BTC","action":"hold","type":"limit","price":"1","volume":"10"}`, http.StatusBadRequest},
+		{"invalid type", `{"symbol":"SKY/BTC","action":"buy","type":"stop"}`, http.StatusBadRequest},
+		{"missing symbol", `{"action":"buy","type":"market","amount":"1"}`, http.StatusBadRequest},
+		{"invalid json", `{`, http.StatusBadRequest},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			code, body := do(t, s, http.MethodPost, "/api/v1/fake/orders", tc.body)
+			require.Equal(t, tc.code, code, body)
+		})
+	}
+
+	require.Len(t, e.placed, 4)
+	require.Equal(t, exchange.ActionSell, e.placed[1].Action)
+	require.True(t, e.placed[1].Price.Equal(decimal.New(2, -3)))
+	require.True(t, e.placed[2].Volume.Equal(decimal.New(1, -2)))
+
+	code, body := do(t, s, http.MethodGet, "/api/v1/fake/orders/2?symbol=SKY/BTC", "")
+	require.Equal(t, http.StatusOK, code)
+	var order exchange.Order
+	require.NoError(t, json.Unmarshal([]byte(body), &order))
+	require.Equal(t, exchange.OrderID(2), order.OrderID)
+
+	code, _ = do(t, s, http.MethodGet, "/api/v1/fake/orders/9?symbol=SKY/BTC", "")
+	require.Equal(t, http.StatusNotFound, code)
+
+	code, _ = do(t, s, http.MethodGet, "/api/v1/fake/orders/x?symbol=SKY/BTC", "")
+	require.Equal(t, http.StatusBadRequest, code)
+
+	code, body = do(t, s, http.MethodDelete, "/api/v1/fake/orders/2?symbol=SKY/BTC", "")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, `{"order_id":2}`, body)
+	require.Equal(t, []exchange.OrderID{2}, e.cancelled)
+}
+
+func TestServerAuthorization(t *testing.T) {
+	e := &fakeExchange{}
+	s := New(e)
+	var logs bytes.Buffer
+	s.Logger = log.New(&logs, "", 0)
+
+	order := `{"symbol":"SKY/BTC","action":"buy","type":"limit","price":"0.001","volume":"10"}`
+
+	// without a configured token, orders can't be placed
+	code, _ := do(t, s, http.MethodPost, "/api/v1/fake/orders", order)
+	require.Equal(t, http.StatusUnauthorized, code)
+
+	s.Token = testToken
+	for _, token := range []string{"", "wrong"} {
+		code, _ = doWithToken(t, s, http.MethodPost, "/api/v1/fake/orders", order, token)
+		require.Equal(t, http.StatusUnauthorized, code)
+		code, _ = doWithToken(t, s, http.MethodDelete, "/api/v1/fake/orders/1?symbol=SKY/BTC", "", token)
+		require.Equal(t, http.StatusUnauthorized, code)
+	}
+	require.Empty(t, e.placed)
+	require.Empty(t, e.cancelled)
+
+	// reads don't require the token
+	code, _ = doWithToken(t, s, http.MethodGet, "/api/v1/fake/balances", "", "")
+	require.Equal(t, http.StatusOK, code)
+
+	// orders are audited with their parameters and results
+	logs.Reset()
+	code, _ = do(t, s, http.MethodPost, "/api/v1/fake/orders", order)
+	require.Equal(t, http.StatusOK, code)
+	code, _ = do(t, s, http.MethodDelete, "/api/v1/fake/orders/1?symbol=SKY/BTC", "")
+	require.Equal(t, http.StatusOK, code)
+
+	e.err = exchange.ErrFilledInstantly
+	code, body := do(t, s, http.MethodPost, "/api/v1/fake/orders", order)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, `{"order_id":0,"filled_instantly":true}`, body)
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	require.Len(t, lines, 6)
+	require.Equal(t, "192.0.2.1:1234 place exchange=fake symbol=SKY/BTC action=buy type=limit price=0.001 volume=10 amount=- order_id=1 ok", lines[0])
+	require.Equal(t, "192.0.2.1:1234 cancel exchange=fake symbol=SKY/BTC order_id=1 ok", lines[2])
+	require.Contains(t, lines[4], "order_id=0 filled_instantly")
+}